@@ -0,0 +1,141 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// Kind classifies a domain error, it decides the response status
+type Kind int
+
+const (
+	// KindInvalid is an error of a request that can't be processed as sent
+	KindInvalid Kind = iota + 1
+	// KindUnauthorized is an error of missing or wrong credentials
+	KindUnauthorized
+	// KindForbidden is an error of an action the user isn't allowed to do
+	KindForbidden
+	// KindNotFound is an error of a resource that doesn't exist
+	KindNotFound
+	// KindConflict is an error of a resource that already exists or changed state
+	KindConflict
+	// KindQuotaExceeded is an error of an action over the limits of the user's plan
+	KindQuotaExceeded
+	// KindRateLimited is an error of an action repeated too often
+	KindRateLimited
+	// KindUpstream is an error of an external service the request depends on
+	KindUpstream
+)
+
+var kindStatus = map[Kind]int{
+	KindInvalid:       http.StatusBadRequest,
+	KindUnauthorized:  http.StatusUnauthorized,
+	KindForbidden:     http.StatusForbidden,
+	KindNotFound:      http.StatusNotFound,
+	KindConflict:      http.StatusConflict,
+	KindQuotaExceeded: http.StatusPaymentRequired,
+	KindRateLimited:   http.StatusTooManyRequests,
+	KindUpstream:      http.StatusBadGateway,
+}
+
+// Error is an error of the domain returned by services, Code and Message are shown to the client
+type Error struct {
+	Kind    Kind
+	Code    string // stable machine-readable code, e.g. group_not_found
+	Message string
+	Data    interface{} // details shown along the message, e.g. invalid fields
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Status returns the response status of the error
+func (e *Error) Status() int {
+	return kindStatus[e.Kind]
+}
+
+// Invalid creates an error of a request that can't be processed as sent
+func Invalid(code, format string, a ...interface{}) *Error {
+	return &Error{Kind: KindInvalid, Code: code, Message: message(format, a)}
+}
+
+// Validation creates an error of an invalid request body with the invalid fields as data
+func Validation(code, message string, fields interface{}) *Error {
+	return &Error{Kind: KindInvalid, Code: code, Message: message, Data: fields}
+}
+
+// Unauthorized creates an error of missing or wrong credentials
+func Unauthorized(code, format string, a ...interface{}) *Error {
+	return &Error{Kind: KindUnauthorized, Code: code, Message: message(format, a)}
+}
+
+// Forbidden creates an error of an action the user isn't allowed to do
+func Forbidden(code, format string, a ...interface{}) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message(format, a)}
+}
+
+// NotFound creates an error of a resource that doesn't exist
+func NotFound(code, format string, a ...interface{}) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message(format, a)}
+}
+
+// Conflict creates an error of a resource that already exists or changed state
+func Conflict(code, format string, a ...interface{}) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message(format, a)}
+}
+
+// QuotaExceeded creates an error of an action over the limits of the user's plan
+func QuotaExceeded(code, format string, a ...interface{}) *Error {
+	return &Error{Kind: KindQuotaExceeded, Code: code, Message: message(format, a)}
+}
+
+// RateLimited creates an error of an action repeated too often
+func RateLimited(code, format string, a ...interface{}) *Error {
+	return &Error{Kind: KindRateLimited, Code: code, Message: message(format, a)}
+}
+
+// Upstream creates an error of an external service the request depends on
+func Upstream(code, format string, a ...interface{}) *Error {
+	return &Error{Kind: KindUpstream, Code: code, Message: message(format, a)}
+}
+
+func message(format string, a []interface{}) string {
+	if len(a) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, a...)
+}
+
+// As returns the domain error in the chain of err, a record not found error of GORM is
+// a not found error
+func As(err error) (*Error, bool) {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr, true
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NotFound("not_found", "Record not found."), true
+	}
+
+	return nil, false
+}
+
+// IsNotFound reports whether err is a not found error, including GORM record not found
+func IsNotFound(err error) bool {
+	domainErr, ok := As(err)
+	return ok && domainErr.Kind == KindNotFound
+}
+
+// Status returns the response status of an error, errors outside the domain are failures of
+// the database or other services
+func Status(err error) int {
+	if domainErr, ok := As(err); ok {
+		return domainErr.Status()
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,59 @@
+package apperror_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"Invalid", apperror.Invalid("month_invalid", "Month invalid."), http.StatusBadRequest},
+		{"Unauthorized", apperror.Unauthorized("password_incorrect", "Password incorrect."), http.StatusUnauthorized},
+		{"Forbidden", apperror.Forbidden("organization_forbidden", "Your organization role can't do this."), http.StatusForbidden},
+		{"Not found", apperror.NotFound("user_not_found", "User with ID %v not found.", 5), http.StatusNotFound},
+		{"Conflict", apperror.Conflict("group_already_joined", "You already joined this group."), http.StatusConflict},
+		{"Quota exceeded", apperror.QuotaExceeded("group_full", "Group is full."), http.StatusPaymentRequired},
+		{"Rate limited", apperror.RateLimited("rate_limited", "Too many requests."), http.StatusTooManyRequests},
+		{"Upstream", apperror.Upstream("oauth_provider_failed", "Provider unreachable."), http.StatusBadGateway},
+		{"Wrapped", fmt.Errorf("join: %w", apperror.Conflict("group_already_joined", "You already joined this group.")), http.StatusConflict},
+		{"Record not found", gorm.ErrRecordNotFound, http.StatusNotFound},
+		{"Database failure", errors.New("connection refused"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.status, apperror.Status(tt.err))
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	assert.Equal(t, "User with ID 5 not found.", apperror.NotFound("user_not_found", "User with ID %v not found.", 5).Error())
+	assert.Equal(t, "100% used.", apperror.Invalid("month_invalid", "100% used.").Error())
+}
+
+func TestCode(t *testing.T) {
+	domainErr, ok := apperror.As(fmt.Errorf("join: %w", apperror.QuotaExceeded("group_full", "Group is full.")))
+	assert.True(t, ok)
+	assert.Equal(t, "group_full", domainErr.Code)
+
+	domainErr, _ = apperror.As(gorm.ErrRecordNotFound)
+	assert.Equal(t, "not_found", domainErr.Code)
+}
+
+func TestIsNotFound(t *testing.T) {
+	assert.True(t, apperror.IsNotFound(gorm.ErrRecordNotFound))
+	assert.True(t, apperror.IsNotFound(fmt.Errorf("find: %w", gorm.ErrRecordNotFound)))
+	assert.True(t, apperror.IsNotFound(apperror.NotFound("group_not_found", "Group not found.")))
+	assert.False(t, apperror.IsNotFound(apperror.Conflict("group_already_joined", "You already joined this group.")))
+	assert.False(t, apperror.IsNotFound(errors.New("connection refused")))
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"crypto/subtle"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderAdminToken is a request header to send the admin token
+const HeaderAdminToken = "X-Admin-Token"
+
+// adminToken is a secret token of operators, admin endpoints are disabled when it's empty
+var adminToken string
+
+// RequireAdmin is a middleware that only lets requests with the admin token through
+func RequireAdmin(c *fiber.Ctx) error {
+	if adminToken == "" {
+		return fiber.ErrNotFound
+	}
+
+	if subtle.ConstantTimeCompare([]byte(c.Get(HeaderAdminToken)), []byte(adminToken)) != 1 {
+		return apperror.Unauthorized("admin_token_invalid", "Admin token invalid.")
+	}
+
+	return c.Next()
+}
+
+// metricsToken is a secret token of the metrics scraper, metrics aren't served when it's empty
+var metricsToken string
+
+// RequireMetrics is a middleware that only lets requests with the metrics token sent as a bearer
+// token through, which Prometheus sends with the authorization of its scrape config
+func RequireMetrics(c *fiber.Ctx) error {
+	if metricsToken == "" {
+		return fiber.ErrNotFound
+	}
+
+	if subtle.ConstantTimeCompare([]byte(c.Get(fiber.HeaderAuthorization)), []byte("Bearer "+metricsToken)) != 1 {
+		return apperror.Unauthorized("metrics_token_invalid", "Metrics token invalid.")
+	}
+
+	return c.Next()
+}
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"github.com/dinopuguh/mycap-backend/config"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+const localsUserID = "user_id"
+
+// Configure sets the secrets used to sign JWT and authenticate operators and the metrics scraper
+func Configure(cfg config.Auth) {
+	signingKey = []byte(cfg.JWTSecret)
+	adminToken = cfg.AdminToken
+	metricsToken = cfg.MetricsToken
+}
+
+// SetUser records the ID of the user authenticated by a JWT or an API key, the ID is added to
+// the request logger
+func SetUser(c *fiber.Ctx, userID uint) {
+	c.Locals(localsUserID, userID)
+	logger.SetUser(c, userID)
+}
+
+// UserID returns the ID of the authenticated user, 0 for anonymous requests
+func UserID(c *fiber.Ctx) uint {
+	userID, _ := c.Locals(localsUserID).(uint)
+	return userID
+}
@@ -2,29 +2,48 @@ package auth
 
 import (
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/gofiber/fiber/v2"
 )
 
-// SigningKey is a secret key to store in jwt claims
-var SigningKey = []byte(os.Getenv("MYCAP_JWT_TOKEN"))
+// signingKey is a secret key to sign JWT
+var signingKey = []byte{}
+
+// SigningKey returns the secret key to verify JWT
+func SigningKey() []byte {
+	return signingKey
+}
 
 // GenerateJWT creates JWT token from payload
-func GenerateJWT(name, email string) (string, error) {
+func GenerateJWT(name, email string, sessionID uint) (string, error) {
 	token := jwt.New(jwt.SigningMethodHS256)
 
 	claims := token.Claims.(jwt.MapClaims)
 	claims["name"] = name
 	claims["email"] = email
+	claims["sid"] = sessionID
 	claims["issued"] = time.Now().Unix()
 	claims["exp"] = time.Now().Add(time.Hour * 72).Unix()
 
-	t, err := token.SignedString(SigningKey)
+	t, err := token.SignedString(signingKey)
 	if err != nil {
 		return "", fmt.Errorf("Failed to generate JWT")
 	}
 
 	return t, nil
 }
+
+// TokenError responds a failed JWT verification like the default handler of the JWT middleware,
+// with the error code of the failure for problem details
+func TokenError(c *fiber.Ctx, err error) error {
+	if err.Error() == "Missing or malformed JWT" {
+		response.SetCode(c, "token_missing")
+		return c.Status(fiber.StatusBadRequest).SendString("Missing or malformed JWT")
+	}
+
+	response.SetCode(c, "token_invalid")
+	return c.Status(fiber.StatusUnauthorized).SendString("Invalid or expired JWT")
+}
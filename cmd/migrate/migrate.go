@@ -1,14 +1,69 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/dinopuguh/mycap-backend/config"
 	"github.com/dinopuguh/mycap-backend/database"
 	"github.com/dinopuguh/mycap-backend/migrations"
 )
 
 func main() {
-	if err := database.Connect(); err != nil {
+	steps := flag.Int("steps", 1, "Number of migrations rolled back by down")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: migrate [-steps n] [up|down|status]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	command := flag.Arg(0)
+	if command == "" {
+		command = "up"
+	}
+
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+	if err := database.Connect(cfg); err != nil {
 		panic("Can't connect database.")
 	}
 
-	migrations.All()
+	switch command {
+	case "up":
+		versions, err := migrations.Up(database.DBConn)
+		if err != nil {
+			log.Fatalln(err.Error())
+		}
+		log.Printf("%d migrations applied %v.\n", len(versions), versions)
+	case "down":
+		if *steps < 1 {
+			log.Fatalln("Steps must be at least 1.")
+		}
+		versions, err := migrations.Down(database.DBConn, *steps)
+		if err != nil {
+			log.Fatalln(err.Error())
+		}
+		log.Printf("%d migrations rolled back %v.\n", len(versions), versions)
+	case "status":
+		states, err := migrations.Status(database.DBConn)
+		if err != nil {
+			log.Fatalln(err.Error())
+		}
+		for _, state := range states {
+			switch {
+			case state.Up == "":
+				fmt.Printf("%04d %-30s unknown, applied at %s\n", state.Version, state.Name, state.AppliedAt.Format("2006-01-02 15:04:05"))
+			case state.AppliedAt == nil:
+				fmt.Printf("%04d %-30s pending\n", state.Version, state.Name)
+			default:
+				fmt.Printf("%04d %-30s applied at %s\n", state.Version, state.Name, state.AppliedAt.Format("2006-01-02 15:04:05"))
+			}
+		}
+	default:
+		flag.Usage()
+		log.Fatalf("Unknown command %s.\n", command)
+	}
 }
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/dinopuguh/mycap-backend/services/oauth/oauthtest"
+)
+
+func main() {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "9000"
+	}
+
+	log.Printf("Stub identity provider listening on :%s\n", port)
+	log.Fatal(http.ListenAndServe(":"+port, oauthtest.NewStubProvider()))
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/config"
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/services/organization"
+)
+
+func main() {
+	id := flag.Uint("organization", 0, "Organization ID")
+	minutes := flag.Int64("minutes", 0, "Minutes added to the organization's pooled time")
+	reference := flag.String("reference", "", "Reference of the purchase, e.g. an invoice number")
+	flag.Parse()
+
+	if *id == 0 || *minutes <= 0 {
+		log.Fatalln("Organization ID and minutes not specified.")
+	}
+
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+	if err := database.Connect(cfg); err != nil {
+		panic("Can't connect database.")
+	}
+
+	amount := (time.Duration(*minutes) * time.Minute).Milliseconds()
+	if err := organization.TopUp(database.DBConn, *id, amount, *reference); err != nil {
+		log.Fatalln(err.Error())
+	}
+	log.Printf("Added %d minutes to organization %d.\n", *minutes, *id)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/config"
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/helpers"
+	"github.com/dinopuguh/mycap-backend/services/promo"
+	"github.com/dinopuguh/mycap-backend/services/user"
+)
+
+func main() {
+	code := flag.String("code", "", "Promo code")
+	minutes := flag.Int64("minutes", 0, "Bonus minutes")
+	plan := flag.Uint("plan", 0, "User type ID given temporarily")
+	days := flag.Int("days", 30, "Days of the temporary plan upgrade")
+	max := flag.Int64("max", 0, "Maximum redemptions, 0 means unlimited")
+	expires := flag.String("expires", "", "Expiry date (2006-01-02 or RFC3339)")
+	flag.Parse()
+
+	if *code == "" {
+		log.Fatalln("Promo code not specified.")
+	}
+	if *minutes <= 0 && *plan == 0 {
+		log.Fatalln("Promo code needs bonus minutes or a plan upgrade.")
+	}
+
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+	if err := database.Connect(cfg); err != nil {
+		panic("Can't connect database.")
+	}
+
+	promoCode := promo.PromoCode{
+		Code:           promo.Normalize(*code),
+		BonusTime:      (time.Duration(*minutes) * time.Minute).Milliseconds(),
+		MaxRedemptions: *max,
+	}
+
+	if *plan != 0 {
+		if err := database.DBConn.First(&user.Type{}, *plan).Error; err != nil {
+			log.Fatalln("User type with this ID not exist.")
+		}
+		promoCode.UpgradeTypeID = *plan
+		promoCode.UpgradeDays = *days
+	}
+
+	if *expires != "" {
+		expiresAt, err := helpers.ParseDate(*expires)
+		if err != nil {
+			log.Fatalln("Expiry date invalid.")
+		}
+		promoCode.ExpiresAt = &expiresAt
+	}
+
+	if err := database.DBConn.Create(&promoCode).Error; err != nil {
+		log.Fatalln(err.Error())
+	}
+	log.Printf("Promo code %s created.\n", promoCode.Code)
+}
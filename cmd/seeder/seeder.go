@@ -3,12 +3,17 @@ package main
 import (
 	"log"
 
+	"github.com/dinopuguh/mycap-backend/config"
 	"github.com/dinopuguh/mycap-backend/database"
 	"github.com/dinopuguh/mycap-backend/seed"
 )
 
 func main() {
-	if err := database.Connect(); err != nil {
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+	if err := database.Connect(cfg); err != nil {
 		panic("Can't connect database.")
 	}
 
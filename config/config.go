@@ -0,0 +1,446 @@
+package config
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/validation"
+)
+
+// EnvFile is an environment variable naming the optional config file, the -config flag overrides it
+const EnvFile = "MYCAP_CONFIG"
+
+// Config is the configuration of the app, options are read from the env tag variables or the
+// flag tag flags
+type Config struct {
+	Port            int           `json:"port" env:"MYCAP_PORT,PORT" flag:"port" usage:"Port to listen on" validate:"min=1,max=65535"`
+	ShutdownTimeout time.Duration `json:"shutdown_timeout" env:"MYCAP_SHUTDOWN_TIMEOUT" usage:"Time to drain requests on shutdown" validate:"min=0"`
+	LogLevel        string        `json:"log_level" env:"MYCAP_LOG_LEVEL" flag:"log-level" usage:"Log level" validate:"oneof=trace debug info warn error"`
+	Migrate         bool          `json:"migrate" env:"MYCAP_MIGRATE" flag:"migrate" usage:"Apply pending migrations before starting"`
+	ProxyHeader     string        `json:"proxy_header" env:"MYCAP_PROXY_HEADER"` // header with the client IP set by a reverse proxy
+	AppURL          string        `json:"app_url" env:"MYCAP_APP_URL"`           // web app linked from emails
+	Database        Database      `json:"database"`
+	Auth            Auth          `json:"auth"`
+	RateLimit       RateLimit     `json:"rate_limit"`
+	Balance         Balance       `json:"balance"`
+	SMTP            SMTP          `json:"smtp"`
+	OIDC            OIDC          `json:"oidc"`
+}
+
+// Database is the configuration of the database connection, DSN takes precedence over the other
+// connection options
+type Database struct {
+	DSN             string        `json:"dsn" env:"MYCAP_DB_DSN"`
+	Host            string        `json:"host" env:"MYCAP_DB_HOST" validate:"required_without=DSN"`
+	Port            int           `json:"port" env:"MYCAP_DB_PORT" validate:"min=1,max=65535"`
+	User            string        `json:"user" env:"MYCAP_DB_USER" validate:"required_without=DSN"`
+	Password        string        `json:"password" env:"MYCAP_DB_PASSWORD"`
+	Name            string        `json:"name" env:"MYCAP_DB_NAME" validate:"required_without=DSN"`
+	SSLMode         string        `json:"ssl_mode" env:"MYCAP_DB_SSL_MODE" validate:"oneof=disable allow prefer require verify-ca verify-full"`
+	MaxOpenConns    int           `json:"max_open_conns" env:"MYCAP_DB_MAX_OPEN_CONNS" validate:"min=1"`
+	MaxIdleConns    int           `json:"max_idle_conns" env:"MYCAP_DB_MAX_IDLE_CONNS" validate:"min=0"`
+	ConnMaxLifetime time.Duration `json:"conn_max_lifetime" env:"MYCAP_DB_CONN_MAX_LIFETIME" validate:"min=0"`
+	ConnectAttempts int           `json:"connect_attempts" env:"MYCAP_DB_CONNECT_ATTEMPTS" validate:"min=1"`
+}
+
+// Auth is the configuration of authentication
+type Auth struct {
+	JWTSecret    string `json:"jwt_secret" env:"MYCAP_JWT_TOKEN" validate:"required"`
+	AdminToken   string `json:"admin_token" env:"MYCAP_ADMIN_TOKEN"`     // admin endpoints are disabled when empty
+	MetricsToken string `json:"metrics_token" env:"MYCAP_METRICS_TOKEN"` // bearer token of the metrics scraper, metrics are disabled when empty
+}
+
+// RateLimit is the configuration of request limits per client in a window, a limit of 0 disables it
+type RateLimit struct {
+	Enabled bool          `json:"enabled" env:"MYCAP_RATE_LIMIT"`
+	Window  time.Duration `json:"window" env:"MYCAP_RATE_LIMIT_WINDOW" validate:"gt=0"`
+	Auth    int           `json:"auth" env:"MYCAP_RATE_LIMIT_AUTH" validate:"min=0"`       // sign in and sign up per IP
+	Read    int           `json:"read" env:"MYCAP_RATE_LIMIT_READ" validate:"min=0"`       // reads per user or IP
+	Write   int           `json:"write" env:"MYCAP_RATE_LIMIT_WRITE" validate:"min=0"`     // writes per user
+	Join    int           `json:"join" env:"MYCAP_RATE_LIMIT_JOIN" validate:"min=0"`       // attempts joining groups with a passcode per user
+	APIKey  int           `json:"api_key" env:"MYCAP_RATE_LIMIT_API_KEY" validate:"min=0"` // requests with an API key per IP, counted before the key is looked up
+}
+
+// Balance is the configuration of remaining time notifications and bonus time
+type Balance struct {
+	LowBalanceThresholds []int64         `json:"low_balance_thresholds" env:"MYCAP_LOW_BALANCE_THRESHOLDS" validate:"dive,min=1,max=100"` // percentages of the monthly allowance used
+	TimeWarnings         []time.Duration `json:"time_warnings" env:"MYCAP_TIME_WARNINGS" validate:"dive,gt=0"`                            // remaining times warning group admins
+	ReferralBonusMinutes int             `json:"referral_bonus_minutes" env:"MYCAP_REFERRAL_BONUS_MINUTES" validate:"min=0"`
+}
+
+// SMTP is the configuration of the mail server, emails are written to the log when Host is empty
+type SMTP struct {
+	Host     string `json:"host" env:"MYCAP_SMTP_HOST"`
+	Port     int    `json:"port" env:"MYCAP_SMTP_PORT" validate:"min=1,max=65535"`
+	Username string `json:"username" env:"MYCAP_SMTP_USERNAME"`
+	Password string `json:"password" env:"MYCAP_SMTP_PASSWORD"`
+	From     string `json:"from" env:"MYCAP_SMTP_FROM"`
+}
+
+// OIDC is the configuration of OpenID Connect identity providers
+type OIDC struct {
+	Names     []string       `json:"names" env:"MYCAP_OIDC_PROVIDERS"`
+	Providers []OIDCProvider `json:"providers"` // read from MYCAP_OIDC_<NAME>_* of every name
+}
+
+// OIDCProvider is the configuration of an identity provider, options are read from the env tag
+// variables prefixed by MYCAP_OIDC_<NAME>_
+type OIDCProvider struct {
+	Name         string   `json:"name"`
+	Issuer       string   `json:"issuer" env:"ISSUER"`
+	ClientID     string   `json:"client_id" env:"CLIENT_ID"`
+	ClientSecret string   `json:"client_secret" env:"CLIENT_SECRET"`
+	RedirectURL  string   `json:"redirect_url" env:"REDIRECT_URL"`
+	Scopes       []string `json:"scopes" env:"SCOPES"`
+}
+
+// Default returns the configuration of options not set
+func Default() Config {
+	return Config{
+		Port:            3000,
+		ShutdownTimeout: 30 * time.Second,
+		LogLevel:        "info",
+		Database: Database{
+			Port:            5432,
+			SSLMode:         "disable",
+			MaxOpenConns:    50,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: time.Hour,
+			ConnectAttempts: 10,
+		},
+		RateLimit: RateLimit{
+			Enabled: true,
+			Window:  time.Minute,
+			Auth:    10,
+			Read:    300,
+			Write:   60,
+			Join:    10,
+			APIKey:  300,
+		},
+		Balance: Balance{
+			LowBalanceThresholds: []int64{80, 95},
+			TimeWarnings:         []time.Duration{10 * time.Minute, 5 * time.Minute, time.Minute},
+			ReferralBonusMinutes: 30,
+		},
+		SMTP: SMTP{
+			Port: 587,
+		},
+	}
+}
+
+// Source returns the data source name of the database connection
+func (d Database) Source() string {
+	if d.DSN != "" {
+		return d.DSN
+	}
+
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quote(d.Host), d.Port, quote(d.User), quote(d.Password), quote(d.Name), quote(d.SSLMode))
+}
+
+// quote quotes a value of a data source name when it's empty or has spaces, quotes or backslashes
+func quote(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// Load reads the configuration from defaults, the config file, environment variables and flags
+// in increasing precedence, then validates it
+func Load(args []string) (Config, error) {
+	cfg, err := Read(args)
+	if err != nil {
+		return cfg, err
+	}
+
+	return cfg, Validate(cfg)
+}
+
+// LoadDatabase reads and validates the database configuration only, for tools not serving the API
+func LoadDatabase() (Database, error) {
+	cfg, err := Read(nil)
+	if err != nil {
+		return cfg.Database, err
+	}
+
+	return cfg.Database, Validate(cfg.Database)
+}
+
+// Read reads the configuration like Load without validating it
+func Read(args []string) (Config, error) {
+	return read(args, os.LookupEnv)
+}
+
+func read(args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg := Default()
+	options := fields(&cfg)
+
+	fs := flag.NewFlagSet("mycap", flag.ContinueOnError)
+	file := fs.String("config", "", "Config file of KEY=value lines, defaults to $"+EnvFile)
+	for _, option := range options {
+		if option.flag == "" {
+			continue
+		}
+		if option.value.Kind() == reflect.Bool {
+			fs.Bool(option.flag, option.value.Bool(), option.usage)
+		} else {
+			fs.String(option.flag, fmt.Sprint(option.value.Interface()), option.usage)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if *file == "" {
+		*file, _ = lookupEnv(EnvFile)
+	}
+	var sources []func(string) (string, bool)
+	if *file != "" {
+		values, err := readFile(*file)
+		if err != nil {
+			return cfg, err
+		}
+		sources = append(sources, func(key string) (string, bool) {
+			value, ok := values[key]
+			return value, ok
+		})
+	}
+	sources = append(sources, lookupEnv)
+
+	if err := setOptions(options, sources); err != nil {
+		return cfg, err
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		for _, option := range options {
+			if option.flag == f.Name && err == nil {
+				err = option.set(f.Value.String())
+			}
+		}
+	})
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.OIDC.Providers, err = readProviders(cfg.OIDC.Names, sources)
+	return cfg, err
+}
+
+// setOptions sets options found in sources, later sources take precedence
+func setOptions(options []option, sources []func(string) (string, bool)) error {
+	for _, lookup := range sources {
+		for _, option := range options {
+			if value, ok := option.lookup(lookup); ok {
+				if err := option.set(value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// readProviders reads the identity providers of names from MYCAP_OIDC_<NAME>_* options
+func readProviders(names []string, sources []func(string) (string, bool)) ([]OIDCProvider, error) {
+	var providers []OIDCProvider
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+
+		provider := OIDCProvider{Name: name, Scopes: []string{"openid", "email", "profile"}}
+		options := fields(&provider)
+		prefix := "MYCAP_OIDC_" + strings.ToUpper(name) + "_"
+		for _, option := range options {
+			for i, key := range option.keys {
+				option.keys[i] = prefix + key
+			}
+		}
+		if err := setOptions(options, sources); err != nil {
+			return nil, err
+		}
+
+		provider.Issuer = strings.TrimSuffix(provider.Issuer, "/")
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
+}
+
+// Validate returns an error listing the variables of invalid options of a configuration
+func Validate(cfg interface{}) error {
+	err := validation.Struct(cfg)
+	if err == nil {
+		return nil
+	}
+
+	domainErr, ok := apperror.As(err)
+	if !ok {
+		return err
+	}
+	invalid, ok := domainErr.Data.(validation.Errors)
+	if !ok {
+		return err
+	}
+
+	keys := map[string]string{}
+	for _, option := range fields(cfg) {
+		keys[option.path] = option.keys[0]
+	}
+
+	messages := make([]string, 0, len(invalid))
+	for _, fieldErr := range invalid {
+		key := fieldErr.Field
+		if k, ok := keys[strings.SplitN(key, "[", 2)[0]]; ok {
+			key = k
+		}
+		messages = append(messages, key+" "+fieldErr.Message)
+	}
+
+	return fmt.Errorf("Config invalid: %s.", strings.Join(messages, ", "))
+}
+
+// option is a configuration field read from environment variables, the config file or a flag
+type option struct {
+	path  string // JSON path of the field as named by validation errors
+	keys  []string
+	flag  string
+	usage string
+	value reflect.Value
+}
+
+// fields returns the options of a configuration struct, nested structs included
+func fields(cfg interface{}) []option {
+	value := reflect.Indirect(reflect.ValueOf(cfg))
+	return appendFields(nil, value, "")
+}
+
+func appendFields(options []option, value reflect.Value, prefix string) []option {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		path := prefix + strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+
+		if field.Type.Kind() == reflect.Struct {
+			options = appendFields(options, value.Field(i), path+".")
+			continue
+		}
+
+		env := field.Tag.Get("env")
+		if env == "" {
+			continue
+		}
+		options = append(options, option{
+			path:  path,
+			keys:  strings.Split(env, ","),
+			flag:  field.Tag.Get("flag"),
+			usage: field.Tag.Get("usage"),
+			value: value.Field(i),
+		})
+	}
+
+	return options
+}
+
+// lookup returns the value of the first key of the option found
+func (o option) lookup(lookupEnv func(string) (string, bool)) (string, bool) {
+	for _, key := range o.keys {
+		if value, ok := lookupEnv(key); ok && value != "" {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+func (o option) set(s string) error {
+	if err := setValue(o.value, s); err != nil {
+		return fmt.Errorf("Config invalid: %s %q can't be parsed.", o.keys[0], s)
+	}
+	return nil
+}
+
+// setValue parses s into a value, slices are parsed from comma separated items
+func setValue(value reflect.Value, s string) error {
+	switch {
+	case value.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+	case value.Kind() == reflect.Int || value.Kind() == reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetInt(i)
+	case value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case value.Kind() == reflect.Slice:
+		items := strings.Split(s, ",")
+		slice := reflect.MakeSlice(value.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+	default:
+		value.SetString(s)
+	}
+
+	return nil
+}
+
+// readFile reads KEY=value lines of a config file, empty lines and lines starting with # are
+// skipped and values may be quoted
+func readFile(name string) (map[string]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseFile(f)
+}
+
+func parseFile(r io.Reader) (map[string]string, error) {
+	values := map[string]string{}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(line, "export "), "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Config file invalid: line %d isn't KEY=value.", n)
+		}
+
+		value := strings.TrimSpace(parts[1])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(parts[0])] = value
+	}
+
+	return values, scanner.Err()
+}
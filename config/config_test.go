@@ -0,0 +1,205 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func env(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+func TestRead(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "mycap.env")
+	assert.Nil(t, ioutil.WriteFile(file, []byte(`
+# database
+MYCAP_DB_HOST=db.internal
+MYCAP_DB_PASSWORD="s3cr3t p4ss"
+export MYCAP_DB_MAX_OPEN_CONNS=20
+MYCAP_LOG_LEVEL=warn
+`), 0600))
+
+	tests := []struct {
+		name  string
+		args  []string
+		env   map[string]string
+		check func(t *testing.T, cfg Config)
+	}{
+		{"Defaults", nil, nil, func(t *testing.T, cfg Config) {
+			assert.Equal(t, Default(), cfg)
+		}},
+		{"Environment", nil, map[string]string{
+			"MYCAP_PORT":                 "8080",
+			"MYCAP_DB_SSL_MODE":          "require",
+			"MYCAP_DB_CONN_MAX_LIFETIME": "15m",
+			"MYCAP_MIGRATE":              "true",
+			"MYCAP_JWT_TOKEN":            "v3rys3cr3tt0k3n",
+		}, func(t *testing.T, cfg Config) {
+			assert.Equal(t, 8080, cfg.Port)
+			assert.Equal(t, "require", cfg.Database.SSLMode)
+			assert.Equal(t, 15*time.Minute, cfg.Database.ConnMaxLifetime)
+			assert.True(t, cfg.Migrate)
+			assert.Equal(t, "v3rys3cr3tt0k3n", cfg.Auth.JWTSecret)
+		}},
+		{"Platform port", nil, map[string]string{"PORT": "5000"}, func(t *testing.T, cfg Config) {
+			assert.Equal(t, 5000, cfg.Port)
+		}},
+		{"Port preferred to platform port", nil, map[string]string{"MYCAP_PORT": "8080", "PORT": "5000"}, func(t *testing.T, cfg Config) {
+			assert.Equal(t, 8080, cfg.Port)
+		}},
+		{"File", []string{"-config", file}, nil, func(t *testing.T, cfg Config) {
+			assert.Equal(t, "db.internal", cfg.Database.Host)
+			assert.Equal(t, "s3cr3t p4ss", cfg.Database.Password)
+			assert.Equal(t, 20, cfg.Database.MaxOpenConns)
+			assert.Equal(t, "warn", cfg.LogLevel)
+		}},
+		{"Environment over file", nil, map[string]string{EnvFile: file, "MYCAP_LOG_LEVEL": "debug"}, func(t *testing.T, cfg Config) {
+			assert.Equal(t, "db.internal", cfg.Database.Host)
+			assert.Equal(t, "debug", cfg.LogLevel)
+		}},
+		{"Flags over environment", []string{"-port", "9000", "-migrate", "-log-level", "error"}, map[string]string{
+			"MYCAP_PORT":      "8080",
+			"MYCAP_LOG_LEVEL": "debug",
+		}, func(t *testing.T, cfg Config) {
+			assert.Equal(t, 9000, cfg.Port)
+			assert.True(t, cfg.Migrate)
+			assert.Equal(t, "error", cfg.LogLevel)
+		}},
+		{"Lists", nil, map[string]string{
+			"MYCAP_LOW_BALANCE_THRESHOLDS": "50, 90",
+			"MYCAP_TIME_WARNINGS":          "30s",
+		}, func(t *testing.T, cfg Config) {
+			assert.Equal(t, []int64{50, 90}, cfg.Balance.LowBalanceThresholds)
+			assert.Equal(t, []time.Duration{30 * time.Second}, cfg.Balance.TimeWarnings)
+		}},
+		{"Identity providers", nil, map[string]string{
+			"MYCAP_OIDC_PROVIDERS":           "google,corp",
+			"MYCAP_OIDC_GOOGLE_ISSUER":       "https://accounts.google.com/",
+			"MYCAP_OIDC_GOOGLE_CLIENT_ID":    "mycap",
+			"MYCAP_OIDC_CORP_ISSUER":         "https://sso.corp.example",
+			"MYCAP_OIDC_CORP_SCOPES":         "openid,email",
+			"MYCAP_OIDC_CORP_CLIENT_SECRET":  "s3cr3t",
+			"MYCAP_OIDC_GOOGLE_REDIRECT_URL": "http://localhost:3000/api/v1/oauth/google/callback",
+		}, func(t *testing.T, cfg Config) {
+			assert.Equal(t, []OIDCProvider{{
+				Name:        "google",
+				Issuer:      "https://accounts.google.com",
+				ClientID:    "mycap",
+				RedirectURL: "http://localhost:3000/api/v1/oauth/google/callback",
+				Scopes:      []string{"openid", "email", "profile"},
+			}, {
+				Name:         "corp",
+				Issuer:       "https://sso.corp.example",
+				ClientSecret: "s3cr3t",
+				Scopes:       []string{"openid", "email"},
+			}}, cfg.OIDC.Providers)
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg, err := read(test.args, env(test.env))
+			assert.Nil(t, err)
+			test.check(t, cfg)
+		})
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		err  string
+	}{
+		{"Port not a number", nil, map[string]string{"MYCAP_PORT": "http"}, `Config invalid: MYCAP_PORT "http" can't be parsed.`},
+		{"Duration without unit", nil, map[string]string{"MYCAP_SHUTDOWN_TIMEOUT": "30"}, `Config invalid: MYCAP_SHUTDOWN_TIMEOUT "30" can't be parsed.`},
+		{"List item not a number", nil, map[string]string{"MYCAP_LOW_BALANCE_THRESHOLDS": "80,abc"}, `Config invalid: MYCAP_LOW_BALANCE_THRESHOLDS "80,abc" can't be parsed.`},
+		{"Unknown flag", []string{"-verbose"}, nil, "flag provided but not defined: -verbose"},
+		{"File missing", []string{"-config", filepath.Join(t.TempDir(), "missing.env")}, nil, "no such file or directory"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := read(test.args, env(test.env))
+			if assert.NotNil(t, err) {
+				assert.Contains(t, err.Error(), test.err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Default()
+	valid.Database.Host = "localhost"
+	valid.Database.User = "postgres"
+	valid.Database.Name = "mycap"
+	valid.Auth.JWTSecret = "v3rys3cr3tt0k3n"
+
+	tests := []struct {
+		name   string
+		change func(cfg *Config)
+		err    string
+	}{
+		{"Valid", func(cfg *Config) {}, ""},
+		{"DSN instead of options", func(cfg *Config) {
+			cfg.Database = Default().Database
+			cfg.Database.DSN = "postgres://postgres@localhost/mycap"
+		}, ""},
+		{"Missing required options", func(cfg *Config) {
+			cfg.Database.Host = ""
+			cfg.Auth.JWTSecret = ""
+		}, "Config invalid: MYCAP_DB_HOST is required, MYCAP_JWT_TOKEN is required."},
+		{"Invalid options", func(cfg *Config) {
+			cfg.Port = 70000
+			cfg.LogLevel = "verbose"
+			cfg.Database.SSLMode = "on"
+			cfg.Database.MaxOpenConns = 0
+		}, "Config invalid: MYCAP_PORT must be at most 65535, MYCAP_LOG_LEVEL must be one of trace, debug, info, warn, error, " +
+			"MYCAP_DB_SSL_MODE must be one of disable, allow, prefer, require, verify-ca, verify-full, MYCAP_DB_MAX_OPEN_CONNS must be at least 1."},
+		{"Invalid list items", func(cfg *Config) {
+			cfg.Balance.LowBalanceThresholds = []int64{80, 120}
+		}, "Config invalid: MYCAP_LOW_BALANCE_THRESHOLDS must be at most 100."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := valid
+			test.change(&cfg)
+
+			err := Validate(cfg)
+			if test.err == "" {
+				assert.Nil(t, err)
+			} else if assert.NotNil(t, err) {
+				assert.Equal(t, test.err, err.Error())
+			}
+		})
+	}
+
+	err := Validate(Database{Port: 5432, SSLMode: "disable", MaxOpenConns: 1, ConnectAttempts: 1})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "Config invalid: MYCAP_DB_HOST is required, MYCAP_DB_USER is required, MYCAP_DB_NAME is required.", err.Error())
+	}
+}
+
+func TestSource(t *testing.T) {
+	database := Default().Database
+	database.Host = "localhost"
+	database.User = "postgres"
+	database.Name = "mycap"
+	assert.Equal(t, "host=localhost port=5432 user=postgres password='' dbname=mycap sslmode=disable", database.Source())
+
+	database.Password = `it's a \secret`
+	assert.True(t, strings.Contains(database.Source(), `password='it\'s a \\secret'`))
+
+	database.DSN = "postgres://postgres@localhost/mycap?sslmode=require"
+	assert.Equal(t, database.DSN, database.Source())
+}
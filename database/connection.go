@@ -1,42 +1,75 @@
 package database
 
 import (
-	"fmt"
-	"os"
-	"strconv"
 	"time"
 
+	"github.com/dinopuguh/mycap-backend/config"
+	"github.com/dinopuguh/mycap-backend/logger"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-var (
-	// DBConn is a pointer to gorm.DB
-	DBConn   *gorm.DB
-	user     = os.Getenv("MYCAP_DB_USER")
-	password = os.Getenv("MYCAP_DB_PASSWORD")
-	host     = os.Getenv("MYCAP_DB_HOST")
-	db       = os.Getenv("MYCAP_DB_NAME")
-	port     = os.Getenv("MYCAP_DB_PORT")
+// DBConn is a pointer to gorm.DB
+var DBConn *gorm.DB
+
+const (
+	connectBackoff    = time.Second
+	connectMaxBackoff = 30 * time.Second
 )
 
 // Connect creates a connection to database
-func Connect() (err error) {
-	port, err := strconv.Atoi(port)
+func Connect(cfg config.Database) (err error) {
+	DBConn, err = gorm.Open(logger.Redact(postgres.Open(cfg.Source())), &gorm.Config{
+		Logger: logger.GORM{},
+	})
 	if err != nil {
 		return err
 	}
 
-	dsn := fmt.Sprintf("user=%s password=%s host=%s dbname=%s port=%d sslmode=disable", user, password, host, db, port)
-	DBConn, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	sqlDB, err := DBConn.DB()
 	if err != nil {
 		return err
 	}
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	return nil
+}
+
+// ConnectRetry connects to database, retrying failed attempts with exponential backoff so the
+// app can start before the database is ready
+func ConnectRetry(cfg config.Database) error {
+	return retry(cfg.ConnectAttempts, connectBackoff, connectMaxBackoff, func() error {
+		return Connect(cfg)
+	}, time.Sleep)
+}
+
+func retry(attempts int, backoff, maxBackoff time.Duration, connect func() error, sleep func(time.Duration)) (err error) {
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = connect(); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		logger.Log.Warn().Err(err).Int("attempt", attempt).Dur("backoff", backoff).Msg("Connect database failed, retrying.")
+		sleep(backoff)
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+
+	return err
+}
 
+// Close closes all connections to database
+func Close() error {
 	sqlDB, err := DBConn.DB()
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(50)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return sqlDB.Close()
 }
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	errRefused := errors.New("connection refused")
+
+	tests := []struct {
+		name     string
+		attempts int
+		failures int
+		err      error
+		sleeps   []time.Duration
+	}{
+		{"Connected at once", 5, 0, nil, nil},
+		{"Connected after failures", 5, 3, nil, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}},
+		{"Backoff capped", 6, 5, nil, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}},
+		{"Attempts run out", 3, 3, errRefused, []time.Duration{time.Second, 2 * time.Second}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var calls int
+			var sleeps []time.Duration
+			connect := func() error {
+				calls++
+				if calls <= test.failures {
+					return errRefused
+				}
+				return nil
+			}
+			sleep := func(d time.Duration) {
+				sleeps = append(sleeps, d)
+			}
+
+			err := retry(test.attempts, time.Second, 5*time.Second, connect, sleep)
+			assert.Equal(t, test.err, err)
+			assert.Equal(t, test.sleeps, sleeps)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+// Package databasetest connects integration tests to PostgreSQL
+package databasetest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/config"
+	"github.com/dinopuguh/mycap-backend/database"
+)
+
+// Config returns the configuration of the app read from MYCAP_* variables without validating it,
+// rate limits are disabled since tests sign up and sign in many users from the same address
+func Config() config.Config {
+	cfg, err := config.Read(nil)
+	if err != nil {
+		panic("Can't read config.")
+	}
+	cfg.RateLimit.Enabled = false
+
+	return cfg
+}
+
+// Connect connects a test to PostgreSQL configured by MYCAP_DB_* variables, the test is skipped
+// when MYCAP_DB_HOST is not set so packages backed by in-memory repositories still run without it
+func Connect(t *testing.T) config.Config {
+	if os.Getenv("MYCAP_DB_HOST") == "" {
+		t.Skip("MYCAP_DB_HOST not set, skipping PostgreSQL test.")
+	}
+
+	cfg := Config()
+	if err := database.Connect(cfg.Database); err != nil {
+		panic("Can't connect database.")
+	}
+
+	return cfg
+}
@@ -31,9 +31,14 @@ var doc = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/v1/groups": {
+        "/v1/account/bonus": {
             "get": {
-                "description": "Get all groups",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get bonus time credited to and consumed by the current user, newest first",
                 "consumes": [
                     "application/json"
                 ],
@@ -41,9 +46,23 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "account"
+                ],
+                "summary": "Get bonus time ledger",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Next page cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all groups",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -56,10 +75,22 @@ var doc = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/group.Group"
-                                            }
+                                            "allOf": [
+                                                {
+                                                    "$ref": "#/definitions/pagination.Response"
+                                                },
+                                                {
+                                                    "type": "object",
+                                                    "properties": {
+                                                        "items": {
+                                                            "type": "array",
+                                                            "items": {
+                                                                "$ref": "#/definitions/user.BonusEntry"
+                                                            }
+                                                        }
+                                                    }
+                                                }
+                                            ]
                                         }
                                     }
                                 }
@@ -67,14 +98,16 @@ var doc = `{
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/v1/account/email": {
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create a group chat or conference",
+                "description": "Send confirmation to the new email of the current user",
                 "consumes": [
                     "application/json"
                 ],
@@ -82,17 +115,17 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "account"
                 ],
-                "summary": "Create a group chat or conference",
+                "summary": "Change email",
                 "parameters": [
                     {
-                        "description": "Create group",
-                        "name": "group",
+                        "description": "Change email",
+                        "name": "email",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/group.CreateGroup"
+                            "$ref": "#/definitions/user.ChangeEmail"
                         }
                     }
                 ],
@@ -100,32 +133,15 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/response.HTTP"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/group.Group"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/response.HTTP"
                         }
                     }
                 }
             }
         },
-        "/v1/join-groups": {
+        "/v1/account/email/confirm": {
             "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Joining group chat or conference",
+                "description": "Confirm email change with token sent to the new email, all sessions are signed out",
                 "consumes": [
                     "application/json"
                 ],
@@ -133,17 +149,17 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "groups"
+                    "account"
                 ],
-                "summary": "Joining group chat or conference",
+                "summary": "Confirm email change",
                 "parameters": [
                     {
-                        "description": "Join group",
-                        "name": "group",
+                        "description": "Confirm email",
+                        "name": "token",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/group.JoinGroup"
+                            "$ref": "#/definitions/user.ConfirmEmail"
                         }
                     }
                 ],
@@ -159,7 +175,7 @@ var doc = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/group.Group"
+                                            "$ref": "#/definitions/user.PrivateUser"
                                         }
                                     }
                                 }
@@ -169,14 +185,14 @@ var doc = `{
                 }
             }
         },
-        "/v1/leave-groups": {
-            "post": {
+        "/v1/account/history": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Leaving group chat or conference",
+                "description": "Get group chat and conference sessions the current user administered or joined",
                 "consumes": [
                     "application/json"
                 ],
@@ -186,16 +202,43 @@ var doc = `{
                 "tags": [
                     "groups"
                 ],
-                "summary": "Leaving group chat or conference",
+                "summary": "Get group session history",
                 "parameters": [
                     {
-                        "description": "Leave group",
-                        "name": "group",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/group.LeaveGroup"
-                        }
+                        "type": "string",
+                        "description": "Group type (Group or Conference)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Started from date (2006-01-02 or RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Started to date (2006-01-02 or RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by started_at, duration or time_used, prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Next page cursor",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -210,7 +253,22 @@ var doc = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/group.Group"
+                                            "allOf": [
+                                                {
+                                                    "$ref": "#/definitions/pagination.Response"
+                                                },
+                                                {
+                                                    "type": "object",
+                                                    "properties": {
+                                                        "items": {
+                                                            "type": "array",
+                                                            "items": {
+                                                                "$ref": "#/definitions/group.GroupSession"
+                                                            }
+                                                        }
+                                                    }
+                                                }
+                                            ]
                                         }
                                     }
                                 }
@@ -220,9 +278,14 @@ var doc = `{
                 }
             }
         },
-        "/v1/login": {
-            "post": {
-                "description": "User login",
+        "/v1/account/password": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Change password of the current user, all other sessions are signed out",
                 "consumes": [
                     "application/json"
                 ],
@@ -230,17 +293,17 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "account"
                 ],
-                "summary": "User login",
+                "summary": "Change password",
                 "parameters": [
                     {
-                        "description": "User login",
-                        "name": "user",
+                        "description": "Change password",
+                        "name": "password",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user.LoginUser"
+                            "$ref": "#/definitions/user.ChangePassword"
                         }
                     }
                 ],
@@ -248,27 +311,18 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/response.HTTP"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/user.ResponseAuth"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/response.HTTP"
                         }
                     }
                 }
-            }
-        },
-        "/v1/register": {
+            },
             "post": {
-                "description": "Register user",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Set password of the current user registered by an identity provider to also login with email",
                 "consumes": [
                     "application/json"
                 ],
@@ -276,19 +330,55 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "account"
                 ],
-                "summary": "Register a new user",
+                "summary": "Set password",
                 "parameters": [
                     {
-                        "description": "Register user",
-                        "name": "user",
+                        "description": "Set password",
+                        "name": "password",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user.RegisterUser"
+                            "$ref": "#/definitions/user.SetPassword"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.HTTP"
                         }
                     }
+                }
+            }
+        },
+        "/v1/account/usage": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get time used by the current user as group admin in a month, aggregated by day and by group type",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Get monthly usage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Month (2006-01), default current month",
+                        "name": "month",
+                        "in": "query"
+                    }
                 ],
                 "responses": {
                     "200": {
@@ -302,7 +392,7 @@ var doc = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/user.ResponseAuth"
+                                            "$ref": "#/definitions/group.UsageSummary"
                                         }
                                     }
                                 }
@@ -312,9 +402,14 @@ var doc = `{
                 }
             }
         },
-        "/v1/users": {
+        "/v1/api-keys": {
             "get": {
-                "description": "Get all users",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get all API keys of the current user",
                 "consumes": [
                     "application/json"
                 ],
@@ -322,9 +417,9 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "api-keys"
                 ],
-                "summary": "Get all users",
+                "summary": "Get all API keys",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -339,7 +434,7 @@ var doc = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/user.User"
+                                                "$ref": "#/definitions/apikey.APIKey"
                                             }
                                         }
                                     }
@@ -348,16 +443,14 @@ var doc = `{
                         }
                     }
                 }
-            }
-        },
-        "/v1/users/{id}": {
-            "put": {
+            },
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update user by ID",
+                "description": "Create an API key, the key is only shown once",
                 "consumes": [
                     "application/json"
                 ],
@@ -365,24 +458,17 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "api-keys"
                 ],
-                "summary": "Update user by ID",
+                "summary": "Create an API key",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Update user",
-                        "name": "user",
+                        "description": "Create API key",
+                        "name": "api_key",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user.UpdateUser"
+                            "$ref": "#/definitions/apikey.CreateAPIKey"
                         }
                     }
                 ],
@@ -398,7 +484,7 @@ var doc = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/user.User"
+                                            "$ref": "#/definitions/apikey.ResponseAPIKey"
                                         }
                                     }
                                 }
@@ -406,14 +492,16 @@ var doc = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/v1/api-keys/{id}": {
             "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Remove user by ID",
+                "description": "Revoke API key by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -421,13 +509,13 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "api-keys"
                 ],
-                "summary": "Remove user by ID",
+                "summary": "Revoke API key by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "API key ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -442,80 +530,2012 @@ var doc = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "group.CreateGroup": {
-            "type": "object",
-            "properties": {
-                "type": {
-                    "type": "string"
-                }
-            }
         },
-        "group.Group": {
-            "type": "object",
-            "properties": {
-                "admin": {
-                    "type": "object",
-                    "$ref": "#/definitions/user.User"
-                },
-                "admin_id": {
-                    "type": "integer"
-                },
-                "admin_username": {
-                    "type": "string"
-                },
-                "participants": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/user.User"
+        "/v1/groups": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
                     }
-                },
-                "type": {
-                    "type": "string"
-                }
-            }
-        },
-        "group.JoinGroup": {
-            "type": "object",
-            "properties": {
-                "admin_username": {
-                    "type": "string"
-                }
-            }
-        },
-        "group.LeaveGroup": {
-            "type": "object",
-            "properties": {
-                "admin_username": {
-                    "type": "string"
-                },
-                "remaining_time": {
-                    "type": "integer"
-                }
-            }
-        },
-        "response.HTTP": {
-            "type": "object",
-            "properties": {
-                "data": {
-                    "type": "object"
-                },
-                "message": {
-                    "type": "string"
-                },
-                "status": {
-                    "type": "integer"
-                },
-                "success": {
-                    "type": "boolean"
-                }
-            }
-        },
-        "user.LoginUser": {
-            "type": "object",
-            "properties": {
+                ],
+                "description": "Get public groups and groups the current user belongs to with participant counts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Get all groups",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group type (Group or Conference)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin username",
+                        "name": "admin",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Group status (active, ended or all)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by created_at or participant_count, prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Next page cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "allOf": [
+                                                {
+                                                    "$ref": "#/definitions/pagination.Response"
+                                                },
+                                                {
+                                                    "type": "object",
+                                                    "properties": {
+                                                        "items": {
+                                                            "type": "array",
+                                                            "items": {
+                                                                "$ref": "#/definitions/group.GroupSummary"
+                                                            }
+                                                        }
+                                                    }
+                                                }
+                                            ]
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a group chat or conference",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Create a group chat or conference",
+                "parameters": [
+                    {
+                        "description": "Create group",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/group.CreateGroup"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/group.PublicGroup"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/join-groups": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Joining group chat or conference",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Joining group chat or conference",
+                "parameters": [
+                    {
+                        "description": "Join group",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/group.JoinGroup"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/group.PublicGroup"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/leave-groups": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Leaving group chat or conference",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Leaving group chat or conference",
+                "parameters": [
+                    {
+                        "description": "Leave group",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/group.LeaveGroup"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/group.PublicGroup"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/login": {
+            "post": {
+                "description": "User login",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "User login",
+                "parameters": [
+                    {
+                        "description": "User login",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.LoginUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/user.ResponseAuth"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/notifications": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get notifications of the current user, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Get all notifications",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only unread notifications",
+                        "name": "unread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Next page cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "allOf": [
+                                                {
+                                                    "$ref": "#/definitions/pagination.Response"
+                                                },
+                                                {
+                                                    "type": "object",
+                                                    "properties": {
+                                                        "items": {
+                                                            "type": "array",
+                                                            "items": {
+                                                                "$ref": "#/definitions/notification.Notification"
+                                                            }
+                                                        }
+                                                    }
+                                                }
+                                            ]
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/notifications/stream": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stream live notifications and group time warnings of the current user as server-sent events",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Stream notifications",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/notification.Notification"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/notifications/{id}/read": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Mark notification as read by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Mark notification as read",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Notification ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/notification.Notification"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/oauth/{provider}/authorize": {
+            "get": {
+                "description": "Start OpenID Connect authorization code flow with PKCE, the state is also set to an HttpOnly cookie the callback checks",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Start social login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Login hint",
+                        "name": "login_hint",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/oauth.ResponseAuthorize"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/oauth/{provider}/callback": {
+            "get": {
+                "description": "Exchange authorization code, link identity to an user by verified email and login. The state must match the state cookie set when authorization started.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Finish social login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/user.ResponseAuth"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/oauth/{provider}/link": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Start OpenID Connect authorization code flow linking the provider account to the current user, the password of the user is required",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Link identity provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Password of the current user",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/oauth.LinkIdentity"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/oauth.ResponseAuthorize"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/organization": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the organization of the current user with its members and pooled time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Get current organization",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/organization.Organization"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/organization/invitations": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Send an email invitation to join the organization, owners and admins only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Invite member",
+                "parameters": [
+                    {
+                        "description": "Invite member",
+                        "name": "invitation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/organization.InviteMember"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/organization.Invitation"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/organization/invitations/accept": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Join an organization with an invitation token sent to the current user's email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Accept invitation",
+                "parameters": [
+                    {
+                        "description": "Accept invitation",
+                        "name": "invitation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/organization.AcceptInvitation"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/organization.Organization"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/organization/members/{user_id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Change the role of a member by user ID, owners only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Change member role",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Change role",
+                        "name": "member",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/organization.ChangeRole"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/organization.Member"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove a member by user ID, owners and admins remove others and members leave by removing themselves",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Remove member",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.HTTP"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/organization/usage": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get time used by members' group sessions and drawn from pooled time in a month, owners and admins only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Get organization usage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Month (2006-01), default current month",
+                        "name": "month",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/organization.UsageReport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/organizations": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create an organization with the current user as owner",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Create an organization",
+                "parameters": [
+                    {
+                        "description": "Create organization",
+                        "name": "organization",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/organization.CreateOrganization"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/organization.Organization"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/promo-codes/redeem": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Redeem promo code for bonus time and/or a temporary plan upgrade",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "account"
+                ],
+                "summary": "Redeem promo code",
+                "parameters": [
+                    {
+                        "description": "Redeem promo code",
+                        "name": "promo",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/promo.RedeemPromoCode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/promo.ResponseRedeem"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/register": {
+            "post": {
+                "description": "Register user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "Register user",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RegisterUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/user.ResponseAuth"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get all active sessions and devices of the current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Get all sessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/session.Session"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revoke all sessions except the current one",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Revoke all other sessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.HTTP"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revoke session by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Revoke session by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.HTTP"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users": {
+            "get": {
+                "description": "Get users, search by username or name prefix and filter by plan type and creation date",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get all users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username or name prefix",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User type ID",
+                        "name": "type_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created from date (2006-01-02 or RFC3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created to date (2006-01-02 or RFC3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by name, username or created_at, prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Next page cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "allOf": [
+                                                {
+                                                    "$ref": "#/definitions/pagination.Response"
+                                                },
+                                                {
+                                                    "type": "object",
+                                                    "properties": {
+                                                        "items": {
+                                                            "type": "array",
+                                                            "items": {
+                                                                "$ref": "#/definitions/user.PublicUser"
+                                                            }
+                                                        }
+                                                    }
+                                                }
+                                            ]
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update the name of the current user, plans and remaining time are updated by admins",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update user by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update user",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.UpdateUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.HTTP"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/user.PrivateUser"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove the current user by ID, signing out its sessions and revoking its API keys",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Remove user by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.HTTP"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apikey.APIKey": {
+            "type": "object",
+            "properties": {
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apikey.CreateAPIKey": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Conference bot"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "groups:read",
+                        "groups:manage"
+                    ]
+                }
+            }
+        },
+        "apikey.ResponseAPIKey": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "type": "object",
+                    "$ref": "#/definitions/apikey.APIKey"
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
+        "group.CreateGroup": {
+            "type": "object",
+            "required": [
+                "type"
+            ],
+            "properties": {
+                "passcode": {
+                    "type": "string",
+                    "example": "123456"
+                },
+                "type": {
+                    "description": "(Group, Conference)",
+                    "type": "string",
+                    "example": "Group"
+                },
+                "visibility": {
+                    "description": "(public, unlisted, private)",
+                    "type": "string",
+                    "example": "public"
+                }
+            }
+        },
+        "group.GroupSession": {
+            "type": "object",
+            "properties": {
+                "admin_id": {
+                    "type": "integer"
+                },
+                "admin_username": {
+                    "type": "string"
+                },
+                "duration": {
+                    "description": "milliseconds between start and end",
+                    "type": "integer"
+                },
+                "ended_at": {
+                    "type": "string"
+                },
+                "group_id": {
+                    "type": "integer"
+                },
+                "participant_count": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "time_used": {
+                    "description": "milliseconds deducted from admin's remaining time",
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "group.GroupSummary": {
+            "type": "object",
+            "properties": {
+                "admin_id": {
+                    "type": "integer"
+                },
+                "admin_username": {
+                    "type": "string"
+                },
+                "participant_count": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "type": "string"
+                }
+            }
+        },
+        "group.JoinGroup": {
+            "type": "object",
+            "required": [
+                "admin_username"
+            ],
+            "properties": {
+                "admin_username": {
+                    "type": "string",
+                    "example": "dinopuguh"
+                },
+                "passcode": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "group.LeaveGroup": {
+            "type": "object",
+            "required": [
+                "admin_username"
+            ],
+            "properties": {
+                "admin_username": {
+                    "type": "string",
+                    "example": "dinopuguh"
+                },
+                "remaining_time": {
+                    "description": "milliseconds",
+                    "type": "integer",
+                    "example": 1800
+                }
+            }
+        },
+        "group.PublicGroup": {
+            "type": "object",
+            "properties": {
+                "admin": {
+                    "type": "object",
+                    "$ref": "#/definitions/user.PublicUser"
+                },
+                "admin_id": {
+                    "type": "integer"
+                },
+                "admin_username": {
+                    "type": "string"
+                },
+                "has_passcode": {
+                    "type": "boolean"
+                },
+                "participants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user.PublicUser"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "type": "string"
+                }
+            }
+        },
+        "group.UsageByDay": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2020-10-01"
+                },
+                "duration": {
+                    "description": "milliseconds",
+                    "type": "integer"
+                },
+                "sessions": {
+                    "type": "integer"
+                },
+                "time_used": {
+                    "description": "milliseconds",
+                    "type": "integer"
+                }
+            }
+        },
+        "group.UsageByType": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "description": "milliseconds",
+                    "type": "integer"
+                },
+                "sessions": {
+                    "type": "integer"
+                },
+                "time_used": {
+                    "description": "milliseconds",
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "Group"
+                }
+            }
+        },
+        "group.UsageSummary": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/group.UsageByDay"
+                    }
+                },
+                "duration": {
+                    "description": "milliseconds",
+                    "type": "integer"
+                },
+                "month": {
+                    "type": "string",
+                    "example": "2020-10"
+                },
+                "sessions": {
+                    "type": "integer"
+                },
+                "time_used": {
+                    "description": "milliseconds",
+                    "type": "integer"
+                },
+                "types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/group.UsageByType"
+                    }
+                }
+            }
+        },
+        "notification.Notification": {
+            "type": "object",
+            "properties": {
+                "kind": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "read_at": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "oauth.LinkIdentity": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "s3cr3tp45sw0rd"
+                }
+            }
+        },
+        "oauth.ResponseAuthorize": {
+            "type": "object",
+            "properties": {
+                "authorization_url": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "organization.AcceptInvitation": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "organization.ChangeRole": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "description": "(owner, admin, member)",
+                    "type": "string",
+                    "example": "admin"
+                }
+            }
+        },
+        "organization.CreateOrganization": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "MyCap School"
+                }
+            }
+        },
+        "organization.DayUsage": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2020-10-01"
+                },
+                "from_pool": {
+                    "description": "milliseconds",
+                    "type": "integer"
+                },
+                "sessions": {
+                    "type": "integer"
+                },
+                "time_used": {
+                    "description": "milliseconds",
+                    "type": "integer"
+                }
+            }
+        },
+        "organization.Invitation": {
+            "type": "object",
+            "properties": {
+                "accepted_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "organization.InviteMember": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "teacher@mycap.com"
+                },
+                "role": {
+                    "description": "(owner, admin, member)",
+                    "type": "string",
+                    "example": "member"
+                }
+            }
+        },
+        "organization.Member": {
+            "type": "object",
+            "properties": {
+                "organization_id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "user": {
+                    "type": "object",
+                    "$ref": "#/definitions/user.PublicUser"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "organization.MemberUsage": {
+            "type": "object",
+            "properties": {
+                "from_pool": {
+                    "description": "milliseconds",
+                    "type": "integer"
+                },
+                "sessions": {
+                    "type": "integer"
+                },
+                "time_used": {
+                    "description": "milliseconds",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "organization.Organization": {
+            "type": "object",
+            "properties": {
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/organization.Member"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pooled_time": {
+                    "description": "milliseconds drawn by members' group sessions before their own remaining time",
+                    "type": "integer"
+                }
+            }
+        },
+        "organization.UsageReport": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/organization.DayUsage"
+                    }
+                },
+                "from_pool": {
+                    "description": "milliseconds",
+                    "type": "integer"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/organization.MemberUsage"
+                    }
+                },
+                "month": {
+                    "type": "string",
+                    "example": "2020-10"
+                },
+                "pooled_time": {
+                    "type": "integer"
+                },
+                "sessions": {
+                    "type": "integer"
+                },
+                "time_used": {
+                    "description": "milliseconds",
+                    "type": "integer"
+                },
+                "topped_up": {
+                    "type": "integer"
+                }
+            }
+        },
+        "pagination.Response": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "object"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "promo.RedeemPromoCode": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "LAUNCH2020"
+                }
+            }
+        },
+        "promo.Redemption": {
+            "type": "object",
+            "properties": {
+                "bonus_time": {
+                    "type": "integer"
+                },
+                "promo_code_id": {
+                    "type": "integer"
+                },
+                "upgrade_ends_at": {
+                    "type": "string"
+                },
+                "upgrade_type_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "promo.ResponseRedeem": {
+            "type": "object",
+            "properties": {
+                "redemption": {
+                    "type": "object",
+                    "$ref": "#/definitions/promo.Redemption"
+                },
+                "user": {
+                    "type": "object",
+                    "$ref": "#/definitions/user.PrivateUser"
+                }
+            }
+        },
+        "response.HTTP": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "session.Session": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "type": "boolean"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "user.BonusEntry": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "milliseconds, negative when consumed",
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "user.ChangeEmail": {
+            "type": "object",
+            "required": [
+                "new_email",
+                "password"
+            ],
+            "properties": {
+                "new_email": {
+                    "type": "string",
+                    "example": "dino@mycap.com"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "s3cr3tp45sw0rd"
+                }
+            }
+        },
+        "user.ChangePassword": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string",
+                    "example": "s3cr3tp45sw0rd"
+                },
+                "new_password": {
+                    "type": "string",
+                    "example": "n3ws3cr3tp45sw0rd"
+                }
+            }
+        },
+        "user.ConfirmEmail": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "5f2b1c..."
+                }
+            }
+        },
+        "user.LoginUser": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
                 "email": {
                     "type": "string",
                     "example": "dinopuguh@mycap.com"
@@ -526,8 +2546,84 @@ var doc = `{
                 }
             }
         },
+        "user.PrivateUser": {
+            "type": "object",
+            "properties": {
+                "bonus_time": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "cycle_anchor": {
+                    "type": "string"
+                },
+                "cycle_ends_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "provider_only": {
+                    "description": "users registered by an identity provider sign in only with it until they set a password",
+                    "type": "boolean"
+                },
+                "reached_time_limit": {
+                    "type": "boolean"
+                },
+                "referral_code": {
+                    "type": "string"
+                },
+                "remaining_time": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "object",
+                    "$ref": "#/definitions/user.Type"
+                },
+                "type_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.PublicUser": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "object",
+                    "$ref": "#/definitions/user.Type"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
         "user.RegisterUser": {
             "type": "object",
+            "required": [
+                "email",
+                "name",
+                "password",
+                "username"
+            ],
             "properties": {
                 "email": {
                     "type": "string",
@@ -541,6 +2637,10 @@ var doc = `{
                     "type": "string",
                     "example": "s3cr3tp45sw0rd"
                 },
+                "referral_code": {
+                    "type": "string",
+                    "example": "A1B2C3D4"
+                },
                 "type_id": {
                     "description": "(1: Free, 2: Premium, 3: Pro)",
                     "type": "integer",
@@ -560,55 +2660,85 @@ var doc = `{
                 },
                 "user": {
                     "type": "object",
-                    "$ref": "#/definitions/user.User"
+                    "$ref": "#/definitions/user.PrivateUser"
+                }
+            }
+        },
+        "user.SetPassword": {
+            "type": "object",
+            "required": [
+                "new_password"
+            ],
+            "properties": {
+                "new_password": {
+                    "type": "string",
+                    "example": "n3ws3cr3tp45sw0rd"
                 }
             }
         },
         "user.Type": {
             "type": "object",
             "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "max_conference_participants": {
+                    "description": "0 means unlimited",
+                    "type": "integer"
+                },
+                "max_group_participants": {
+                    "description": "0 means unlimited",
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
         "user.UpdateUser": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
                 "name": {
                     "type": "string",
                     "example": "Dino Puguh"
-                },
-                "reached_time_limit": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "remaining_time": {
-                    "type": "integer",
-                    "example": 1800
-                },
-                "type_id": {
-                    "description": "(1: Free, 2: Premium, 3: Pro)",
-                    "type": "integer",
-                    "example": 2
                 }
             }
         },
         "user.User": {
             "type": "object",
             "properties": {
-                "email": {
+                "bonus_time": {
+                    "description": "milliseconds kept across billing cycles",
+                    "type": "integer"
+                },
+                "cycle_anchor": {
+                    "description": "start of the first billing cycle",
                     "type": "string"
                 },
-                "name": {
+                "cycle_ends_at": {
                     "type": "string"
                 },
-                "password": {
+                "email": {
+                    "type": "string"
+                },
+                "name": {
                     "type": "string"
                 },
                 "reached_time_limit": {
                     "type": "boolean"
                 },
+                "referral_code": {
+                    "type": "string"
+                },
                 "remaining_time": {
                     "type": "integer"
                 },
@@ -650,7 +2780,7 @@ var SwaggerInfo = swaggerInfo{
 	BasePath:    "/api",
 	Schemes:     []string{},
 	Title:       "MyCap API",
-	Description: "This is an API for MyCap Application",
+	Description: "This is an API for MyCap Application. Endpoints are documented under /v1, the same endpoints under /v2 respond with HTTP status codes, data only bodies and RFC 7807 problem details for errors.",
 }
 
 type s struct{}
@@ -1,6 +1,6 @@
 module github.com/dinopuguh/mycap-backend
 
-go 1.14
+go 1.16
 
 require (
 	github.com/alecthomas/template v0.0.0-20190718012654-fb15b899a751
@@ -10,17 +10,22 @@ require (
 	github.com/go-co-op/gocron v0.3.1
 	github.com/go-openapi/spec v0.19.9 // indirect
 	github.com/go-openapi/swag v0.19.9 // indirect
+	github.com/go-playground/validator/v10 v10.4.1
 	github.com/gofiber/cors v0.2.2
 	github.com/gofiber/fiber v1.14.6
 	github.com/gofiber/fiber/v2 v2.0.4
 	github.com/gofiber/jwt v0.2.0
 	github.com/gofiber/jwt/v2 v2.0.0
+	github.com/gofiber/utils v0.0.10
 	github.com/labstack/echo v3.3.10+incompatible
 	github.com/labstack/gommon v0.3.0
 	github.com/mailru/easyjson v0.7.6 // indirect
+	github.com/prometheus/client_golang v1.7.0
+	github.com/rs/zerolog v1.20.0
 	github.com/stretchr/testify v1.5.1
 	github.com/swaggo/swag v1.6.7
 	github.com/urfave/cli/v2 v2.2.0 // indirect
+	github.com/valyala/fasthttp v1.16.0
 	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 	golang.org/x/net v0.0.0-20200930145003-4acb6c075d10 // indirect
 	golang.org/x/sys v0.0.0-20200930185726-fdedc70b468f // indirect
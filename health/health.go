@@ -0,0 +1,85 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/migrations"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Status of the app or one of its checks
+const (
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+)
+
+const checkTimeout = 2 * time.Second
+
+// Report is the response of health endpoints
+type Report struct {
+	Status string            `json:"status" example:"ok"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Handler serves liveness and readiness probes
+type Handler struct {
+	db *gorm.DB
+}
+
+// NewHandler creates a health handler checking database
+func NewHandler(db *gorm.DB) *Handler {
+	return &Handler{db: db}
+}
+
+// Live function responds while the app is running
+func (h *Handler) Live(c *fiber.Ctx) error {
+	return c.JSON(Report{Status: StatusOK})
+}
+
+// Ready function responds whether the app can serve requests, the database must be reachable
+// and its schema migrated to the migrations of the app
+func (h *Handler) Ready(c *fiber.Ctx) error {
+	report := Report{
+		Status: StatusOK,
+		Checks: map[string]string{},
+	}
+	check := func(name string, fc func() error) {
+		if err := fc(); err != nil {
+			logger.Ctx(c).Warn().Err(err).Str("check", name).Msg("Readiness check failed.")
+			report.Checks[name] = StatusFailing
+			report.Status = StatusFailing
+			return
+		}
+		report.Checks[name] = StatusOK
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	check("database", func() error {
+		return h.ping(ctx)
+	})
+	if report.Status == StatusOK {
+		check("migrations", func() error {
+			return migrations.Verify(h.db.WithContext(ctx))
+		})
+	}
+
+	if report.Status != StatusOK {
+		return c.Status(http.StatusServiceUnavailable).JSON(report)
+	}
+	return c.JSON(report)
+}
+
+func (h *Handler) ping(ctx context.Context) error {
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
+}
@@ -0,0 +1,52 @@
+package health_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/health"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestHealth(t *testing.T) {
+	// the database is unreachable, gorm doesn't connect until the first query
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1 port=1 user=mycap dbname=mycap sslmode=disable connect_timeout=1"), &gorm.Config{
+		DisableAutomaticPing: true,
+	})
+	assert.Nil(t, err)
+
+	handler := health.NewHandler(db)
+	app := fiber.New()
+	app.Get("/health/live", handler.Live)
+	app.Get("/health/ready", handler.Ready)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		report health.Report
+	}{
+		{"Live", "/health/live", http.StatusOK, health.Report{Status: health.StatusOK}},
+		{"Ready without database", "/health/ready", http.StatusServiceUnavailable, health.Report{
+			Status: health.StatusFailing,
+			Checks: map[string]string{"database": health.StatusFailing},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := app.Test(httptest.NewRequest("GET", test.path, nil), 5000)
+			assert.Nil(t, err)
+			assert.Equal(t, test.status, res.StatusCode)
+
+			var report health.Report
+			assert.Nil(t, json.NewDecoder(res.Body).Decode(&report))
+			assert.Equal(t, test.report, report)
+		})
+	}
+}
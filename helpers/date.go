@@ -0,0 +1,12 @@
+package helpers
+
+import "time"
+
+// ParseDate parses a date in RFC3339 or 2006-01-02 format
+func ParseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.Parse("2006-01-02", value)
+}
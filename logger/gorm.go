@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// SlowQuery is the duration of a query logged as slow
+const SlowQuery = 200 * time.Millisecond
+
+// GORM writes logs of GORM with the logger of the query context or the application logger, failed
+// queries are logged as errors, slow queries as warnings and the other queries at debug level
+type GORM struct{}
+
+// LogMode is a no-op, the level of the application logger applies to GORM logs
+func (l GORM) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+// Info logs a message of GORM at info level
+func (GORM) Info(ctx context.Context, msg string, data ...interface{}) {
+	FromContext(ctx).Info().Msg(fmt.Sprintf(msg, data...))
+}
+
+// Warn logs a message of GORM at warn level
+func (GORM) Warn(ctx context.Context, msg string, data ...interface{}) {
+	FromContext(ctx).Warn().Msg(fmt.Sprintf(msg, data...))
+}
+
+// Error logs a message of GORM at error level
+func (GORM) Error(ctx context.Context, msg string, data ...interface{}) {
+	FromContext(ctx).Error().Msg(fmt.Sprintf(msg, data...))
+}
+
+// Redact wraps the dialector of the database so queries are logged with placeholders, bound values
+// such as password hashes, API key hashes, tokens and emails are never written to the logs.
+// GORM only explains queries to log them, migrations keep explaining with the wrapped dialector.
+func Redact(dialector gorm.Dialector) gorm.Dialector {
+	return redacted{dialector}
+}
+
+type redacted struct {
+	gorm.Dialector
+}
+
+// Explain returns the query without its values
+func (redacted) Explain(sql string, vars ...interface{}) string {
+	return sql
+}
+
+// SavePoint creates a savepoint of nested transactions with the wrapped dialector
+func (r redacted) SavePoint(tx *gorm.DB, name string) error {
+	if savePointer, ok := r.Dialector.(gorm.SavePointerDialectorInterface); ok {
+		return savePointer.SavePoint(tx, name)
+	}
+	return gorm.ErrUnsupportedDriver
+}
+
+// RollbackTo rolls nested transactions back to a savepoint with the wrapped dialector
+func (r redacted) RollbackTo(tx *gorm.DB, name string) error {
+	if savePointer, ok := r.Dialector.(gorm.SavePointerDialectorInterface); ok {
+		return savePointer.RollbackTo(tx, name)
+	}
+	return gorm.ErrUnsupportedDriver
+}
+
+// Trace logs an executed query, with placeholders when the dialector is redacted. Record not found errors are expected and logged at debug level
+func (GORM) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	l := FromContext(ctx)
+
+	event := l.Debug()
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		event = l.Error().Err(err)
+	case elapsed > SlowQuery:
+		event = l.Warn()
+	}
+	if !event.Enabled() {
+		return
+	}
+
+	sql, rows := fc()
+	event.Str("sql", sql).Int64("rows", rows).Dur("elapsed", elapsed).Msg("Query executed.")
+}
@@ -0,0 +1,201 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/helpers"
+	"github.com/dinopuguh/mycap-backend/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// HeaderRequestID is a request and response header carrying the request ID
+const HeaderRequestID = "X-Request-ID"
+
+const (
+	localsLogger = "logger"
+	localsStatus = "status"
+)
+
+// requestIDPattern limits request IDs sent by clients or proxies
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Log is the application logger writing JSON lines to stdout
+var Log = New(os.Stdout)
+
+func init() {
+	zerolog.TimeFieldFormat = time.RFC3339Nano
+	zerolog.DurationFieldUnit = time.Millisecond
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+}
+
+// New creates a JSON logger with timestamps
+func New(w io.Writer) zerolog.Logger {
+	return zerolog.New(w).With().Timestamp().Logger()
+}
+
+// SetLevel changes the level of every logger, it's safe to call while serving requests
+func SetLevel(level string) error {
+	l, err := zerolog.ParseLevel(strings.ToLower(level))
+	if err != nil {
+		return err
+	}
+	if l == zerolog.NoLevel {
+		return fmt.Errorf("Log level not specified.")
+	}
+
+	zerolog.SetGlobalLevel(l)
+	return nil
+}
+
+// Level returns the current log level
+func Level() string {
+	return zerolog.GlobalLevel().String()
+}
+
+// Ctx returns the logger of the request with its request ID and user ID, or the application
+// logger outside of RequestID
+func Ctx(c *fiber.Ctx) *zerolog.Logger {
+	if l, ok := c.Locals(localsLogger).(*zerolog.Logger); ok {
+		return l
+	}
+	return &Log
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying a logger, GORM logs queries run with the context by it
+func NewContext(ctx context.Context, l *zerolog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// Context returns a context carrying the logger of the request, queries run with
+// db.WithContext(logger.Context(c)) are logged with its request ID and user ID
+func Context(c *fiber.Ctx) context.Context {
+	return NewContext(context.Background(), Ctx(c))
+}
+
+// FromContext returns the logger carried by a context, or the application logger
+func FromContext(ctx context.Context) *zerolog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*zerolog.Logger); ok {
+			return l
+		}
+	}
+	return &Log
+}
+
+// RequestID is a middleware that reads the request ID from the request header or creates a new
+// one, the ID is sent back in the response header and added to the request logger
+func RequestID(c *fiber.Ctx) error {
+	id := c.Get(HeaderRequestID)
+	if !requestIDPattern.MatchString(id) {
+		var err error
+		if id, err = helpers.RandomToken(8); err != nil {
+			return err
+		}
+	}
+
+	c.Set(HeaderRequestID, id)
+
+	l := Log.With().Str("request_id", id).Logger()
+	c.Locals(localsLogger, &l)
+
+	return c.Next()
+}
+
+// SetUser adds the ID of the authenticated user to the request logger
+func SetUser(c *fiber.Ctx, userID uint) {
+	l := Ctx(c).With().Uint("user_id", userID).Logger()
+	c.Locals(localsLogger, &l)
+}
+
+// SetStatus records the status of an error responded in the body with HTTP 200, as v1 does
+func SetStatus(c *fiber.Ctx, status int) {
+	c.Locals(localsStatus, status)
+}
+
+// Status returns the status of a handled request, the status recorded by SetStatus for errors
+// responded in the body or the HTTP status otherwise
+func Status(c *fiber.Ctx) int {
+	if status, ok := c.Locals(localsStatus).(int); ok {
+		return status
+	}
+	return c.Response().StatusCode()
+}
+
+// Handle runs the next handlers of a request and returns the status sent. An error they return
+// is responded by the error handler of the app here, so middlewares observing requests such as
+// Requests and metrics.Requests share the status without responding the error twice.
+func Handle(c *fiber.Ctx) int {
+	if err := c.Next(); err != nil {
+		if err := c.App().Config().ErrorHandler(c, err); err != nil {
+			_ = c.SendStatus(fiber.StatusInternalServerError)
+		}
+	}
+
+	return Status(c)
+}
+
+// Requests is a middleware that logs every request after it's handled
+func Requests(c *fiber.Ctx) error {
+	start := time.Now()
+
+	status := Handle(c)
+	l := Ctx(c)
+	event := l.Info()
+	switch {
+	case status >= fiber.StatusInternalServerError:
+		event = l.Error()
+	case status >= fiber.StatusBadRequest:
+		event = l.Warn()
+	}
+
+	event.
+		Str("method", c.Method()).
+		Str("path", c.Path()).
+		Str("route", c.Route().Path).
+		Int("status", status).
+		Dur("latency", time.Since(start)).
+		Str("ip", c.IP()).
+		Str("user_agent", c.Get(fiber.HeaderUserAgent)).
+		Msg("Request handled.")
+
+	return nil
+}
+
+// ChangeLevel is a data transfer object for changing log level
+type ChangeLevel struct {
+	Level string `json:"level" validate:"required,oneof=trace debug info warn error" example:"debug"`
+}
+
+// GetLevel function responds the current log level
+func GetLevel(c *fiber.Ctx) error {
+	return c.JSON(ChangeLevel{Level: Level()})
+}
+
+// UpdateLevel function changes the log level while the app is running
+func UpdateLevel(c *fiber.Ctx) error {
+	changeLevel := new(ChangeLevel)
+	if err := c.BodyParser(&changeLevel); err != nil {
+		return apperror.Invalid("body_invalid", err.Error())
+	}
+
+	if err := validation.Struct(changeLevel); err != nil {
+		return err
+	}
+
+	if err := SetLevel(changeLevel.Level); err != nil {
+		return apperror.Invalid("log_level_invalid", err.Error())
+	}
+	Ctx(c).Info().Str("level", Level()).Msg("Log level changed.")
+
+	return c.JSON(ChangeLevel{Level: Level()})
+}
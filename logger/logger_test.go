@@ -0,0 +1,218 @@
+package logger_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// newApp serves requests logged to the returned buffer, the user ID is taken from the X-User-ID header
+func newApp() (*fiber.App, *bytes.Buffer) {
+	buf := new(bytes.Buffer)
+	logger.Log = logger.New(buf)
+
+	app := fiber.New()
+	app.Use(logger.RequestID)
+	app.Use(logger.Requests)
+	app.Get("/public", func(c *fiber.Ctx) error {
+		return c.SendString("public")
+	})
+	app.Get("/private", func(c *fiber.Ctx) error {
+		logger.SetUser(c, 7)
+		logger.Ctx(c).Info().Msg("Handling private request.")
+		return c.SendString("private")
+	})
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return apperror.NotFound("group_not_found", "Group not found.")
+	})
+	app.Get("/missing-v1", func(c *fiber.Ctx) error {
+		logger.SetStatus(c, http.StatusNotFound)
+		return c.JSON(fiber.Map{"status": http.StatusNotFound, "message": "Group not found."})
+	})
+	app.Get("/query", func(c *fiber.Ctx) error {
+		logger.GORM{}.Trace(logger.Context(c), time.Now(), func() (string, int64) {
+			return "SELECT 1", 1
+		}, errors.New("connection refused"))
+		return c.SendString("query")
+	})
+	app.Put("/log-level", logger.UpdateLevel)
+
+	return app, buf
+}
+
+func lines(buf *bytes.Buffer) []map[string]interface{} {
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		entry := make(map[string]interface{})
+		json.Unmarshal(scanner.Bytes(), &entry)
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestRequestID(t *testing.T) {
+	app, buf := newApp()
+
+	tests := []struct {
+		name      string
+		requestID string
+		generated bool
+	}{
+		{"Generated", "", true},
+		{"Propagated", "edge-1234.abc", false},
+		{"Invalid replaced", "bad id\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/public", nil)
+			req.Header.Set(logger.HeaderRequestID, tt.requestID)
+			res, _ := app.Test(req, -1)
+
+			requestID := res.Header.Get(logger.HeaderRequestID)
+			if tt.generated {
+				assert.Len(t, requestID, 16)
+			} else {
+				assert.Equal(t, tt.requestID, requestID)
+			}
+
+			entries := lines(buf)
+			assert.Len(t, entries, 1)
+			assert.Equal(t, requestID, entries[0]["request_id"])
+		})
+	}
+}
+
+func TestRequests(t *testing.T) {
+	app, buf := newApp()
+
+	res, _ := app.Test(httptest.NewRequest(http.MethodGet, "/private", nil), -1)
+	requestID := res.Header.Get(logger.HeaderRequestID)
+
+	entries := lines(buf)
+	assert.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, requestID, entry["request_id"])
+		assert.Equal(t, float64(7), entry["user_id"])
+	}
+	assert.Equal(t, "info", entries[1]["level"])
+	assert.Equal(t, "/private", entries[1]["route"])
+	assert.Equal(t, float64(http.StatusOK), entries[1]["status"])
+
+	buf.Reset()
+	res, _ = app.Test(httptest.NewRequest(http.MethodGet, "/missing", nil), -1)
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+	entries = lines(buf)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "error", entries[0]["level"])
+	assert.Nil(t, entries[0]["user_id"])
+
+	buf.Reset()
+	res, _ = app.Test(httptest.NewRequest(http.MethodGet, "/missing-v1", nil), -1)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	entries = lines(buf)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "warn", entries[0]["level"], "status in the body is logged")
+	assert.Equal(t, float64(http.StatusNotFound), entries[0]["status"])
+}
+
+func TestHandle(t *testing.T) {
+	responded := 0
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		responded++
+		return c.Status(fiber.StatusNotFound).SendString(err.Error())
+	}})
+
+	var statuses []int
+	observe := func(c *fiber.Ctx) error {
+		statuses = append(statuses, logger.Handle(c))
+		return nil
+	}
+	app.Use(observe, observe)
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return apperror.NotFound("group_not_found", "Group not found.")
+	})
+
+	app.Test(httptest.NewRequest(http.MethodGet, "/missing", nil), -1)
+
+	assert.Equal(t, 1, responded, "error responded once")
+	assert.Equal(t, []int{http.StatusNotFound, http.StatusNotFound}, statuses)
+}
+
+func TestGORM(t *testing.T) {
+	app, buf := newApp()
+
+	res, _ := app.Test(httptest.NewRequest(http.MethodGet, "/query", nil), -1)
+	requestID := res.Header.Get(logger.HeaderRequestID)
+
+	entries := lines(buf)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "error", entries[0]["level"])
+	assert.Equal(t, "SELECT 1", entries[0]["sql"])
+	assert.Equal(t, requestID, entries[0]["request_id"], "query is logged with the request logger")
+
+	buf.Reset()
+	logger.GORM{}.Trace(context.Background(), time.Now(), func() (string, int64) {
+		return "SELECT 1", 1
+	}, errors.New("connection refused"))
+
+	entries = lines(buf)
+	assert.Len(t, entries, 1)
+	assert.Nil(t, entries[0]["request_id"])
+}
+
+func TestRedact(t *testing.T) {
+	dialector := logger.Redact(postgres.Dialector{})
+
+	sql := dialector.Explain("SELECT * FROM users WHERE password = $1", "$2a$14$hash")
+	assert.Equal(t, "SELECT * FROM users WHERE password = $1", sql)
+	assert.Equal(t, "postgres", dialector.Name())
+	assert.Implements(t, (*gorm.SavePointerDialectorInterface)(nil), dialector, "nested transactions still supported")
+}
+
+func TestUpdateLevel(t *testing.T) {
+	app, buf := newApp()
+	defer logger.SetLevel("info")
+
+	tests := []struct {
+		name       string
+		body       string
+		statusCode int
+		level      string
+	}{
+		{"Level invalid", `{"level":"verbose"}`, http.StatusInternalServerError, "info"},
+		{"Valid change", `{"level":"warn"}`, http.StatusOK, "warn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/log-level", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			res, _ := app.Test(req, -1)
+
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			assert.Equal(t, tt.level, logger.Level())
+		})
+	}
+
+	buf.Reset()
+	app.Test(httptest.NewRequest(http.MethodGet, "/public", nil), -1)
+	assert.Empty(t, buf.String())
+}
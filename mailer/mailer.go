@@ -0,0 +1,106 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"sync"
+
+	"github.com/dinopuguh/mycap-backend/config"
+	"github.com/dinopuguh/mycap-backend/logger"
+)
+
+// Mailer sends an email message, ctx carries the logger of the request or job sending it
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// Default is the mailer used by services, log until configured
+var Default Mailer = Log{}
+
+// Configure sets the default mailer
+func Configure(cfg config.SMTP) {
+	Default = New(cfg)
+}
+
+// New creates a SMTP mailer, or a log mailer when no host is configured
+func New(cfg config.SMTP) Mailer {
+	if cfg.Host == "" {
+		return Log{}
+	}
+
+	return &SMTP{
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		From:     cfg.From,
+	}
+}
+
+// Send sends an email message with the default mailer
+func Send(ctx context.Context, to, subject, body string) error {
+	return Default.Send(ctx, to, subject, body)
+}
+
+// SMTP is a mailer that delivers messages through an SMTP server
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Send delivers an email message
+func (m *SMTP) Send(ctx context.Context, to, subject, body string) error {
+	auth := smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n", m.From, to, subject, body)
+
+	return smtp.SendMail(fmt.Sprintf("%s:%d", m.Host, m.Port), auth, m.From, []string{to}, []byte(msg))
+}
+
+// Log is a mailer that prints messages to the log for local development
+type Log struct{}
+
+// Send prints an email message
+func (Log) Send(ctx context.Context, to, subject, body string) error {
+	logger.FromContext(ctx).Info().Str("to", to).Str("subject", subject).Str("body", body).Msg("Mail sent to the log.")
+	return nil
+}
+
+// Message is an email message kept by Recorder
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Recorder is a mailer that keeps messages in memory for tests
+type Recorder struct {
+	mu       sync.Mutex
+	Messages []Message
+}
+
+// Send keeps an email message
+func (r *Recorder) Send(ctx context.Context, to, subject, body string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Messages = append(r.Messages, Message{To: to, Subject: subject, Body: body})
+	return nil
+}
+
+// Last returns the last message sent to the address
+func (r *Recorder) Last(to string) (Message, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := len(r.Messages) - 1; i >= 0; i-- {
+		if r.Messages[i].To == to {
+			return r.Messages[i], true
+		}
+	}
+
+	return Message{}, false
+}
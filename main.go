@@ -2,22 +2,30 @@ package main
 
 import (
 	"flag"
-	"log"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/dinopuguh/mycap-backend/config"
 	"github.com/dinopuguh/mycap-backend/database"
 	_ "github.com/dinopuguh/mycap-backend/docs"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/mailer"
+	"github.com/dinopuguh/mycap-backend/metrics"
 	"github.com/dinopuguh/mycap-backend/migrations"
 	"github.com/dinopuguh/mycap-backend/routes"
 	"github.com/dinopuguh/mycap-backend/scheduler"
 	"github.com/dinopuguh/mycap-backend/seed"
+	"github.com/dinopuguh/mycap-backend/services/notification"
 	"github.com/go-co-op/gocron"
+	"github.com/gofiber/fiber/v2"
 )
 
 // @title MyCap API
 // @version 1.0
-// @description This is an API for MyCap Application
+// @description This is an API for MyCap Application. Endpoints are documented under /v1, the same endpoints under /v2 respond with HTTP status codes, data only bodies and RFC 7807 problem details for errors.
 
 // @contact.name Dino Puguh
 // @contact.email dinopuguh@gmail.com
@@ -31,28 +39,92 @@ import (
 // @in header
 // @name Authorization
 func main() {
-	if err := database.Connect(); err != nil {
-		panic("Can't connect database.")
+	cfg, err := config.Load(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		logger.Log.Fatal().Err(err).Msg("Can't load config.")
+	}
+
+	if err := logger.SetLevel(cfg.LogLevel); err != nil {
+		logger.Log.Fatal().Err(err).Msg("Can't set log level.")
 	}
 
-	migrate := flag.Bool("migrate", false, "Migrate all models to database")
-	flag.Parse()
+	mailer.Configure(cfg.SMTP)
 
-	if *migrate {
-		migrations.All()
+	if err := database.ConnectRetry(cfg.Database); err != nil {
+		logger.Log.Fatal().Err(err).Msg("Can't connect database.")
+	}
+
+	if cfg.Migrate {
+		versions, err := migrations.Up(database.DBConn)
+		if err != nil {
+			logger.Log.Fatal().Err(err).Msg("Migrations failed.")
+		}
+		logger.Log.Info().Ints64("versions", versions).Msg("Migrations applied.")
+	}
+
+	if err := migrations.Check(database.DBConn); err != nil {
+		logger.Log.Fatal().Err(err).Msg("Schema check failed.")
 	}
 
 	for _, seeder := range seed.AllTypes() {
 		if err := seeder.Run(database.DBConn); err != nil {
-			log.Fatalln(err.Error())
+			logger.Log.Fatal().Err(err).Msg("Seeding failed.")
 		}
 	}
 
+	if err := metrics.Register(database.DBConn); err != nil {
+		logger.Log.Fatal().Err(err).Msg("Metrics registration failed.")
+	}
+
+	// services are configured by routes before scheduled jobs use them
+	app := routes.New(cfg)
+
 	cron := gocron.NewScheduler(time.UTC)
-	cron.Every(1).Month(8).Do(scheduler.ResetTimeLimit)
+	cron.Every(1).Hour().Do(scheduler.ResetTimeLimit)
+	cron.Every(1).Hour().Do(scheduler.ExpireUpgrades)
+	cron.Every(30).Seconds().Do(scheduler.CheckBalance)
 	cron.StartAsync()
 
-	port := os.Getenv("PORT")
-	app := routes.New()
-	log.Fatal(app.Listen(":" + port))
+	done := make(chan struct{})
+	go shutdown(app, cron, cfg.ShutdownTimeout, done)
+
+	if err := app.Listen(fmt.Sprintf(":%d", cfg.Port)); err != nil {
+		logger.Log.Fatal().Err(err).Msg("Server stopped.")
+	}
+	<-done
+}
+
+// shutdown stops the app gracefully on SIGINT or SIGTERM, scheduled jobs stop and live
+// notification streams end so in-flight requests can be drained before database is closed
+func shutdown(app *fiber.App, cron *gocron.Scheduler, timeout time.Duration, done chan<- struct{}) {
+	defer close(done)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	sig := <-quit
+	logger.Log.Info().Str("signal", sig.String()).Msg("Shutting down.")
+
+	cron.Stop()
+	notification.Live.Close()
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- app.Shutdown()
+	}()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			logger.Log.Error().Err(err).Msg("Shutdown server failed.")
+		}
+	case <-time.After(timeout):
+		logger.Log.Warn().Dur("timeout", timeout).Msg("Shutdown timed out, dropping in-flight requests.")
+	}
+
+	if err := database.Close(); err != nil {
+		logger.Log.Error().Err(err).Msg("Close database failed.")
+	}
+	logger.Log.Info().Msg("Server stopped.")
 }
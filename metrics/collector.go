@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+// dbCollector reports statistics of the database connection pool on every scrape
+type dbCollector struct {
+	db *sql.DB
+
+	maxOpen      *prometheus.Desc
+	open         *prometheus.Desc
+	inUse        *prometheus.Desc
+	idle         *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+}
+
+func newDBCollector(db *sql.DB) *dbCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db", name), help, nil, nil)
+	}
+
+	return &dbCollector{
+		db:           db,
+		maxOpen:      desc("max_open_connections", "Maximum number of open connections to the database."),
+		open:         desc("open_connections", "Number of established connections to the database."),
+		inUse:        desc("in_use_connections", "Number of connections currently in use."),
+		idle:         desc("idle_connections", "Number of idle connections."),
+		waitCount:    desc("wait_count_total", "Number of connections waited for."),
+		waitDuration: desc("wait_duration_seconds_total", "Time blocked waiting for a new connection."),
+	}
+}
+
+func (c *dbCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+}
+
+func (c *dbCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.db.Stats()
+
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+}
+
+// groupCollector counts active groups and their participants by group type on every scrape
+type groupCollector struct {
+	db *gorm.DB
+
+	groups       *prometheus.Desc
+	participants *prometheus.Desc
+}
+
+type typeCount struct {
+	Type  string
+	Count int64
+}
+
+func newGroupCollector(db *gorm.DB) *groupCollector {
+	return &groupCollector{
+		db:           db,
+		groups:       prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "active_groups"), "Number of active groups by type.", []string{"type"}, nil),
+		participants: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "active_participants"), "Number of participants of active groups by group type.", []string{"type"}, nil),
+	}
+}
+
+func (c *groupCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.groups
+	ch <- c.participants
+}
+
+func (c *groupCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(ch, c.groups, "SELECT type, COUNT(*) AS count FROM groups WHERE deleted_at IS NULL GROUP BY type")
+	c.collect(ch, c.participants, `SELECT g.type, COUNT(*) AS count FROM group_participants gp
+		JOIN groups g ON g.id = gp.group_id WHERE g.deleted_at IS NULL GROUP BY g.type`)
+}
+
+func (c *groupCollector) collect(ch chan<- prometheus.Metric, desc *prometheus.Desc, query string) {
+	var counts []typeCount
+	if err := c.db.Raw(query).Scan(&counts).Error; err != nil {
+		ch <- prometheus.NewInvalidMetric(desc, err)
+		return
+	}
+
+	for _, count := range counts {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(count.Count), count.Type)
+	}
+}
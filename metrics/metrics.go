@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+	"gorm.io/gorm"
+)
+
+const namespace = "mycap"
+
+// routeUnmatched labels requests not matching any endpoint, so scanners requesting random paths
+// don't create a series per path
+const routeUnmatched = "unmatched"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests handled by route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	minutesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "minutes_consumed_total",
+		Help:      "Captioning minutes used by ended groups by group type.",
+	}, []string{"type"})
+
+	jobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduler_jobs_total",
+		Help:      "Number of scheduler job runs by job and outcome.",
+	}, []string{"job", "outcome"})
+
+	jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "scheduler_job_duration_seconds",
+		Help:      "Duration of scheduler job runs by job.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"job"})
+)
+
+// Register adds collectors of database connections, active groups and participants
+func Register(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	if err := prometheus.Register(newDBCollector(sqlDB)); err != nil {
+		return err
+	}
+	return prometheus.Register(newGroupCollector(db))
+}
+
+// Handler responds the metrics in Prometheus text format
+func Handler(c *fiber.Ctx) error {
+	fasthttpadaptor.NewFastHTTPHandler(promhttp.Handler())(c.Context())
+	return nil
+}
+
+// Requests is a middleware that counts requests and observes their latency by route template
+// (/users/:id) instead of path
+func Requests(c *fiber.Ctx) error {
+	start := time.Now()
+
+	status := logger.Handle(c)
+	route := routeLabel(c, status)
+
+	httpRequests.WithLabelValues(c.Method(), route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(c.Method(), route).Observe(time.Since(start).Seconds())
+
+	return nil
+}
+
+// routeLabel returns the template of the route handling a request, requests not found by
+// a middleware matching every path under its prefix aren't labeled with the prefix
+func routeLabel(c *fiber.Ctx, status int) string {
+	route := c.Route()
+	if status == fiber.StatusNotFound && len(route.Params) == 0 &&
+		!strings.EqualFold(utils.TrimRight(c.Path(), '/'), utils.TrimRight(route.Path, '/')) {
+		return routeUnmatched
+	}
+
+	return route.Path
+}
+
+// ConsumeMinutes counts time used by an ended group, timeUsed is in milliseconds
+func ConsumeMinutes(groupType string, timeUsed int64) {
+	if timeUsed <= 0 {
+		return
+	}
+	minutesConsumed.WithLabelValues(groupType).Add(float64(timeUsed) / float64(time.Minute/time.Millisecond))
+}
+
+// ObserveJob counts a scheduler job run as success or failure and observes its duration
+func ObserveJob(job string, start time.Time, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+
+	jobRuns.WithLabelValues(job, outcome).Inc()
+	jobDuration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,84 @@
+package metrics_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/metrics"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApp() *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
+	app.Use(metrics.Requests)
+	app.Get("/metrics", metrics.Handler)
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		return c.SendString(c.Params("id"))
+	})
+	app.Get("/groups/:id", func(c *fiber.Ctx) error {
+		return apperror.NotFound("group_not_found", "Group not found.")
+	})
+	app.Use(func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNotFound)
+	})
+
+	return app
+}
+
+func scrape(t *testing.T, app *fiber.App) string {
+	res, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	assert.Nil(t, err)
+
+	return string(body)
+}
+
+func TestRequests(t *testing.T) {
+	app := newApp()
+
+	for _, path := range []string{"/users/1", "/users/2", "/groups/3", "/wp-login.php"} {
+		_, err := app.Test(httptest.NewRequest("GET", path, nil))
+		assert.Nil(t, err)
+	}
+
+	body := scrape(t, app)
+	assert.Contains(t, body, `mycap_http_requests_total{method="GET",route="/users/:id",status="200"} 2`)
+	assert.Contains(t, body, `mycap_http_requests_total{method="GET",route="/groups/:id",status="404"} 1`)
+	assert.Contains(t, body, `mycap_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `mycap_http_request_duration_seconds_count{method="GET",route="/users/:id"} 2`)
+	assert.NotContains(t, body, `/users/1`)
+	assert.NotContains(t, body, `wp-login`)
+}
+
+func TestConsumeMinutes(t *testing.T) {
+	app := newApp()
+
+	metrics.ConsumeMinutes("Conference", 90*time.Second.Milliseconds())
+	metrics.ConsumeMinutes("Conference", 0)
+
+	assert.Contains(t, scrape(t, app), `mycap_minutes_consumed_total{type="Conference"} 1.5`)
+}
+
+func TestObserveJob(t *testing.T) {
+	app := newApp()
+
+	metrics.ObserveJob("reset_time_limit", time.Now(), nil)
+	metrics.ObserveJob("reset_time_limit", time.Now(), errors.New("connection refused"))
+	metrics.ObserveJob("reset_time_limit", time.Now(), nil)
+
+	body := scrape(t, app)
+	assert.Contains(t, body, `mycap_scheduler_jobs_total{job="reset_time_limit",outcome="success"} 2`)
+	assert.Contains(t, body, `mycap_scheduler_jobs_total{job="reset_time_limit",outcome="failure"} 1`)
+	assert.Contains(t, body, `mycap_scheduler_job_duration_seconds_count{job="reset_time_limit"} 3`)
+}
@@ -1,18 +1,291 @@
 package migrations
 
 import (
-	"log"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
 
-	"github.com/dinopuguh/mycap-backend/database"
-	"github.com/dinopuguh/mycap-backend/services/group"
-	"github.com/dinopuguh/mycap-backend/services/user"
+	"gorm.io/gorm"
 )
 
-// All migrates all models to database
-func All() {
-	database.DBConn.AutoMigrate(&user.Type{})
-	database.DBConn.AutoMigrate(&user.User{})
-	database.DBConn.AutoMigrate(&group.Group{})
+//go:embed sql/*.sql
+var files embed.FS
 
-	log.Println("Models migrated to database.")
+// lockKey identifies the advisory lock held while migrating, so parallel instances
+// apply migrations one after another
+const lockKey = 4172011
+
+// ErrSchemaMismatch is returned when applied migrations don't match migrations of this build
+var ErrSchemaMismatch = errors.New("Database schema doesn't match this build, run migrations first.")
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// SchemaMigration is a model for applied migration
+type SchemaMigration struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false;"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// State represents a migration with time it was applied
+type State struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Load reads migrations embedded in this build ordered by version
+func Load() ([]Migration, error) {
+	return load(files, "sql")
+}
+
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("Migration file name %s invalid.", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("Migration version %d has different names.", version)
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("Migration version %d must have up and down files.", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// locked runs fc in a transaction holding the migration advisory lock, the lock is
+// released when the transaction ends
+func locked(db *gorm.DB, fc func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`CREATE TABLE IF NOT EXISTS "schema_migrations" ("version" bigint PRIMARY KEY, "name" text, "applied_at" timestamptz)`).Error; err != nil {
+			return err
+		}
+
+		return fc(tx)
+	})
+}
+
+func applied(tx *gorm.DB) (map[int64]SchemaMigration, error) {
+	var rows []SchemaMigration
+	if err := tx.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	versions := make(map[int64]SchemaMigration, len(rows))
+	for _, row := range rows {
+		versions[row.Version] = row
+	}
+
+	return versions, nil
+}
+
+// Up applies all pending migrations and returns the applied versions
+func Up(db *gorm.DB) ([]int64, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []int64
+	err = locked(db, func(tx *gorm.DB) error {
+		done, err := applied(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			if err := tx.Exec(migration.Up).Error; err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			if err := tx.Create(&SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error; err != nil {
+				return err
+			}
+			versions = append(versions, migration.Version)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// Down rolls back the latest applied migrations and returns the rolled back versions
+func Down(db *gorm.DB, steps int) ([]int64, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []int64
+	err = locked(db, func(tx *gorm.DB) error {
+		done, err := applied(tx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(versions) < steps; i-- {
+			migration := migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+
+			if err := tx.Exec(migration.Down).Error; err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			if err := tx.Delete(&SchemaMigration{}, migration.Version).Error; err != nil {
+				return err
+			}
+			versions = append(versions, migration.Version)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// Status returns migrations of this build with time they were applied, followed by
+// applied versions unknown to this build
+func Status(db *gorm.DB) ([]State, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var states []State
+	err = locked(db, func(tx *gorm.DB) error {
+		done, err := applied(tx)
+		if err != nil {
+			return err
+		}
+
+		states = merge(migrations, done)
+		return nil
+	})
+
+	return states, err
+}
+
+func merge(migrations []Migration, done map[int64]SchemaMigration) []State {
+	states := make([]State, 0, len(migrations))
+	for _, migration := range migrations {
+		state := State{Migration: migration}
+		if row, ok := done[migration.Version]; ok {
+			state.AppliedAt = &row.AppliedAt
+			delete(done, migration.Version)
+		}
+		states = append(states, state)
+	}
+
+	var unknown []State
+	for _, row := range done {
+		appliedAt := row.AppliedAt
+		unknown = append(unknown, State{Migration: Migration{Version: row.Version, Name: row.Name}, AppliedAt: &appliedAt})
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].Version < unknown[j].Version
+	})
+
+	return append(states, unknown...)
+}
+
+// Check returns ErrSchemaMismatch when a migration is pending or an applied version is unknown to this build
+func Check(db *gorm.DB) error {
+	states, err := Status(db)
+	if err != nil {
+		return err
+	}
+
+	return check(states)
+}
+
+// Verify is Check for frequent callers such as readiness probes, applied versions are only read
+// so it neither waits for a running migration nor creates the schema_migrations table. A missing
+// table is returned as error.
+func Verify(db *gorm.DB) error {
+	migrations, err := Load()
+	if err != nil {
+		return err
+	}
+
+	done, err := applied(db)
+	if err != nil {
+		return err
+	}
+
+	return check(merge(migrations, done))
+}
+
+func check(states []State) error {
+	for _, state := range states {
+		if state.AppliedAt == nil || state.Up == "" {
+			return ErrSchemaMismatch
+		}
+	}
+
+	return nil
 }
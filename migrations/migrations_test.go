@@ -0,0 +1,180 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/database/databasetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	for i, migration := range migrations {
+		assert.Equal(t, int64(i+1), migration.Version, "versions must be sequential")
+		assert.NotEmpty(t, migration.Up)
+		assert.NotEmpty(t, migration.Down)
+	}
+}
+
+func TestLoadFiles(t *testing.T) {
+	file := &fstest.MapFile{Data: []byte("SELECT 1;")}
+
+	tests := []struct {
+		description string
+		files       fstest.MapFS
+		versions    []int64
+		wantErr     bool
+	}{
+		{
+			description: "ordered by version",
+			files: fstest.MapFS{
+				"sql/0010_later.up.sql":     file,
+				"sql/0010_later.down.sql":   file,
+				"sql/0002_earlier.up.sql":   file,
+				"sql/0002_earlier.down.sql": file,
+			},
+			versions: []int64{2, 10},
+		},
+		{
+			description: "missing down file",
+			files: fstest.MapFS{
+				"sql/0001_initial.up.sql": file,
+			},
+			wantErr: true,
+		},
+		{
+			description: "different names of a version",
+			files: fstest.MapFS{
+				"sql/0001_initial.up.sql": file,
+				"sql/0001_other.down.sql": file,
+			},
+			wantErr: true,
+		},
+		{
+			description: "invalid file name",
+			files: fstest.MapFS{
+				"sql/initial.sql": file,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		migrations, err := load(test.files, "sql")
+		if test.wantErr {
+			assert.Errorf(t, err, test.description)
+			continue
+		}
+
+		assert.NoErrorf(t, err, test.description)
+		var versions []int64
+		for _, migration := range migrations {
+			versions = append(versions, migration.Version)
+		}
+		assert.Equalf(t, test.versions, versions, test.description)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+	applied := State{Migration: Migration{Version: 1, Up: "SELECT 1;"}, AppliedAt: &now}
+	pending := State{Migration: Migration{Version: 2, Up: "SELECT 1;"}}
+	unknown := State{Migration: Migration{Version: 3}, AppliedAt: &now}
+
+	assert.NoError(t, check([]State{applied}))
+	assert.Equal(t, ErrSchemaMismatch, check([]State{applied, pending}))
+	assert.Equal(t, ErrSchemaMismatch, check([]State{applied, unknown}))
+}
+
+// baseline is the schema AutoMigrate created for the models of the first release
+const baseline = `
+CREATE TABLE "types" ("id" bigserial, "created_at" timestamptz, "updated_at" timestamptz, "deleted_at" timestamptz,
+    "name" text, PRIMARY KEY ("id"));
+CREATE TABLE "users" ("id" bigserial, "created_at" timestamptz, "updated_at" timestamptz, "deleted_at" timestamptz,
+    "name" text, "username" text, "email" text, "password" text, "remaining_time" bigint DEFAULT 36000000,
+    "reached_time_limit" boolean DEFAULT false, "type_id" bigint, PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_type" FOREIGN KEY ("type_id") REFERENCES "types"("id"));
+CREATE TABLE "groups" ("id" bigserial, "created_at" timestamptz, "updated_at" timestamptz, "deleted_at" timestamptz,
+    "admin_id" bigint, "admin_username" text, "type" text, PRIMARY KEY ("id"),
+    CONSTRAINT "fk_groups_admin" FOREIGN KEY ("admin_id") REFERENCES "users"("id"));
+CREATE TABLE "group_participants" ("group_id" bigint, "user_id" bigint, PRIMARY KEY ("group_id","user_id"));
+
+INSERT INTO "types" ("name") VALUES ('Free');
+INSERT INTO "users" ("created_at", "name", "username", "type_id") VALUES (now() - interval '40 days', 'Dino', 'dinopuguh', 1);
+INSERT INTO "groups" ("created_at", "admin_id", "admin_username", "type") VALUES
+    (now(), 1, 'dinopuguh', 'Group'), (now(), 1, 'dinopuguh', 'Conference');
+`
+
+func TestUpBaseline(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	// a single connection keeps the search path of the test schema for every statement
+	cfg.Database.MaxOpenConns = 1
+	cfg.Database.MaxIdleConns = 1
+	assert.NoError(t, database.Connect(cfg.Database))
+	db := database.DBConn
+	defer database.Close()
+
+	assert.NoError(t, db.Exec(`DROP SCHEMA IF EXISTS "migrations_baseline" CASCADE; CREATE SCHEMA "migrations_baseline"; SET search_path TO "migrations_baseline"`).Error)
+	defer db.Exec(`SET search_path TO DEFAULT; DROP SCHEMA "migrations_baseline" CASCADE`)
+	assert.NoError(t, db.Exec(baseline).Error)
+	assert.Error(t, Verify(db), "schema_migrations is missing")
+
+	versions, err := Up(db)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int64(1), versions[0])
+	assert.NoError(t, Check(db))
+	assert.NoError(t, Verify(db))
+
+	var user struct {
+		ReferralCode      string
+		CycleAnchor       *time.Time
+		CycleEndsAt       *time.Time
+		BonusTime         int64
+		NotifiedThreshold int64
+	}
+	assert.NoError(t, db.Table("users").First(&user).Error)
+	assert.Len(t, user.ReferralCode, 8)
+	if assert.NotNil(t, user.CycleAnchor) && assert.NotNil(t, user.CycleEndsAt) {
+		assert.True(t, user.CycleEndsAt.After(time.Now()))
+	}
+
+	var groups []struct {
+		ID         uint
+		Visibility string
+		WarnedAt   *int64
+	}
+	assert.NoError(t, db.Table("groups").Where("deleted_at IS NULL").Find(&groups).Error)
+	if assert.Len(t, groups, 1, "only the latest group of an admin stays active") {
+		assert.Equal(t, uint(2), groups[0].ID)
+		assert.Equal(t, "public", groups[0].Visibility)
+		assert.Equal(t, int64(0), *groups[0].WarnedAt)
+	}
+
+	var limits struct {
+		MaxGroupParticipants      *int64
+		MaxConferenceParticipants *int64
+	}
+	assert.NoError(t, db.Table("types").First(&limits).Error)
+	assert.Equal(t, int64(0), *limits.MaxGroupParticipants, "existing plans stay unlimited")
+	assert.Equal(t, int64(0), *limits.MaxConferenceParticipants)
+
+	migrations, _ := Load()
+	versions, err = Down(db, len(migrations))
+	assert.NoError(t, err)
+	assert.Len(t, versions, len(migrations))
+
+	var count int64
+	assert.NoError(t, db.Table("users").Count(&count).Error, "rolling back the baseline leaves adopted tables")
+	assert.Equal(t, int64(1), count)
+	assert.NoError(t, db.Table("groups").Count(&count).Error)
+	assert.Error(t, db.Table("api_keys").Count(&count).Error, "tables of later migrations are dropped")
+}
@@ -0,0 +1,3 @@
+-- Tables of the first release existed before migrations and hold production data, rolling back
+-- the baseline leaves them in place.
+SELECT 1;
@@ -0,0 +1,50 @@
+-- Baseline schema of the first release, which AutoMigrate created before versioned migrations.
+-- Statements are idempotent so existing databases are adopted without changes.
+
+CREATE TABLE IF NOT EXISTS "types" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_types_deleted_at" ON "types" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    "username" text,
+    "email" text,
+    "password" text,
+    "remaining_time" bigint DEFAULT 36000000,
+    "reached_time_limit" boolean DEFAULT false,
+    "type_id" bigint,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_type" FOREIGN KEY ("type_id") REFERENCES "types"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "groups" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "admin_id" bigint,
+    "admin_username" text,
+    "type" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_groups_admin" FOREIGN KEY ("admin_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_groups_deleted_at" ON "groups" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "group_participants" (
+    "group_id" bigint,
+    "user_id" bigint,
+    PRIMARY KEY ("group_id","user_id"),
+    CONSTRAINT "fk_group_participants_group" FOREIGN KEY ("group_id") REFERENCES "groups"("id"),
+    CONSTRAINT "fk_group_participants_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "states";
+DROP TABLE IF EXISTS "identities";
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS "identities" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "provider" text,
+    "subject" text,
+    "email" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_identities_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_identity_provider_subject" ON "identities" ("provider","subject");
+CREATE INDEX IF NOT EXISTS "idx_identities_deleted_at" ON "identities" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "states" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "provider" text,
+    "state" text,
+    "nonce" text,
+    "code_verifier" text,
+    "expires_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_states_state" ON "states" ("state");
+CREATE INDEX IF NOT EXISTS "idx_states_provider" ON "states" ("provider");
+CREATE INDEX IF NOT EXISTS "idx_states_deleted_at" ON "states" ("deleted_at");
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "email_changes" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "new_email" text,
+    "token_hash" text,
+    "expires_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_email_changes_token_hash" ON "email_changes" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_email_changes_user_id" ON "email_changes" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_email_changes_deleted_at" ON "email_changes" ("deleted_at");
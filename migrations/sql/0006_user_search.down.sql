@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "idx_users_created_at";
+DROP INDEX IF EXISTS "idx_users_lower_name";
+DROP INDEX IF EXISTS "idx_users_lower_username";
+DROP INDEX IF EXISTS "idx_users_type_id";
+DROP INDEX IF EXISTS "idx_users_reached_time_limit";
@@ -0,0 +1,5 @@
+CREATE INDEX IF NOT EXISTS "idx_users_reached_time_limit" ON "users" ("reached_time_limit");
+CREATE INDEX IF NOT EXISTS "idx_users_type_id" ON "users" ("type_id");
+CREATE INDEX IF NOT EXISTS "idx_users_lower_username" ON "users" (LOWER("username") text_pattern_ops);
+CREATE INDEX IF NOT EXISTS "idx_users_lower_name" ON "users" (LOWER("name") text_pattern_ops);
+CREATE INDEX IF NOT EXISTS "idx_users_created_at" ON "users" ("created_at");
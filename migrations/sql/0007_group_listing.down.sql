@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "idx_groups_admin_id";
+DROP INDEX IF EXISTS "idx_groups_admin_username";
+DROP INDEX IF EXISTS "idx_groups_type";
+ALTER TABLE "groups" DROP COLUMN IF EXISTS "visibility";
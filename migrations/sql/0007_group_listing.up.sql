@@ -0,0 +1,5 @@
+ALTER TABLE "groups" ADD COLUMN IF NOT EXISTS "visibility" text DEFAULT 'public';
+CREATE INDEX IF NOT EXISTS "idx_groups_visibility" ON "groups" ("visibility");
+CREATE INDEX IF NOT EXISTS "idx_groups_type" ON "groups" ("type");
+CREATE INDEX IF NOT EXISTS "idx_groups_admin_username" ON "groups" ("admin_username");
+CREATE INDEX IF NOT EXISTS "idx_groups_admin_id" ON "groups" ("admin_id");
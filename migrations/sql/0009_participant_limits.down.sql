@@ -0,0 +1,2 @@
+ALTER TABLE "types" DROP COLUMN IF EXISTS "max_conference_participants";
+ALTER TABLE "types" DROP COLUMN IF EXISTS "max_group_participants";
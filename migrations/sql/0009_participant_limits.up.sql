@@ -0,0 +1,6 @@
+ALTER TABLE "types" ADD COLUMN IF NOT EXISTS "max_group_participants" bigint;
+ALTER TABLE "types" ADD COLUMN IF NOT EXISTS "max_conference_participants" bigint;
+
+-- plans created before participant limits existed stay unlimited
+UPDATE "types" SET "max_group_participants" = 0 WHERE "max_group_participants" IS NULL;
+UPDATE "types" SET "max_conference_participants" = 0 WHERE "max_conference_participants" IS NULL;
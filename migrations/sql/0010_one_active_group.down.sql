@@ -0,0 +1,2 @@
+-- groups ended by the up migration stay ended
+DROP INDEX IF EXISTS "idx_groups_active_admin";
@@ -0,0 +1,5 @@
+-- admins could have several active groups before one group per admin was enforced, only the
+-- latest group of an admin stays active
+UPDATE "groups" SET "deleted_at" = now()
+    WHERE "deleted_at" IS NULL AND "id" NOT IN (SELECT MAX("id") FROM "groups" WHERE "deleted_at" IS NULL GROUP BY "admin_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_groups_active_admin" ON "groups" ("admin_id") WHERE "deleted_at" IS NULL;
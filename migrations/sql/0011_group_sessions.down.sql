@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "group_session_participants";
+DROP TABLE IF EXISTS "group_sessions";
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS "group_sessions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "group_id" bigint,
+    "admin_id" bigint,
+    "admin_username" text,
+    "type" text,
+    "started_at" timestamptz,
+    "ended_at" timestamptz,
+    "duration" bigint,
+    "time_used" bigint,
+    "participant_count" bigint,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_group_sessions_admin_id" ON "group_sessions" ("admin_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_group_sessions_group_id" ON "group_sessions" ("group_id");
+CREATE INDEX IF NOT EXISTS "idx_group_sessions_deleted_at" ON "group_sessions" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_group_sessions_started_at" ON "group_sessions" ("started_at");
+
+CREATE TABLE IF NOT EXISTS "group_session_participants" (
+    "group_session_id" bigint,
+    "user_id" bigint,
+    PRIMARY KEY ("group_session_id","user_id"),
+    CONSTRAINT "fk_group_session_participants_group_session" FOREIGN KEY ("group_session_id") REFERENCES "group_sessions"("id"),
+    CONSTRAINT "fk_group_session_participants_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
@@ -0,0 +1,3 @@
+ALTER TABLE "groups" DROP COLUMN IF EXISTS "warned_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "notified_threshold";
+DROP TABLE IF EXISTS "notifications";
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS "notifications" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "kind" text,
+    "title" text,
+    "message" text,
+    "read_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_notifications_user_id" ON "notifications" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_notifications_deleted_at" ON "notifications" ("deleted_at");
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "notified_threshold" bigint DEFAULT 0;
+ALTER TABLE "groups" ADD COLUMN IF NOT EXISTS "warned_at" bigint;
+
+-- no warning was sent to admins of existing groups yet
+UPDATE "groups" SET "warned_at" = 0 WHERE "warned_at" IS NULL;
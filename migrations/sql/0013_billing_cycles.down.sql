@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "cycle_resets";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "cycle_ends_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "cycle_anchor";
@@ -0,0 +1,22 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "cycle_anchor" timestamptz;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "cycle_ends_at" timestamptz;
+CREATE INDEX IF NOT EXISTS "idx_users_cycle_ends_at" ON "users" ("cycle_ends_at");
+
+CREATE TABLE IF NOT EXISTS "cycle_resets" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "cycle_start" timestamptz,
+    "previous_remaining_time" bigint,
+    "remaining_time" bigint,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_cycle_resets_user_cycle" ON "cycle_resets" ("user_id","cycle_start");
+CREATE INDEX IF NOT EXISTS "idx_cycle_resets_deleted_at" ON "cycle_resets" ("deleted_at");
+
+-- anchor billing cycles of users registered before cycles existed at their registration date
+UPDATE "users" SET "cycle_anchor" = "created_at",
+    "cycle_ends_at" = "created_at" + make_interval(months => (EXTRACT(YEAR FROM age(now(), "created_at")) * 12 + EXTRACT(MONTH FROM age(now(), "created_at")))::int + 1)
+    WHERE "cycle_anchor" IS NULL;
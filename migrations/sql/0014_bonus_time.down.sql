@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "redemptions";
+DROP TABLE IF EXISTS "promo_codes";
+DROP TABLE IF EXISTS "bonus_entries";
+DROP INDEX IF EXISTS "idx_users_referral_code";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "referred_by_id";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "referral_code";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "bonus_time";
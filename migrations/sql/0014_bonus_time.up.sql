@@ -0,0 +1,57 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "bonus_time" bigint DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "referral_code" text;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "referred_by_id" bigint;
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_referral_code" ON "users" ("referral_code");
+
+CREATE TABLE IF NOT EXISTS "bonus_entries" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "amount" bigint,
+    "reason" text,
+    "reference" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_bonus_entries_user_id" ON "bonus_entries" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_bonus_entries_deleted_at" ON "bonus_entries" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "promo_codes" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "code" text,
+    "bonus_time" bigint,
+    "upgrade_type_id" bigint,
+    "upgrade_days" bigint,
+    "max_redemptions" bigint,
+    "redemptions" bigint,
+    "expires_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_promo_codes_code" ON "promo_codes" ("code");
+CREATE INDEX IF NOT EXISTS "idx_promo_codes_deleted_at" ON "promo_codes" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "redemptions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "promo_code_id" bigint,
+    "user_id" bigint,
+    "bonus_time" bigint,
+    "upgrade_type_id" bigint,
+    "previous_type_id" bigint,
+    "upgrade_ends_at" timestamptz,
+    "reverted" boolean DEFAULT false,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_redemptions_upgrade_ends_at" ON "redemptions" ("upgrade_ends_at");
+CREATE INDEX IF NOT EXISTS "idx_redemptions_user_id" ON "redemptions" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_redemptions_promo_user" ON "redemptions" ("promo_code_id","user_id");
+CREATE INDEX IF NOT EXISTS "idx_redemptions_deleted_at" ON "redemptions" ("deleted_at");
+
+-- users registered before referrals existed get a referral code
+UPDATE "users" SET "referral_code" = UPPER(SUBSTR(MD5(RANDOM()::text || "id"::text), 1, 8)) WHERE "referral_code" IS NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "pool_entries";
+DROP TABLE IF EXISTS "invitations";
+DROP TABLE IF EXISTS "members";
+DROP TABLE IF EXISTS "organizations";
@@ -0,0 +1,59 @@
+CREATE TABLE IF NOT EXISTS "organizations" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    "pooled_time" bigint,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_organizations_deleted_at" ON "organizations" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "members" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "user_id" bigint,
+    "role" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_members_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_organizations_members" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_members_user_id" ON "members" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_members_organization_id" ON "members" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_members_deleted_at" ON "members" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "invitations" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "email" text,
+    "role" text,
+    "token_hash" text,
+    "expires_at" timestamptz,
+    "accepted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_invitations_deleted_at" ON "invitations" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invitations_token_hash" ON "invitations" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_invitations_organization_id" ON "invitations" ("organization_id");
+
+CREATE TABLE IF NOT EXISTS "pool_entries" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "user_id" bigint,
+    "amount" bigint,
+    "time_used" bigint,
+    "reference" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_pool_entries_organization_id" ON "pool_entries" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_pool_entries_deleted_at" ON "pool_entries" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_pool_entries_user_id" ON "pool_entries" ("user_id");
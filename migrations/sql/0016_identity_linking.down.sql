@@ -0,0 +1,2 @@
+ALTER TABLE "states" DROP COLUMN IF EXISTS "user_id";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "email_confirmed_at";
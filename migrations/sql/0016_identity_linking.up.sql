@@ -0,0 +1,2 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "email_confirmed_at" timestamptz;
+ALTER TABLE "states" ADD COLUMN IF NOT EXISTS "user_id" bigint;
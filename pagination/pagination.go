@@ -0,0 +1,100 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultLimit is the page size when limit is not specified
+	DefaultLimit = 20
+	// MaxLimit is the largest page size a client can request
+	MaxLimit = 100
+)
+
+// Page is a requested window of a list
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// Response represents paginated list in response body
+type Response struct {
+	Items      interface{} `json:"items"`
+	Total      int64       `json:"total"`
+	Limit      int         `json:"limit"`
+	NextCursor string      `json:"next_cursor"`
+}
+
+// Parse reads limit and cursor query of the request
+func Parse(c *fiber.Ctx) (Page, error) {
+	page := Page{Limit: DefaultLimit}
+
+	if limit := c.Query("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 || l > MaxLimit {
+			return page, apperror.Invalid("pagination_limit_invalid", "Limit must be between 1 and %d.", MaxLimit)
+		}
+		page.Limit = l
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil || !strings.HasPrefix(string(decoded), "offset:") {
+			return page, apperror.Invalid("pagination_cursor_invalid", "Cursor invalid.")
+		}
+
+		offset, err := strconv.Atoi(strings.TrimPrefix(string(decoded), "offset:"))
+		if err != nil || offset < 0 {
+			return page, apperror.Invalid("pagination_cursor_invalid", "Cursor invalid.")
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
+
+// Scope limits a query to the page
+func (p Page) Scope(db *gorm.DB) *gorm.DB {
+	return db.Limit(p.Limit).Offset(p.Offset)
+}
+
+// Response wraps page items with total count and cursor of the next page
+func (p Page) Response(items interface{}, count int, total int64) Response {
+	res := Response{
+		Items: items,
+		Total: total,
+		Limit: p.Limit,
+	}
+
+	if next := p.Offset + count; count == p.Limit && int64(next) < total {
+		res.NextCursor = base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(next)))
+	}
+
+	return res
+}
+
+// Sort converts sort query such as "-created_at" to an order clause from allowed columns
+func Sort(sort string, columns map[string]string, fallback string) (string, error) {
+	if sort == "" {
+		return fallback, nil
+	}
+
+	direction := "asc"
+	if strings.HasPrefix(sort, "-") {
+		direction = "desc"
+		sort = strings.TrimPrefix(sort, "-")
+	}
+
+	column, ok := columns[sort]
+	if !ok {
+		return "", apperror.Invalid("sort_invalid", "Sort by %s not supported.", sort)
+	}
+
+	return column + " " + direction + ", id " + direction, nil
+}
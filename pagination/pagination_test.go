@@ -0,0 +1,37 @@
+package pagination_test
+
+import (
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/pagination"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponse(t *testing.T) {
+	page := pagination.Page{Limit: 2}
+
+	res := page.Response([]int{1, 2}, 2, 5)
+	assert.Equal(t, int64(5), res.Total)
+	assert.NotEmpty(t, res.NextCursor)
+
+	res = pagination.Page{Limit: 2, Offset: 4}.Response([]int{5}, 1, 5)
+	assert.Empty(t, res.NextCursor)
+
+	res = pagination.Page{Limit: 2, Offset: 3}.Response([]int{4, 5}, 2, 5)
+	assert.Empty(t, res.NextCursor)
+}
+
+func TestSort(t *testing.T) {
+	columns := map[string]string{"name": "name"}
+
+	order, err := pagination.Sort("", columns, "id asc")
+	assert.NoError(t, err)
+	assert.Equal(t, "id asc", order)
+
+	order, err = pagination.Sort("-name", columns, "id asc")
+	assert.NoError(t, err)
+	assert.Equal(t, "name desc, id desc", order)
+
+	_, err = pagination.Sort("password", columns, "id asc")
+	assert.Error(t, err)
+}
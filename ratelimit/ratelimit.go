@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/auth"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Response headers describing the limit of a request
+const (
+	HeaderLimit     = "RateLimit-Limit"
+	HeaderRemaining = "RateLimit-Remaining"
+	HeaderReset     = "RateLimit-Reset"
+)
+
+// Policy limits requests of a client to Limit per Window, clients are told apart by Key
+type Policy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Key    func(c *fiber.Ctx) string
+}
+
+// ByIP returns the IP address of the client as key, the last address is used when a proxy header
+// lists several since it's the one added by the proxy
+func ByIP(c *fiber.Ctx) string {
+	ip := c.IP()
+	if i := strings.LastIndexByte(ip, ','); i >= 0 {
+		ip = ip[i+1:]
+	}
+
+	return "ip:" + strings.TrimSpace(ip)
+}
+
+// ByUser returns the ID of the authenticated user as key, or the IP address for anonymous requests
+func ByUser(c *fiber.Ctx) string {
+	if userID := auth.UserID(c); userID != 0 {
+		return fmt.Sprintf("user:%d", userID)
+	}
+
+	return ByIP(c)
+}
+
+// Limiter limits requests with policies counted in a store
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+// New creates a limiter counting requests in a store
+func New(store Store) *Limiter {
+	return &Limiter{store: store, now: time.Now}
+}
+
+// Limit creates a middleware that rejects requests over the limit of a policy, every request gets
+// RateLimit-* headers and rejected ones a Retry-After header. Requests aren't limited when the
+// policy has no limit or the store fails.
+func (l *Limiter) Limit(policy Policy) fiber.Handler {
+	if policy.Limit <= 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		count, reset, err := l.store.Increment(policy.Name+":"+policy.Key(c), policy.Window)
+		if err != nil {
+			logger.Ctx(c).Error().Err(err).Str("policy", policy.Name).Msg("Rate limit store failed.")
+			return c.Next()
+		}
+
+		remaining := policy.Limit - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		seconds := int(math.Ceil(reset.Sub(l.now()).Seconds()))
+		if seconds < 0 {
+			seconds = 0
+		}
+
+		c.Set(HeaderLimit, strconv.Itoa(policy.Limit))
+		c.Set(HeaderRemaining, strconv.Itoa(remaining))
+		c.Set(HeaderReset, strconv.Itoa(seconds))
+
+		if count > policy.Limit {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+			return apperror.RateLimited("rate_limited", "Too many requests, retry in %d seconds.", seconds)
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/auth"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func TestMemoryStore(t *testing.T) {
+	clock := &clock{now: time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clock.Now
+
+	for i := 1; i <= 3; i++ {
+		count, reset, err := store.Increment("login:ip:10.0.0.1", time.Minute)
+		assert.Nil(t, err)
+		assert.Equal(t, i, count)
+		assert.Equal(t, clock.now.Add(time.Minute), reset)
+	}
+
+	count, _, _ := store.Increment("login:ip:10.0.0.2", time.Minute)
+	assert.Equal(t, 1, count)
+
+	clock.now = clock.now.Add(time.Minute)
+	count, reset, _ := store.Increment("login:ip:10.0.0.1", time.Minute)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, clock.now.Add(time.Minute), reset)
+	assert.Len(t, store.counters, 1, "ended windows are swept")
+}
+
+func TestLimit(t *testing.T) {
+	clock := &clock{now: time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clock.Now
+	limiter := New(store)
+	limiter.now = clock.Now
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+		ProxyHeader:  fiber.HeaderXForwardedFor,
+	})
+	app.Post("/login", limiter.Limit(Policy{Name: "auth", Limit: 2, Window: time.Minute, Key: ByIP}), func(c *fiber.Ctx) error {
+		return c.SendString("signed in")
+	})
+	app.Get("/groups", func(c *fiber.Ctx) error {
+		if c.Get("X-User-ID") != "" {
+			auth.SetUser(c, 7)
+		}
+		return c.Next()
+	}, limiter.Limit(Policy{Name: "read", Limit: 1, Window: time.Minute, Key: ByUser}), func(c *fiber.Ctx) error {
+		return c.SendString("groups")
+	})
+	app.Get("/unlimited", limiter.Limit(Policy{Name: "unlimited", Window: time.Minute, Key: ByIP}), func(c *fiber.Ctx) error {
+		return c.SendString("unlimited")
+	})
+
+	type request struct {
+		method    string
+		path      string
+		ip        string
+		user      bool
+		limited   bool
+		remaining string
+	}
+
+	tests := []struct {
+		name     string
+		requests []request
+	}{
+		{"Limited by IP", []request{
+			{"POST", "/login", "10.0.0.1", false, false, "1"},
+			{"POST", "/login", "10.0.0.1", false, false, "0"},
+			{"POST", "/login", "10.0.0.1", false, true, "0"},
+			{"POST", "/login", "10.0.0.2", false, false, "1"},
+		}},
+		{"Last proxy address used", []request{
+			{"POST", "/login", "1.2.3.4, 10.0.0.3", false, false, "1"},
+			{"POST", "/login", "5.6.7.8, 10.0.0.3", false, false, "0"},
+			{"POST", "/login", "10.0.0.3", false, true, "0"},
+		}},
+		{"Limited by user", []request{
+			{"GET", "/groups", "10.0.0.4", true, false, "0"},
+			{"GET", "/groups", "10.0.0.5", true, true, "0"},
+			{"GET", "/groups", "10.0.0.4", false, false, "0"},
+		}},
+		{"Unlimited", []request{
+			{"GET", "/unlimited", "10.0.0.6", false, false, ""},
+			{"GET", "/unlimited", "10.0.0.6", false, false, ""},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, r := range test.requests {
+				req := httptest.NewRequest(r.method, r.path, nil)
+				req.Header.Set(fiber.HeaderXForwardedFor, r.ip)
+				if r.user {
+					req.Header.Set("X-User-ID", "7")
+				}
+
+				res, err := app.Test(req)
+				assert.Nil(t, err)
+				assert.Equal(t, r.remaining, res.Header.Get(HeaderRemaining))
+
+				if r.remaining != "" {
+					assert.NotEmpty(t, res.Header.Get(HeaderLimit))
+					assert.Equal(t, "60", res.Header.Get(HeaderReset))
+				}
+
+				if !r.limited {
+					assert.Empty(t, res.Header.Get(fiber.HeaderRetryAfter))
+					continue
+				}
+
+				var body response.HTTP
+				json.NewDecoder(res.Body).Decode(&body)
+				assert.Equal(t, http.StatusTooManyRequests, body.Status)
+				assert.Equal(t, "Too many requests, retry in 60 seconds.", body.Message)
+				assert.Equal(t, "60", res.Header.Get(fiber.HeaderRetryAfter))
+			}
+		})
+	}
+}
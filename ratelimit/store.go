@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Store counts requests of keys in fixed windows, a store shared by instances of the app such as
+// Redis enforces limits across all of them
+type Store interface {
+	// Increment counts a request of a key, returns the number of requests counted in the current
+	// window and when the window resets
+	Increment(key string, window time.Duration) (int, time.Time, error)
+}
+
+type counter struct {
+	count int
+	reset time.Time
+}
+
+// MemoryStore is a store counting requests of an instance of the app in memory
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore creates an empty memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: map[string]*counter{},
+		now:      time.Now,
+	}
+}
+
+// Increment counts a request of a key, counters of ended windows are removed once per window so
+// the store doesn't grow with every client seen
+func (s *MemoryStore) Increment(key string, window time.Duration) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if !now.Before(s.nextSweep) {
+		for k, c := range s.counters {
+			if !now.Before(c.reset) {
+				delete(s.counters, k)
+			}
+		}
+		s.nextSweep = now.Add(window)
+	}
+
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.reset) {
+		c = &counter{reset: now.Add(window)}
+		s.counters[key] = c
+	}
+	c.count++
+
+	return c.count, c.reset, nil
+}
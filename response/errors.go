@@ -0,0 +1,56 @@
+package response
+
+import (
+	"net/http"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorHandler responds errors returned by handlers in the v1 response body. Domain errors keep
+// their message, other errors are failures of the database or other services and are responded
+// with status 503 like v1 always did.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	if e, ok := err.(*fiber.Error); ok {
+		return fiber.DefaultErrorHandler(c, e)
+	}
+
+	status, _, message, data := describe(err)
+	if status == http.StatusInternalServerError {
+		status = http.StatusServiceUnavailable
+	}
+	logError(c, status, err)
+	logger.SetStatus(c, status)
+
+	return c.JSON(HTTP{
+		Data:    data,
+		Status:  status,
+		Message: message,
+	})
+}
+
+// describe returns the status, code, message and data of an error. Errors outside the domain are
+// responded as internal errors without their details, which are only logged.
+func describe(err error) (int, string, string, interface{}) {
+	if domainErr, ok := apperror.As(err); ok {
+		return domainErr.Status(), domainErr.Code, domainErr.Message, domainErr.Data
+	}
+	return http.StatusInternalServerError, statusCode(http.StatusInternalServerError), "Internal server error.", nil
+}
+
+// logError logs failures as errors and domain errors for debugging, with the request logger and
+// its request ID
+func logError(c *fiber.Ctx, status int, err error) {
+	l := logger.Ctx(c)
+	event := l.Debug()
+	if status >= http.StatusInternalServerError {
+		event = l.Error()
+	}
+
+	event.Err(err).
+		Str("method", c.Method()).
+		Str("path", c.OriginalURL()).
+		Int("status", status).
+		Msg("Request failed.")
+}
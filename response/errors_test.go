@@ -0,0 +1,106 @@
+package response_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorHandler(t *testing.T) {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
+	app.Get("/not-found", func(c *fiber.Ctx) error {
+		return apperror.NotFound("group_not_found", "Group not found.")
+	})
+	app.Get("/invalid", func(c *fiber.Ctx) error {
+		return apperror.Validation("validation_failed", "Request body invalid.", []fiber.Map{{"field": "email", "message": "is required"}})
+	})
+	app.Get("/failure", func(c *fiber.Ctx) error {
+		return errors.New("connection refused")
+	})
+
+	tests := []struct {
+		name       string
+		endpoint   string
+		statusCode int
+		status     int
+		message    string
+		data       interface{}
+	}{
+		{"Domain error", "/not-found", http.StatusOK, http.StatusNotFound, "Group not found.", nil},
+		{"Domain error with data", "/invalid", http.StatusOK, http.StatusBadRequest, "Request body invalid.", []interface{}{map[string]interface{}{"field": "email", "message": "is required"}}},
+		{"Other error", "/failure", http.StatusOK, http.StatusServiceUnavailable, "Internal server error.", nil},
+		{"Unknown route", "/unknown", http.StatusNotFound, 0, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, _ := app.Test(httptest.NewRequest(http.MethodGet, tt.endpoint, nil), -1)
+			defer res.Body.Close()
+			resBody, _ := ioutil.ReadAll(res.Body)
+
+			assert.Equalf(t, tt.statusCode, res.StatusCode, string(resBody))
+			if tt.status == 0 {
+				return
+			}
+
+			resHTTP := new(response.HTTP)
+			json.Unmarshal(resBody, &resHTTP)
+			assert.False(t, resHTTP.Success)
+			assert.Equal(t, tt.status, resHTTP.Status)
+			assert.Equal(t, tt.message, resHTTP.Message)
+			assert.Equal(t, tt.data, resHTTP.Data)
+		})
+	}
+}
+
+func TestProblemsDomainErrors(t *testing.T) {
+	app := fiber.New()
+	app.Use(response.Problems)
+	app.Get("/conflict", func(c *fiber.Ctx) error {
+		return apperror.Conflict("group_already_joined", "You already joined this group.")
+	})
+	app.Get("/invalid", func(c *fiber.Ctx) error {
+		return apperror.Validation("validation_failed", "Request body invalid.", []fiber.Map{{"field": "email", "message": "is required"}})
+	})
+	app.Get("/failure", func(c *fiber.Ctx) error {
+		return errors.New("dial tcp 10.0.0.5:5432: connection refused")
+	})
+
+	tests := []struct {
+		name       string
+		endpoint   string
+		statusCode int
+		code       string
+		detail     string
+		errors     string
+	}{
+		{"Domain error", "/conflict", http.StatusConflict, "group_already_joined", "You already joined this group.", ""},
+		{"Validation error", "/invalid", http.StatusBadRequest, "validation_failed", "Request body invalid.", `[{"field":"email","message":"is required"}]`},
+		{"Other error", "/failure", http.StatusInternalServerError, "internal_error", "Internal server error.", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, _ := app.Test(httptest.NewRequest(http.MethodGet, tt.endpoint, nil), -1)
+			defer res.Body.Close()
+			resBody, _ := ioutil.ReadAll(res.Body)
+
+			problem := new(response.Problem)
+			json.Unmarshal(resBody, &problem)
+			assert.Equalf(t, tt.statusCode, res.StatusCode, string(resBody))
+			assert.Equal(t, tt.code, problem.Code)
+			assert.Equal(t, tt.detail, problem.Detail)
+			if tt.errors != "" {
+				assert.JSONEq(t, tt.errors, string(problem.Errors))
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MIMEApplicationProblemJSON is the content type of problem details
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Problem represents RFC 7807 problem details returned by v2 API errors
+type Problem struct {
+	Type     string          `json:"type" example:"about:blank"`
+	Title    string          `json:"title" example:"Not Found"`
+	Status   int             `json:"status" example:"404"`
+	Detail   string          `json:"detail" example:"Group not found."`
+	Instance string          `json:"instance" example:"/api/v2/join-groups"`
+	Code     string          `json:"code" example:"group_not_found"`              // stable machine-readable error code
+	Errors   json.RawMessage `json:"errors,omitempty" swaggertype:"array,object"` // invalid fields of the request body
+}
+
+// statusCodes are error codes of errors without a code of their own, such as unknown routes
+var statusCodes = map[int]string{
+	http.StatusBadRequest:          "bad_request",
+	http.StatusUnauthorized:        "unauthorized",
+	http.StatusPaymentRequired:     "payment_required",
+	http.StatusForbidden:           "forbidden",
+	http.StatusNotFound:            "not_found",
+	http.StatusConflict:            "conflict",
+	http.StatusTooManyRequests:     "too_many_requests",
+	http.StatusInternalServerError: "internal_error",
+	http.StatusServiceUnavailable:  "service_unavailable",
+}
+
+const localsCode = "code"
+
+// SetCode sets the error code of a response sent without returning a domain error, such as the
+// text responses of middlewares
+func SetCode(c *fiber.Ctx, code string) {
+	c.Locals(localsCode, code)
+}
+
+// statusCode returns the error code of a status
+func statusCode(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	return "error"
+}
+
+// SendProblem responds problem details with the status as HTTP status code, the code set by
+// SetCode or the code of the status
+func SendProblem(c *fiber.Ctx, status int, detail string) error {
+	code, ok := c.Locals(localsCode).(string)
+	if !ok {
+		code = statusCode(status)
+	}
+
+	return sendProblem(c, status, code, detail, nil)
+}
+
+func sendProblem(c *fiber.Ctx, status int, code, detail string, errors json.RawMessage) error {
+	if detail == "" {
+		detail = http.StatusText(status)
+	}
+
+	body, err := json.Marshal(Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: c.OriginalURL(),
+		Code:     code,
+		Errors:   errors,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Status(status)
+	c.Set(fiber.HeaderContentType, MIMEApplicationProblemJSON)
+	return c.Send(body)
+}
+
+// Problems is a middleware serving v1 handlers as the v2 API. The status inside response.HTTP
+// becomes the HTTP status code, successful responses carry only their data and errors are
+// responded as problem details.
+func Problems(c *fiber.Ctx) error {
+	if err := c.Next(); err != nil {
+		if e, ok := err.(*fiber.Error); ok {
+			return SendProblem(c, e.Code, e.Message)
+		}
+
+		status, code, message, data := describe(err)
+		logError(c, status, err)
+
+		var errors json.RawMessage
+		if data != nil {
+			if errors, err = json.Marshal(data); err != nil {
+				return err
+			}
+		}
+		return sendProblem(c, status, code, message, errors)
+	}
+
+	res := c.Response()
+	if res.IsBodyStream() {
+		return nil
+	}
+
+	if strings.HasPrefix(string(res.Header.ContentType()), fiber.MIMEApplicationJSON) {
+		var envelope struct {
+			Success *bool           `json:"success"`
+			Data    json.RawMessage `json:"data"`
+			Status  int             `json:"status"`
+			Message string          `json:"message"`
+		}
+		if err := json.Unmarshal(res.Body(), &envelope); err == nil && envelope.Success != nil && envelope.Status != 0 {
+			if !*envelope.Success {
+				var errors json.RawMessage
+				if len(envelope.Data) > 0 && string(envelope.Data) != "null" {
+					errors = append(errors, envelope.Data...)
+				}
+				return sendProblem(c, envelope.Status, statusCode(envelope.Status), envelope.Message, errors)
+			}
+
+			if len(envelope.Data) == 0 || string(envelope.Data) == "null" {
+				res.ResetBody()
+				res.Header.Del(fiber.HeaderContentType)
+				c.Status(http.StatusNoContent)
+				return nil
+			}
+
+			c.Status(envelope.Status)
+			return c.Send(append([]byte(nil), envelope.Data...))
+		}
+	}
+
+	if status := res.StatusCode(); status >= http.StatusBadRequest {
+		return SendProblem(c, status, strings.TrimSpace(string(res.Body())))
+	}
+
+	return nil
+}
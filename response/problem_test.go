@@ -0,0 +1,106 @@
+package response_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblems(t *testing.T) {
+	app := fiber.New()
+	app.Use(response.Problems)
+	app.Get("/error", func(c *fiber.Ctx) error {
+		return c.JSON(response.HTTP{
+			Status:  http.StatusNotFound,
+			Message: "Group not found.",
+		})
+	})
+	app.Get("/data", func(c *fiber.Ctx) error {
+		return c.JSON(response.HTTP{
+			Success: true,
+			Data:    fiber.Map{"name": "Dino"},
+			Status:  http.StatusOK,
+			Message: "Success get data.",
+		})
+	})
+	app.Get("/empty", func(c *fiber.Ctx) error {
+		return c.JSON(response.HTTP{
+			Success: true,
+			Status:  http.StatusOK,
+			Message: "Success delete data.",
+		})
+	})
+	app.Get("/text", func(c *fiber.Ctx) error {
+		response.SetCode(c, "token_invalid")
+		return c.Status(http.StatusUnauthorized).SendString("Invalid or expired JWT")
+	})
+	app.Get("/plain", func(c *fiber.Ctx) error {
+		return c.JSON("Welcome")
+	})
+
+	tests := []struct {
+		name        string
+		endpoint    string
+		statusCode  int
+		contentType string
+		body        string
+		code        string
+	}{
+		{"Error from response body", "/error", http.StatusNotFound, response.MIMEApplicationProblemJSON, "", "not_found"},
+		{"Success data", "/data", http.StatusOK, fiber.MIMEApplicationJSON, `{"name":"Dino"}`, ""},
+		{"Success without data", "/empty", http.StatusNoContent, "", "", ""},
+		{"Text error", "/text", http.StatusUnauthorized, response.MIMEApplicationProblemJSON, "", "token_invalid"},
+		{"Other JSON kept", "/plain", http.StatusOK, fiber.MIMEApplicationJSON, `"Welcome"`, ""},
+		{"Unknown route", "/unknown", http.StatusNotFound, response.MIMEApplicationProblemJSON, "", "not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, _ := app.Test(httptest.NewRequest(http.MethodGet, tt.endpoint, nil), -1)
+			defer res.Body.Close()
+			resBody, _ := ioutil.ReadAll(res.Body)
+
+			assert.Equalf(t, tt.statusCode, res.StatusCode, string(resBody))
+			assert.Contains(t, res.Header.Get(fiber.HeaderContentType), tt.contentType)
+
+			if tt.code == "" {
+				assert.Equal(t, tt.body, string(resBody))
+				return
+			}
+
+			problem := new(response.Problem)
+			json.Unmarshal(resBody, &problem)
+			assert.Equal(t, tt.statusCode, problem.Status)
+			assert.Equal(t, tt.code, problem.Code)
+			assert.Equal(t, tt.endpoint, problem.Instance)
+			assert.Equal(t, http.StatusText(tt.statusCode), problem.Title)
+		})
+	}
+}
+
+func TestProblemsFieldErrors(t *testing.T) {
+	app := fiber.New()
+	app.Use(response.Problems)
+	app.Post("/invalid", func(c *fiber.Ctx) error {
+		return c.JSON(response.HTTP{
+			Data:    []fiber.Map{{"field": "email", "message": "must be a valid email address"}},
+			Status:  http.StatusBadRequest,
+			Message: "Request body invalid.",
+		})
+	})
+
+	res, _ := app.Test(httptest.NewRequest(http.MethodPost, "/invalid", nil), -1)
+	defer res.Body.Close()
+	resBody, _ := ioutil.ReadAll(res.Body)
+
+	problem := new(response.Problem)
+	json.Unmarshal(resBody, &problem)
+	assert.Equalf(t, http.StatusBadRequest, res.StatusCode, string(resBody))
+	assert.Equal(t, "bad_request", problem.Code)
+	assert.JSONEq(t, `[{"field":"email","message":"must be a valid email address"}]`, string(problem.Errors))
+}
@@ -1,31 +1,75 @@
 package routes
 
 import (
+	"strings"
+
 	swagger "github.com/arsmn/fiber-swagger/v2"
 	"github.com/dinopuguh/mycap-backend/auth"
+	"github.com/dinopuguh/mycap-backend/config"
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/health"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/metrics"
+	"github.com/dinopuguh/mycap-backend/ratelimit"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/services/apikey"
 	"github.com/dinopuguh/mycap-backend/services/group"
+	"github.com/dinopuguh/mycap-backend/services/notification"
+	"github.com/dinopuguh/mycap-backend/services/oauth"
+	"github.com/dinopuguh/mycap-backend/services/organization"
+	"github.com/dinopuguh/mycap-backend/services/promo"
+	"github.com/dinopuguh/mycap-backend/services/session"
 	"github.com/dinopuguh/mycap-backend/services/user"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	jwtware "github.com/gofiber/jwt/v2"
 )
 
 // New create an instance of MyCap routes
-func New() *fiber.App {
-	app := fiber.New()
-	app.Use(cors.New())
-	app.Use(logger.New(logger.Config{
-		Format:     "${cyan}[${time}] ${white}${pid} ${red}${status} ${blue}[${method}] ${white}${path}\n",
-		TimeFormat: "02-Jan-2006",
-		TimeZone:   "Asia/Jakarta",
+func New(cfg config.Config) *fiber.App {
+	auth.Configure(cfg.Auth)
+	group.Configure(cfg.Balance)
+	user.Configure(cfg)
+	organization.Configure(cfg)
+	oauth.Configure(cfg.OIDC)
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+		ProxyHeader:  cfg.ProxyHeader,
+	})
+	app.Use(cors.New(cors.Config{
+		ExposeHeaders: strings.Join([]string{
+			ratelimit.HeaderLimit, ratelimit.HeaderRemaining, ratelimit.HeaderReset, fiber.HeaderRetryAfter,
+		}, ","),
 	}))
+	app.Use(logger.RequestID)
+	app.Use(metrics.Requests)
+	app.Use(logger.Requests)
 	app.Use("/docs", swagger.Handler)
 
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.JSON("Welcome to MyCap API 🤟")
 	})
 
+	healthHandler := health.NewHandler(database.DBConn)
+	app.Get("/health/live", healthHandler.Live)
+	app.Get("/health/ready", healthHandler.Ready)
+	app.Get("/metrics", auth.RequireMetrics, metrics.Handler)
+	app.Get("/log-level", auth.RequireAdmin, logger.GetLevel)
+	app.Put("/log-level", auth.RequireAdmin, logger.UpdateLevel)
+
+	// users, types and groups are served through repositories so their handlers are tested in
+	// memory, handlers of the other services still query database.DBConn
+	db := database.DBConn
+	users := user.NewRepository(db)
+	userHandler := user.NewHandler(users, user.NewTypeRepository(db), session.Store{DB: db}, apikey.Store{DB: db})
+	store := ratelimit.NewMemoryStore()
+	groupHandler := group.NewHandler(group.NewRepository(db), users, store)
+	limits := newLimits(cfg.RateLimit, store)
+
+	app.Get("/admin/users", auth.RequireAdmin, userHandler.AdminGetAll)
+	app.Put("/admin/users/:id", auth.RequireAdmin, userHandler.AdminUpdate)
+
 	api := app.Group("/api")
 	v1 := api.Group("/v1", func(c *fiber.Ctx) error {
 		c.JSON(fiber.Map{
@@ -33,27 +77,116 @@ func New() *fiber.App {
 		})
 		return c.Next()
 	})
-	v1.Post("/register", user.New)
-	v1.Post("/login", user.Login)
+	register(v1, userHandler, groupHandler, limits)
 
-	v1.Get("/users", user.GetAll)
+	// v2 serves the same handlers with HTTP status codes and problem details for errors
+	v2 := api.Group("/v2", response.Problems)
+	register(v2, userHandler, groupHandler, limits)
+
+	app.Use(func(c *fiber.Ctx) error {
+		return c.SendStatus(404)
+	})
+
+	return app
+}
+
+// limits are rate limiting middlewares of MyCap endpoints
+type limits struct {
+	auth          fiber.Handler // sign in and sign up, per IP
+	read          fiber.Handler // anonymous reads, per IP
+	authenticated fiber.Handler // reads and writes, per user
+	join          fiber.Handler // joining groups with a passcode, per user
+	apiKey        fiber.Handler // requests with an API key, per IP so guessing keys is limited too
+}
 
-	v1.Get("/groups", group.GetAll)
+func newLimits(cfg config.RateLimit, store ratelimit.Store) limits {
+	if !cfg.Enabled {
+		cfg.Auth, cfg.Read, cfg.Write, cfg.Join, cfg.APIKey = 0, 0, 0, 0, 0
+	}
 
-	v1.Use(jwtware.New(jwtware.Config{
-		SigningKey: auth.SigningKey,
+	limiter := ratelimit.New(store)
+	read := limiter.Limit(ratelimit.Policy{Name: "read", Limit: cfg.Read, Window: cfg.Window, Key: ratelimit.ByUser})
+	write := limiter.Limit(ratelimit.Policy{Name: "write", Limit: cfg.Write, Window: cfg.Window, Key: ratelimit.ByUser})
+	apiKey := limiter.Limit(ratelimit.Policy{Name: "api_key", Limit: cfg.APIKey, Window: cfg.Window, Key: ratelimit.ByIP})
+
+	return limits{
+		auth: limiter.Limit(ratelimit.Policy{Name: "auth", Limit: cfg.Auth, Window: cfg.Window, Key: ratelimit.ByIP}),
+		read: read,
+		authenticated: func(c *fiber.Ctx) error {
+			if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+				return read(c)
+			}
+			return write(c)
+		},
+		join: limiter.Limit(ratelimit.Policy{Name: "join", Limit: cfg.Join, Window: cfg.Window, Key: ratelimit.ByUser}),
+		apiKey: func(c *fiber.Ctx) error {
+			if c.Get(apikey.HeaderAPIKey) == "" {
+				return c.Next()
+			}
+			return apiKey(c)
+		},
+	}
+}
+
+// register adds MyCap endpoints to an API version
+func register(router fiber.Router, userHandler *user.Handler, groupHandler *group.Handler, limits limits) {
+	router.Post("/register", limits.auth, userHandler.New)
+	router.Post("/login", limits.auth, userHandler.Login)
+	router.Get("/oauth/:provider/authorize", limits.auth, oauth.Authorize)
+	router.Get("/oauth/:provider/callback", limits.auth, oauth.Callback)
+	router.Post("/account/email/confirm", limits.auth, userHandler.ConfirmUpdateEmail)
+
+	router.Get("/users", limits.read, userHandler.GetAll)
+
+	router.Use(limits.apiKey)
+	router.Use(apikey.Authenticate)
+	router.Use(jwtware.New(jwtware.Config{
+		SigningKey:     auth.SigningKey(),
+		Filter:         apikey.Authenticated,
+		SuccessHandler: session.Verify,
+		ErrorHandler:   auth.TokenError,
 	}))
+	router.Use(limits.authenticated)
 
-	v1.Put("/users/:id", user.Update)
-	v1.Delete("/users/:id", user.Delete)
+	// API keys may only use routes requiring a scope, routes after apikey.RequireSession are
+	// reserved for signed in users
+	router.Put("/users/:id", apikey.RequireScope(apikey.ScopeUsersManage), userHandler.Update)
+	router.Delete("/users/:id", apikey.RequireScope(apikey.ScopeUsersManage), userHandler.Delete)
 
-	v1.Post("/groups", group.New)
-	v1.Post("/join-groups", group.Join)
-	v1.Post("/leave-groups", group.Leave)
+	router.Get("/account/history", apikey.RequireScope(apikey.ScopeGroupsRead), groupHandler.History)
+	router.Get("/account/usage", apikey.RequireScope(apikey.ScopeGroupsRead), groupHandler.Usage)
+	router.Get("/account/bonus", apikey.RequireScope(apikey.ScopeUsersRead), userHandler.GetBonus)
+	router.Post("/promo-codes/redeem", apikey.RequireScope(apikey.ScopeUsersManage), promo.RedeemCode)
 
-	app.Use(func(c *fiber.Ctx) error {
-		return c.SendStatus(404)
-	})
+	router.Get("/groups", apikey.RequireScope(apikey.ScopeGroupsRead), groupHandler.GetAll)
+	router.Post("/groups", apikey.RequireScope(apikey.ScopeGroupsManage), groupHandler.New)
+	router.Post("/join-groups", limits.join, apikey.RequireScope(apikey.ScopeGroupsManage), groupHandler.Join)
+	router.Post("/leave-groups", apikey.RequireScope(apikey.ScopeGroupsManage), groupHandler.Leave)
 
-	return app
+	router.Post("/organizations", apikey.RequireScope(apikey.ScopeOrganizationsManage), organization.New)
+	router.Get("/organization", apikey.RequireScope(apikey.ScopeOrganizationsRead), organization.Get)
+	router.Get("/organization/usage", apikey.RequireScope(apikey.ScopeOrganizationsRead), organization.Usage)
+	router.Post("/organization/invitations", apikey.RequireScope(apikey.ScopeOrganizationsManage), organization.Invite)
+	router.Post("/organization/invitations/accept", apikey.RequireScope(apikey.ScopeOrganizationsManage), organization.Accept)
+	router.Put("/organization/members/:user_id", apikey.RequireScope(apikey.ScopeOrganizationsManage), organization.UpdateMember)
+	router.Delete("/organization/members/:user_id", apikey.RequireScope(apikey.ScopeOrganizationsManage), organization.RemoveMember)
+
+	router.Get("/notifications", apikey.RequireScope(apikey.ScopeNotificationsRead), notification.GetAll)
+	router.Get("/notifications/stream", apikey.RequireScope(apikey.ScopeNotificationsRead), notification.Stream)
+	router.Put("/notifications/:id/read", apikey.RequireScope(apikey.ScopeNotificationsRead), notification.Read)
+
+	router.Use(apikey.RequireSession)
+
+	router.Post("/account/password", userHandler.SetPassword)
+	router.Put("/account/password", userHandler.UpdatePassword)
+	router.Put("/account/email", userHandler.UpdateEmail)
+	router.Post("/oauth/:provider/link", oauth.Link)
+
+	router.Get("/api-keys", apikey.GetAll)
+	router.Post("/api-keys", apikey.New)
+	router.Delete("/api-keys/:id", apikey.Revoke)
+
+	router.Get("/sessions", session.GetAll)
+	router.Delete("/sessions", session.RevokeOthers)
+	router.Delete("/sessions/:id", session.Revoke)
 }
@@ -0,0 +1,538 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dinopuguh/mycap-backend/config"
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/database/databasetest"
+	"github.com/dinopuguh/mycap-backend/mailer"
+	"github.com/dinopuguh/mycap-backend/ratelimit"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/routes"
+	"github.com/dinopuguh/mycap-backend/services/apikey"
+	"github.com/dinopuguh/mycap-backend/services/group"
+	"github.com/dinopuguh/mycap-backend/services/notification"
+	"github.com/dinopuguh/mycap-backend/services/oauth"
+	"github.com/dinopuguh/mycap-backend/services/oauth/oauthtest"
+	"github.com/dinopuguh/mycap-backend/services/organization"
+	"github.com/dinopuguh/mycap-backend/services/promo"
+	"github.com/dinopuguh/mycap-backend/services/session"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// findKey returns the JSON path of the first key matching in a decoded JSON value
+func findKey(path string, value interface{}, match func(key string) bool) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if match(key) {
+				return path + "." + key
+			}
+			if found := findKey(path+"."+key, child, match); found != "" {
+				return found
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if found := findKey(fmt.Sprintf("%s[%d]", path, i), child, match); found != "" {
+				return found
+			}
+		}
+	}
+
+	return ""
+}
+
+func assertNoPassword(t *testing.T, body []byte) {
+	var value interface{}
+	json.Unmarshal(body, &value)
+
+	password := findKey("$", value, func(key string) bool {
+		return strings.Contains(strings.ToLower(key), "password")
+	})
+	assert.Emptyf(t, password, "password field emitted in %s", string(body))
+}
+
+func TestPasswordNotSerialized(t *testing.T) {
+	hashed := user.User{Password: "$2a$14$hash"}
+
+	models := []interface{}{
+		hashed,
+		user.ResponseAuth{User: hashed.Private()},
+		group.Group{Admin: hashed, Participants: []user.User{hashed}},
+		group.Group{Admin: hashed, Participants: []user.User{hashed}}.Public(),
+		group.GroupSession{Participants: []user.User{hashed}},
+		apikey.APIKey{User: hashed, Hash: "$2a$14$hash"},
+		apikey.ResponseAPIKey{APIKey: apikey.APIKey{User: hashed}},
+		session.Session{},
+		oauth.Identity{User: hashed},
+		oauth.ResponseAuthorize{},
+		promo.ResponseRedeem{User: hashed.Private()},
+		organization.Organization{Members: []organization.Member{{Profile: hashed.Public()}}},
+		organization.Invitation{TokenHash: "$2a$14$hash"},
+		notification.Notification{},
+	}
+
+	for _, model := range models {
+		body, _ := json.Marshal(model)
+		assertNoPassword(t, body)
+	}
+}
+
+func TestUserKeysSnakeCase(t *testing.T) {
+	u := user.User{Model: gorm.Model{ID: 1}, Name: "Dino"}
+
+	for _, model := range []interface{}{u.Public(), u.Private(), user.ResponseAuth{User: u.Private()}} {
+		body, _ := json.Marshal(model)
+
+		var value interface{}
+		json.Unmarshal(body, &value)
+		notSnakeCase := findKey("$", value, func(key string) bool {
+			return strings.ToLower(key) != key
+		})
+		assert.Emptyf(t, notSnakeCase, "key not in snake case in %s", string(body))
+		assert.Contains(t, string(body), `"id":1`)
+	}
+}
+
+// memorySessions and memoryAPIKeys sign users in and out without database
+type memorySessions struct{}
+
+func (memorySessions) New(c *fiber.Ctx, userID uint) (*session.Session, error) {
+	return &session.Session{Model: gorm.Model{ID: userID}, UserID: userID}, nil
+}
+
+func (memorySessions) RevokeAll(c *fiber.Ctx, userID, keepID uint) error {
+	return nil
+}
+
+type memoryAPIKeys struct{}
+
+func (memoryAPIKeys) RevokeAll(c *fiber.Ctx, userID uint) error {
+	return nil
+}
+
+// TestMemoryEndpointsPasswordNotSerialized calls endpoints served through repositories backed by
+// memory, requests are signed in as the user with email from the X-Email header
+func TestMemoryEndpointsPasswordNotSerialized(t *testing.T) {
+	recorder := new(mailer.Recorder)
+	mailer.Default = recorder
+	defer func() { mailer.Default = mailer.Log{} }()
+
+	types := user.NewMemoryTypeRepository(user.Type{ID: 1, Name: "Free"})
+	users := user.NewMemoryRepository(types)
+	userHandler := user.NewHandler(users, types, memorySessions{}, memoryAPIKeys{})
+	groupHandler := group.NewHandler(group.NewMemoryRepository(users), users, ratelimit.NewMemoryStore())
+
+	app := fiber.New(fiber.Config{ErrorHandler: response.ErrorHandler})
+	app.Post("/register", userHandler.New)
+	app.Post("/login", userHandler.Login)
+	app.Get("/users", userHandler.GetAll)
+	app.Post("/account/email/confirm", userHandler.ConfirmUpdateEmail)
+	app.Get("/admin/users", userHandler.AdminGetAll)
+	app.Put("/admin/users/:id", userHandler.AdminUpdate)
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", &jwt.Token{Claims: jwt.MapClaims{"email": c.Get("X-Email")}})
+		session.SetCurrent(c, &session.Session{Model: gorm.Model{ID: 1}})
+		return c.Next()
+	})
+	app.Put("/users/:id", userHandler.Update)
+	app.Get("/account/bonus", userHandler.GetBonus)
+	app.Put("/account/email", userHandler.UpdateEmail)
+	app.Get("/groups", groupHandler.GetAll)
+	app.Post("/groups", groupHandler.New)
+	app.Post("/join-groups", groupHandler.Join)
+	app.Post("/leave-groups", groupHandler.Leave)
+	app.Get("/account/history", groupHandler.History)
+	app.Get("/account/usage", groupHandler.Usage)
+
+	request := func(method, endpoint, email string, data interface{}) (*response.HTTP, []byte) {
+		reqBody, _ := json.Marshal(data)
+		req, _ := http.NewRequest(method, endpoint, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Email", email)
+
+		resHTTP := new(response.HTTP)
+		res, _ := app.Test(req, -1)
+		defer res.Body.Close()
+		resBody, _ := ioutil.ReadAll(res.Body)
+		json.Unmarshal(resBody, &resHTTP)
+
+		assert.Equalf(t, http.StatusOK, resHTTP.Status, "%s %s: %s", method, endpoint, string(resBody))
+		assertNoPassword(t, resBody)
+		return resHTTP, resBody
+	}
+
+	admin := user.RegisterUser{Name: "Dino Contract", Email: "dinocontract@mycap.com", Username: "dinocontract", Password: "s3cr3tp45sw0rd"}
+	participant := user.RegisterUser{Name: "Dino Participant", Email: "dinoparticipant@mycap.com", Username: "dinoparticipant", Password: "s3cr3tp45sw0rd"}
+	request(http.MethodPost, "/register", "", admin)
+	request(http.MethodPost, "/register", "", participant)
+	request(http.MethodPost, "/login", "", user.LoginUser{Email: admin.Email, Password: admin.Password})
+	registered, _ := users.FindByEmail(admin.Email)
+
+	request(http.MethodGet, "/users", "", nil)
+	request(http.MethodPut, fmt.Sprintf("/users/%d", registered.ID), admin.Email, user.UpdateUser{Name: "Dino Contract"})
+	request(http.MethodGet, "/account/bonus", admin.Email, nil)
+	request(http.MethodGet, "/admin/users", "", nil)
+	request(http.MethodPut, fmt.Sprintf("/admin/users/%d", registered.ID), "", user.AdminUpdateUser{RemainingTime: 36000000})
+
+	request(http.MethodPut, "/account/email", participant.Email, user.ChangeEmail{NewEmail: "dinoparticipant@email.com", Password: participant.Password})
+	if message, ok := recorder.Last("dinoparticipant@email.com"); assert.True(t, ok) {
+		token := message.Body[strings.Index(message.Body, "token=")+len("token="):]
+		request(http.MethodPost, "/account/email/confirm", "", user.ConfirmEmail{Token: strings.Fields(token)[0]})
+	}
+
+	request(http.MethodPost, "/groups", admin.Email, group.CreateGroup{Type: group.GroupType})
+	request(http.MethodPost, "/join-groups", "dinoparticipant@email.com", group.JoinGroup{AdminUsername: admin.Username})
+	request(http.MethodGet, "/groups", "dinoparticipant@email.com", nil)
+	request(http.MethodPost, "/leave-groups", "dinoparticipant@email.com", group.LeaveGroup{AdminUsername: admin.Username})
+	request(http.MethodPost, "/leave-groups", admin.Email, group.LeaveGroup{AdminUsername: admin.Username, RemainingTime: 36000000})
+	request(http.MethodGet, "/account/history", admin.Email, nil)
+	request(http.MethodGet, "/account/usage", admin.Email, nil)
+}
+
+func TestEndpointsPasswordNotSerialized(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	recorder := new(mailer.Recorder)
+	mailer.Default = recorder
+	defer func() { mailer.Default = mailer.Log{} }()
+
+	stub := httptest.NewServer(oauthtest.NewStubProvider())
+	defer stub.Close()
+	cfg.OIDC.Providers = []config.OIDCProvider{{
+		Name:         "stub",
+		Issuer:       stub.URL,
+		ClientID:     "mycap",
+		ClientSecret: "s3cr3t",
+		RedirectURL:  "http://localhost:3000/api/v1/oauth/stub/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	}}
+
+	app := routes.New(cfg)
+
+	registerUser := user.RegisterUser{
+		Name:     "Dino Contract",
+		Email:    "dinocontract@mycap.com",
+		Username: "dinocontract",
+		Password: "s3cr3tp45sw0rd",
+	}
+	participant := user.RegisterUser{
+		Name:     "Dino Participant",
+		Email:    "dinoparticipant@mycap.com",
+		Username: "dinoparticipant",
+		Password: "s3cr3tp45sw0rd",
+	}
+
+	request := func(method, endpoint, token string, data interface{}) (*response.HTTP, []byte) {
+		var body *bytes.Buffer
+		if data != nil {
+			reqBody, _ := json.Marshal(data)
+			body = bytes.NewBuffer(reqBody)
+		} else {
+			body = bytes.NewBuffer(nil)
+		}
+
+		req, _ := http.NewRequest(method, endpoint, body)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resHTTP := new(response.HTTP)
+		res, _ := app.Test(req, -1)
+		defer res.Body.Close()
+		resBody, _ := ioutil.ReadAll(res.Body)
+		json.Unmarshal(resBody, &resHTTP)
+
+		return resHTTP, resBody
+	}
+
+	authenticate := func(resHTTP *response.HTTP) *user.ResponseAuth {
+		auth := new(user.ResponseAuth)
+		authJSON, _ := json.Marshal(resHTTP.Data)
+		json.Unmarshal(authJSON, &auth)
+		return auth
+	}
+
+	resHTTP, body := request(http.MethodPost, "/api/v1/register", "", registerUser)
+	assertNoPassword(t, body)
+	admin := authenticate(resHTTP)
+
+	resHTTP, body = request(http.MethodPost, "/api/v1/register", "", participant)
+	assertNoPassword(t, body)
+	joining := authenticate(resHTTP)
+
+	_, body = request(http.MethodPost, "/api/v1/login", "", user.LoginUser{Email: registerUser.Email, Password: registerUser.Password})
+	assertNoPassword(t, body)
+
+	_, body = request(http.MethodGet, "/api/v1/users", "", nil)
+	assertNoPassword(t, body)
+
+	_, body = request(http.MethodPut, fmt.Sprintf("/api/v1/users/%d", admin.User.ID), admin.AccessToken, user.UpdateUser{Name: "Dino Contract"})
+	assertNoPassword(t, body)
+
+	_, body = request(http.MethodPost, "/api/v1/groups", admin.AccessToken, group.CreateGroup{Type: group.GroupType})
+	assertNoPassword(t, body)
+
+	_, body = request(http.MethodPost, "/api/v1/join-groups", joining.AccessToken, group.JoinGroup{AdminUsername: registerUser.Username})
+	assertNoPassword(t, body)
+
+	_, body = request(http.MethodGet, "/api/v1/groups", joining.AccessToken, nil)
+	assertNoPassword(t, body)
+
+	_, body = request(http.MethodPost, "/api/v1/leave-groups", joining.AccessToken, group.LeaveGroup{AdminUsername: registerUser.Username})
+	assertNoPassword(t, body)
+
+	_, body = request(http.MethodPost, "/api/v1/leave-groups", admin.AccessToken, group.LeaveGroup{AdminUsername: registerUser.Username, RemainingTime: 36000000})
+	assertNoPassword(t, body)
+
+	_, body = request(http.MethodPost, "/api/v1/api-keys", admin.AccessToken, apikey.CreateAPIKey{Name: "Contract bot", Scopes: []string{apikey.ScopeUsersRead}})
+	assertNoPassword(t, body)
+
+	_, body = request(http.MethodGet, "/api/v1/api-keys", admin.AccessToken, nil)
+	assertNoPassword(t, body)
+
+	_, body = request(http.MethodGet, "/api/v1/sessions", admin.AccessToken, nil)
+	assertNoPassword(t, body)
+
+	database.DBConn.Create(&promo.PromoCode{Code: "CONTRACT", BonusTime: 60000})
+	defer database.DBConn.Unscoped().Where("code = ?", "CONTRACT").Delete(&promo.PromoCode{})
+	_, body = request(http.MethodPost, "/api/v1/promo-codes/redeem", admin.AccessToken, promo.RedeemPromoCode{Code: "CONTRACT"})
+	assertNoPassword(t, body)
+
+	_, body = request(http.MethodPost, "/api/v1/organizations", admin.AccessToken, organization.CreateOrganization{Name: "MyCap Contract"})
+	assertNoPassword(t, body)
+
+	_, body = request(http.MethodPost, "/api/v1/organization/invitations", admin.AccessToken, organization.InviteMember{Email: participant.Email})
+	assertNoPassword(t, body)
+
+	_, body = request(http.MethodGet, "/api/v1/organization", admin.AccessToken, nil)
+	assertNoPassword(t, body)
+
+	_, body = request(http.MethodGet, "/api/v1/notifications", admin.AccessToken, nil)
+	assertNoPassword(t, body)
+
+	resHTTP, body = request(http.MethodGet, "/api/v1/oauth/stub/authorize?login_hint=dinocontractoidc@mycap.com", "", nil)
+	assertNoPassword(t, body)
+	authorize := new(oauth.ResponseAuthorize)
+	authorizeJSON, _ := json.Marshal(resHTTP.Data)
+	json.Unmarshal(authorizeJSON, &authorize)
+	noRedirect := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	if resProvider, err := noRedirect.Get(authorize.AuthorizationURL); assert.NoError(t, err) {
+		resProvider.Body.Close()
+		callback, _ := url.Parse(resProvider.Header.Get("Location"))
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/oauth/stub/callback?"+callback.RawQuery, nil)
+		req.AddCookie(&http.Cookie{Name: "mycap_oauth_state", Value: authorize.State})
+		res, _ := app.Test(req, -1)
+		body, _ = ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assertNoPassword(t, body)
+
+		json.Unmarshal(body, &resHTTP)
+		provided := authenticate(resHTTP)
+		defer request(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", provided.User.ID), provided.AccessToken, nil)
+	}
+
+	_, body = request(http.MethodPut, "/api/v1/account/email", joining.AccessToken, user.ChangeEmail{NewEmail: "dinoparticipant@email.com", Password: participant.Password})
+	assertNoPassword(t, body)
+	if message, ok := recorder.Last("dinoparticipant@email.com"); assert.True(t, ok) {
+		token := message.Body[strings.Index(message.Body, "token=")+len("token="):]
+		_, body = request(http.MethodPost, "/api/v1/account/email/confirm", "", user.ConfirmEmail{Token: strings.Fields(token)[0]})
+		assertNoPassword(t, body)
+
+		resHTTP, _ = request(http.MethodPost, "/api/v1/login", "", user.LoginUser{Email: "dinoparticipant@email.com", Password: participant.Password})
+		joining = authenticate(resHTTP)
+	}
+
+	request(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", joining.User.ID), joining.AccessToken, nil)
+	request(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", admin.User.ID), admin.AccessToken, nil)
+}
+
+func TestVersions(t *testing.T) {
+	app := routes.New(config.Default())
+
+	tests := []struct {
+		name       string
+		endpoint   string
+		statusCode int
+		body       string
+	}{
+		{"v1 body unchanged", "/api/v1/groups", http.StatusBadRequest, "Missing or malformed JWT"},
+		{"v2 problem details", "/api/v2/groups", http.StatusBadRequest, `"code":"token_missing"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.endpoint, nil)
+			res, _ := app.Test(req, -1)
+			defer res.Body.Close()
+			resBody, _ := ioutil.ReadAll(res.Body)
+
+			assert.Equalf(t, tt.statusCode, res.StatusCode, string(resBody))
+			assert.Contains(t, string(resBody), tt.body)
+		})
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	cfg := config.Default()
+
+	tests := []struct {
+		name       string
+		token      string
+		header     string
+		statusCode int
+	}{
+		{"Disabled without token", "", "Bearer ", http.StatusNotFound},
+		{"Token missing", "m3tr1cs", "", http.StatusUnauthorized},
+		{"Token invalid", "m3tr1cs", "Bearer wrong", http.StatusUnauthorized},
+		{"Valid scrape", "m3tr1cs", "Bearer m3tr1cs", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Auth.MetricsToken = tt.token
+			app := routes.New(cfg)
+
+			req, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+			req.Header.Set("Authorization", tt.header)
+			res, _ := app.Test(req, -1)
+			defer res.Body.Close()
+			resBody, _ := ioutil.ReadAll(res.Body)
+
+			// errors of routes outside the API versions are responded in the v1 body
+			status := res.StatusCode
+			resHTTP := new(response.HTTP)
+			if json.Unmarshal(resBody, resHTTP) == nil && resHTTP.Status != 0 {
+				status = resHTTP.Status
+			}
+			assert.Equalf(t, tt.statusCode, status, string(resBody))
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	cfg := config.Default()
+	cfg.RateLimit.Auth = 2
+	app := routes.New(cfg)
+
+	tests := []struct {
+		name       string
+		endpoint   string
+		statusCode int
+		remaining  string
+	}{
+		{"First sign in", "/api/v2/login", http.StatusBadRequest, "1"},
+		{"Sign in with v1 counted", "/api/v1/login", http.StatusOK, "0"},
+		{"Sign in limited", "/api/v2/login", http.StatusTooManyRequests, "0"},
+		{"Sign up limited", "/api/v2/register", http.StatusTooManyRequests, "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, tt.endpoint, strings.NewReader("{}"))
+			req.Header.Set("Content-Type", "application/json")
+			res, _ := app.Test(req, -1)
+			defer res.Body.Close()
+			resBody, _ := ioutil.ReadAll(res.Body)
+
+			assert.Equalf(t, tt.statusCode, res.StatusCode, string(resBody))
+			assert.Equal(t, "2", res.Header.Get("RateLimit-Limit"))
+			assert.Equal(t, tt.remaining, res.Header.Get("RateLimit-Remaining"))
+		})
+	}
+}
+
+func TestJoinRateLimit(t *testing.T) {
+	cfg := databasetest.Connect(t)
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.Join = 2
+	app := routes.New(cfg)
+
+	request := func(method, endpoint, token string, data interface{}) *http.Response {
+		reqBody, _ := json.Marshal(data)
+		req, _ := http.NewRequest(method, endpoint, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, _ := app.Test(req, -1)
+		return res
+	}
+
+	res := request(http.MethodPost, "/api/v2/register", "", user.RegisterUser{
+		Name:     "Dino Joining",
+		Email:    "dinojoining@mycap.com",
+		Username: "dinojoining",
+		Password: "s3cr3tp45sw0rd",
+	})
+	resHTTP := new(response.HTTP)
+	json.NewDecoder(res.Body).Decode(resHTTP)
+	res.Body.Close()
+	joining := new(user.ResponseAuth)
+	authJSON, _ := json.Marshal(resHTTP.Data)
+	json.Unmarshal(authJSON, &joining)
+	defer request(http.MethodDelete, fmt.Sprintf("/api/v2/users/%d", joining.User.ID), joining.AccessToken, nil)
+
+	tests := []struct {
+		name       string
+		statusCode int
+		remaining  string
+	}{
+		{"First join", http.StatusNotFound, "1"},
+		{"Second join", http.StatusNotFound, "0"},
+		{"Join limited", http.StatusTooManyRequests, "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := request(http.MethodPost, "/api/v2/join-groups", joining.AccessToken, group.JoinGroup{AdminUsername: "dinonobody", Passcode: "000000"})
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			assert.Equal(t, "2", res.Header.Get("RateLimit-Limit"))
+			assert.Equal(t, tt.remaining, res.Header.Get("RateLimit-Remaining"))
+		})
+	}
+}
+
+func TestAPIKeyRateLimit(t *testing.T) {
+	cfg := databasetest.Connect(t)
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.APIKey = 2
+	app := routes.New(cfg)
+
+	tests := []struct {
+		name       string
+		statusCode int
+	}{
+		{"First key guessed", http.StatusUnauthorized},
+		{"Second key guessed", http.StatusUnauthorized},
+		{"Guessing limited before lookup", http.StatusTooManyRequests},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/api/v2/groups", nil)
+			req.Header.Set(apikey.HeaderAPIKey, fmt.Sprintf("mycap_guess%d", i))
+			res, _ := app.Test(req, -1)
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			assert.Equal(t, "2", res.Header.Get("RateLimit-Limit"))
+		})
+	}
+}
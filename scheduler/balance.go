@@ -0,0 +1,20 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/metrics"
+	"github.com/dinopuguh/mycap-backend/services/group"
+)
+
+// CheckBalance function warns admins of active groups running out of time and ends exhausted sessions
+func CheckBalance() {
+	start := time.Now()
+	err := group.CheckBalances(database.DBConn, start)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("job", "check_balance").Msg("Job failed.")
+	}
+	metrics.ObserveJob("check_balance", start, err)
+}
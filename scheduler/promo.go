@@ -0,0 +1,21 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/metrics"
+	"github.com/dinopuguh/mycap-backend/services/promo"
+)
+
+// ExpireUpgrades function reverts temporary plan upgrades given by promo codes when they end
+func ExpireUpgrades() {
+	start := time.Now()
+	reverted, err := promo.ExpireUpgrades(database.DBConn, start)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("job", "expire_upgrades").Msg("Job failed.")
+	}
+	logger.Log.Info().Str("job", "expire_upgrades").Int("reverted", reverted).Msg("Plan upgrades reverted.")
+	metrics.ObserveJob("expire_upgrades", start, err)
+}
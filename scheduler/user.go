@@ -1,19 +1,21 @@
 package scheduler
 
 import (
-	"log"
-	"os/user"
+	"time"
 
 	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/metrics"
+	"github.com/dinopuguh/mycap-backend/services/user"
 )
 
-// ResetTimeLimit function updates users' time limit monthly
+// ResetTimeLimit function resets remaining time of users whose billing cycle rolled over
 func ResetTimeLimit() {
-	db := database.DBConn
-
-	db.Model(user.User{}).Where("type_id = ?", 1).Updates(map[string]interface{}{
-		"reached_time_limit": false,
-		"remaining_time":     36000000,
-	})
-	log.Println("Update free users' remaining time.")
+	start := time.Now()
+	reset, err := user.ResetCycles(database.DBConn, start)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("job", "reset_time_limit").Msg("Job failed.")
+	}
+	logger.Log.Info().Str("job", "reset_time_limit").Int("reset", reset).Msg("Users' remaining time reset.")
+	metrics.ObserveJob("reset_time_limit", start, err)
 }
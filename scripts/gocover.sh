@@ -13,6 +13,57 @@ grep -v "mode: count" >> coverage.txt profile.txt
 go test -v -covermode=count -coverprofile=profile.txt ./services/group/...
 grep -v "mode: count" >> coverage.txt profile.txt
 
+go test -v -covermode=count -coverprofile=profile.txt ./services/apikey/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./services/oauth/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./services/session/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./services/notification/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./services/promo/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./services/organization/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./migrations/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./apperror/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./logger/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./validation/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./response/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./health/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./database/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./config/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./ratelimit/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./metrics/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
+go test -v -covermode=count -coverprofile=profile.txt ./routes/...
+grep -v "mode: count" >> coverage.txt profile.txt
+
 bash <(curl -s https://codecov.io/bash)
 
 rm -rf ./coverage.txt
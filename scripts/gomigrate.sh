@@ -4,4 +4,4 @@
 # Run migrations
 
 set -e
-go run cmd/migrate/migrate.go
\ No newline at end of file
+go run cmd/migrate/migrate.go up
\ No newline at end of file
@@ -7,14 +7,25 @@ import (
 	"gorm.io/gorm"
 )
 
-func createType(db *gorm.DB, name string) error {
+// createType creates a plan with its default participant limits, limits of an existing plan are only
+// filled in when not set so limits configured in the database are kept across restarts
+func createType(db *gorm.DB, name string, maxGroupParticipants, maxConferenceParticipants int64) error {
 	userType := new(user.Type)
 	if res := db.Where("name = ?", name).First(&userType); res.RowsAffected != 0 {
 		log.Printf("Type %s is already exist.\n", name)
-		return nil
+		if err := db.Model(&user.Type{}).Where("id = ? AND max_group_participants IS NULL", userType.ID).
+			UpdateColumn("max_group_participants", maxGroupParticipants).Error; err != nil {
+			return err
+		}
+		return db.Model(&user.Type{}).Where("id = ? AND max_conference_participants IS NULL", userType.ID).
+			UpdateColumn("max_conference_participants", maxConferenceParticipants).Error
 	}
 
-	return db.Create(&user.Type{Name: name}).Error
+	return db.Create(&user.Type{
+		Name:                      name,
+		MaxGroupParticipants:      maxGroupParticipants,
+		MaxConferenceParticipants: maxConferenceParticipants,
+	}).Error
 }
 
 // AllTypes function return all type's seeds
@@ -23,19 +34,19 @@ func AllTypes() []Seed {
 		Seed{
 			Name: "Create user type Free",
 			Run: func(db *gorm.DB) error {
-				return createType(db, "Free")
+				return createType(db, "Free", 5, 50)
 			},
 		},
 		Seed{
 			Name: "Create user type Premium",
 			Run: func(db *gorm.DB) error {
-				return createType(db, "Premium")
+				return createType(db, "Premium", 20, 200)
 			},
 		},
 		Seed{
 			Name: "Create user type Pro",
 			Run: func(db *gorm.DB) error {
-				return createType(db, "Pro")
+				return createType(db, "Pro", 50, 1000)
 			},
 		},
 	}
@@ -0,0 +1,213 @@
+package apikey
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/helpers"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/dinopuguh/mycap-backend/validation"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// APIKey is a model for user's personal API key
+type APIKey struct {
+	gorm.Model
+	UserID     uint       `json:"user_id"`
+	User       user.User  `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix" gorm:"uniqueIndex;"`
+	Hash       string     `json:"-" gorm:"uniqueIndex;"`
+	Scopes     string     `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+const (
+	// ScopeUsersRead is a scope for reading the account of the user
+	ScopeUsersRead = "users:read"
+	// ScopeUsersManage is a scope for updating and removing users and redeeming promo codes
+	ScopeUsersManage = "users:manage"
+	// ScopeGroupsRead is a scope for reading groups and usage history
+	ScopeGroupsRead = "groups:read"
+	// ScopeGroupsManage is a scope for creating, joining and leaving groups
+	ScopeGroupsManage = "groups:manage"
+	// ScopeOrganizationsRead is a scope for reading the organization of the user and its usage
+	ScopeOrganizationsRead = "organizations:read"
+	// ScopeOrganizationsManage is a scope for creating organizations, inviting and managing members
+	ScopeOrganizationsManage = "organizations:manage"
+	// ScopeNotificationsRead is a scope for reading notifications and marking them read
+	ScopeNotificationsRead = "notifications:read"
+)
+
+var validScopes = map[string]bool{
+	ScopeUsersRead:           true,
+	ScopeUsersManage:         true,
+	ScopeGroupsRead:          true,
+	ScopeGroupsManage:        true,
+	ScopeOrganizationsRead:   true,
+	ScopeOrganizationsManage: true,
+	ScopeNotificationsRead:   true,
+}
+
+// Store revokes API keys in database
+type Store struct {
+	DB *gorm.DB
+}
+
+// RevokeAll revokes all API keys of an user
+func (s Store) RevokeAll(c *fiber.Ctx, userID uint) error {
+	return s.DB.WithContext(logger.Context(c)).Where("user_id = ?", userID).Delete(&APIKey{}).Error
+}
+
+// HasScope reports whether the API key was granted the scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range strings.Split(k.Scopes, ",") {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetAll is a function to get all API keys of the current user
+// @Summary Get all API keys
+// @Description Get all API keys of the current user
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.HTTP{data=[]APIKey}
+// @Security ApiKeyAuth
+// @Router /v1/api-keys [get]
+func GetAll(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	owner, err := user.Current(c, user.NewRepository(db))
+	if err != nil {
+		return err
+	}
+
+	var apiKeys []APIKey
+	if res := db.Where("user_id = ?", owner.ID).Find(&apiKeys); res.Error != nil {
+		return res.Error
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    apiKeys,
+		Status:  http.StatusOK,
+		Message: "Success get all API keys.",
+	})
+}
+
+// New function creates a personal API key for server-to-server integrations
+// @Summary Create an API key
+// @Description Create an API key, the key is only shown once
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param api_key body CreateAPIKey true "Create API key"
+// @Success 200 {object} response.HTTP{data=ResponseAPIKey}
+// @Security ApiKeyAuth
+// @Router /v1/api-keys [post]
+func New(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	owner, err := user.Current(c, user.NewRepository(db))
+	if err != nil {
+		return err
+	}
+
+	createAPIKey := new(CreateAPIKey)
+	if err := c.BodyParser(&createAPIKey); err != nil {
+		return apperror.Invalid("body_invalid", err.Error())
+	}
+
+	if err := validation.Struct(createAPIKey); err != nil {
+		return err
+	}
+
+	for _, scope := range createAPIKey.Scopes {
+		if !validScopes[scope] {
+			return apperror.Invalid("scope_invalid", "Scope %s not exist.", scope)
+		}
+	}
+
+	prefix, err := helpers.RandomToken(4)
+	if err != nil {
+		return err
+	}
+
+	secret, err := helpers.RandomToken(24)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("mycap_%s_%s", prefix, secret)
+
+	apiKey := new(APIKey)
+	apiKey.UserID = owner.ID
+	apiKey.Name = createAPIKey.Name
+	apiKey.Prefix = prefix
+	apiKey.Hash = helpers.HashToken(key)
+	apiKey.Scopes = strings.Join(createAPIKey.Scopes, ",")
+
+	if err := db.Create(apiKey).Error; err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data: ResponseAPIKey{
+			APIKey: *apiKey,
+			Key:    key,
+		},
+		Status:  http.StatusOK,
+		Message: "Success create a new API key.",
+	})
+}
+
+// Revoke function removes an API key of the current user by ID
+// @Summary Revoke API key by ID
+// @Description Revoke API key by ID
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} response.HTTP
+// @Security ApiKeyAuth
+// @Router /v1/api-keys/{id} [delete]
+func Revoke(c *fiber.Ctx) error {
+	id := c.Params("id")
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	owner, err := user.Current(c, user.NewRepository(db))
+	if err != nil {
+		return err
+	}
+
+	var apiKey APIKey
+	if err := db.Where("user_id = ?", owner.ID).First(&apiKey, id).Error; err != nil {
+		if apperror.IsNotFound(err) {
+			return apperror.NotFound("api_key_not_found", "API key with ID %v not found.", id)
+		}
+		return err
+	}
+
+	if err := db.Delete(&apiKey).Error; err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Status:  http.StatusOK,
+		Message: "Success revoke API key.",
+	})
+}
@@ -0,0 +1,13 @@
+package apikey
+
+// CreateAPIKey is a data transfer object for create API key
+type CreateAPIKey struct {
+	Name   string   `json:"name" validate:"required,max=100" example:"Conference bot"`
+	Scopes []string `json:"scopes" validate:"required,min=1" example:"groups:read,groups:manage"`
+}
+
+// ResponseAPIKey represents response body for a newly created API key
+type ResponseAPIKey struct {
+	APIKey APIKey `json:"api_key"`
+	Key    string `json:"key"`
+}
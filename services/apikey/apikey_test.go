@@ -0,0 +1,246 @@
+package apikey_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/database/databasetest"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/routes"
+	"github.com/dinopuguh/mycap-backend/services/apikey"
+	"github.com/dinopuguh/mycap-backend/services/group"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	accessToken  string
+	createdKey   *apikey.ResponseAPIKey
+	readOnlyKey  *apikey.ResponseAPIKey
+	registerUser = user.RegisterUser{
+		Name:     "Dino API",
+		Email:    "dinoapi@mycap.com",
+		Username: "dinoapi",
+		Password: "s3cr3tp45sw0rd",
+		TypeID:   1,
+	}
+)
+
+func TestNew(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	app := routes.New(cfg)
+
+	registerBody, _ := json.Marshal(registerUser)
+	reqRegister, _ := http.NewRequest(http.MethodPost, "/api/v1/register", bytes.NewBuffer(registerBody))
+	reqRegister.Header.Set("Content-Type", "application/json")
+
+	resHTTP := new(response.HTTP)
+	register := new(user.ResponseAuth)
+	resRegister, _ := app.Test(reqRegister, -1)
+	defer resRegister.Body.Close()
+	resBodyRegister, _ := ioutil.ReadAll(resRegister.Body)
+	json.Unmarshal(resBodyRegister, &resHTTP)
+	registerJSON, _ := json.Marshal(resHTTP.Data)
+	json.Unmarshal(registerJSON, &register)
+	accessToken = register.AccessToken
+
+	type args struct {
+		data        apikey.CreateAPIKey
+		statusCode  int
+		contentType string
+		readOnly    bool
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{"Valid create API key", args{
+			data: apikey.CreateAPIKey{
+				Name:   "Conference bot",
+				Scopes: []string{apikey.ScopeGroupsRead, apikey.ScopeGroupsManage},
+			},
+			statusCode:  http.StatusOK,
+			contentType: "application/json",
+		}},
+		{"Valid create read only API key", args{
+			data: apikey.CreateAPIKey{
+				Name:   "Notification reader",
+				Scopes: []string{apikey.ScopeNotificationsRead},
+			},
+			statusCode:  http.StatusOK,
+			contentType: "application/json",
+			readOnly:    true,
+		}},
+		{"Name not specified", args{
+			data: apikey.CreateAPIKey{
+				Scopes: []string{apikey.ScopeGroupsRead},
+			},
+			statusCode:  http.StatusBadRequest,
+			contentType: "application/json",
+		}},
+		{"Scope not exist", args{
+			data: apikey.CreateAPIKey{
+				Name:   "Conference bot",
+				Scopes: []string{"everything"},
+			},
+			statusCode:  http.StatusBadRequest,
+			contentType: "application/json",
+		}},
+		{"Body parser invalid", args{
+			data: apikey.CreateAPIKey{
+				Name:   "Conference bot",
+				Scopes: []string{apikey.ScopeGroupsRead},
+			},
+			statusCode: http.StatusBadRequest,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqBody, _ := json.Marshal(tt.args.data)
+			req, _ := http.NewRequest(http.MethodPost, "/api/v1/api-keys", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", tt.args.contentType)
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+
+			resHTTP := new(response.HTTP)
+			res, _ := app.Test(req, -1)
+			defer res.Body.Close()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			json.Unmarshal(resBody, &resHTTP)
+
+			assert.Equalf(t, tt.args.statusCode, resHTTP.Status, string(resBody))
+
+			if tt.args.statusCode == http.StatusOK {
+				keyJSON, _ := json.Marshal(resHTTP.Data)
+				if tt.args.readOnly {
+					json.Unmarshal(keyJSON, &readOnlyKey)
+				} else {
+					json.Unmarshal(keyJSON, &createdKey)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	app := routes.New(cfg)
+
+	type args struct {
+		method     string
+		path       string
+		key        string
+		statusCode int
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{"Valid API key", args{
+			method:     http.MethodPost,
+			path:       "/api/v1/groups",
+			key:        createdKey.Key,
+			statusCode: http.StatusOK,
+		}},
+		{"API key without scope", args{
+			method:     http.MethodPost,
+			path:       "/api/v1/groups",
+			key:        readOnlyKey.Key,
+			statusCode: http.StatusForbidden,
+		}},
+		{"API key with scope", args{
+			method:     http.MethodGet,
+			path:       "/api/v1/notifications",
+			key:        readOnlyKey.Key,
+			statusCode: http.StatusOK,
+		}},
+		{"Endpoint without scope", args{
+			method:     http.MethodGet,
+			path:       "/api/v1/sessions",
+			key:        createdKey.Key,
+			statusCode: http.StatusForbidden,
+		}},
+		{"Invalid API key", args{
+			method:     http.MethodPost,
+			path:       "/api/v1/groups",
+			key:        "mycap_00000000_invalid",
+			statusCode: http.StatusUnauthorized,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqBody, _ := json.Marshal(group.CreateGroup{Type: group.GroupType})
+			req, _ := http.NewRequest(tt.args.method, tt.args.path, bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(apikey.HeaderAPIKey, tt.args.key)
+
+			resHTTP := new(response.HTTP)
+			res, _ := app.Test(req, -1)
+			defer res.Body.Close()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			json.Unmarshal(resBody, &resHTTP)
+
+			assert.Equalf(t, tt.args.statusCode, resHTTP.Status, string(resBody))
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	app := routes.New(cfg)
+
+	type args struct {
+		id         uint
+		key        string
+		statusCode int
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{"Manage with API key", args{
+			id:         createdKey.APIKey.ID,
+			key:        createdKey.Key,
+			statusCode: http.StatusForbidden,
+		}},
+		{"Valid revoke API key", args{
+			id:         createdKey.APIKey.ID,
+			statusCode: http.StatusOK,
+		}},
+		{"API key not found", args{
+			id:         createdKey.APIKey.ID,
+			statusCode: http.StatusNotFound,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := fmt.Sprintf("/api/v1/api-keys/%d", tt.args.id)
+			req, _ := http.NewRequest(http.MethodDelete, endpoint, nil)
+			if tt.args.key != "" {
+				req.Header.Set(apikey.HeaderAPIKey, tt.args.key)
+			} else {
+				req.Header.Set("Authorization", "Bearer "+accessToken)
+			}
+
+			resHTTP := new(response.HTTP)
+			res, _ := app.Test(req, -1)
+			defer res.Body.Close()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			json.Unmarshal(resBody, &resHTTP)
+
+			assert.Equalf(t, tt.args.statusCode, resHTTP.Status, string(resBody))
+		})
+	}
+
+	leaveBody, _ := json.Marshal(group.LeaveGroup{AdminUsername: registerUser.Username})
+	reqLeave, _ := http.NewRequest(http.MethodPost, "/api/v1/leave-groups", bytes.NewBuffer(leaveBody))
+	reqLeave.Header.Set("Content-Type", "application/json")
+	reqLeave.Header.Set("Authorization", "Bearer "+accessToken)
+	app.Test(reqLeave, -1)
+}
@@ -0,0 +1,80 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/auth"
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/helpers"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderAPIKey is a request header to send an API key
+const HeaderAPIKey = "X-API-Key"
+
+const localsAPIKey = "api_key"
+
+// Authenticate is a middleware that signs the request with an API key as an alternative to JWT
+func Authenticate(c *fiber.Ctx) error {
+	key := c.Get(HeaderAPIKey)
+	if key == "" {
+		return c.Next()
+	}
+
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	var apiKey APIKey
+	if err := db.Preload("User").Where("hash = ?", helpers.HashToken(key)).First(&apiKey).Error; err != nil {
+		if apperror.IsNotFound(err) {
+			return apperror.Unauthorized("api_key_invalid", "Invalid or revoked API key.")
+		}
+		return err
+	}
+
+	if err := db.Model(&apiKey).UpdateColumn("last_used_at", time.Now()).Error; err != nil {
+		return err
+	}
+
+	c.Locals("user", &jwt.Token{
+		Valid: true,
+		Claims: jwt.MapClaims{
+			"name":  apiKey.User.Name,
+			"email": apiKey.User.Email,
+		},
+	})
+	c.Locals(localsAPIKey, &apiKey)
+	auth.SetUser(c, apiKey.UserID)
+
+	return c.Next()
+}
+
+// Authenticated reports whether the request was signed with an API key
+func Authenticated(c *fiber.Ctx) bool {
+	_, ok := c.Locals(localsAPIKey).(*APIKey)
+	return ok
+}
+
+// RequireScope is a middleware that rejects API keys without the scope, JWT sessions are always allowed
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		apiKey, ok := c.Locals(localsAPIKey).(*APIKey)
+		if ok && !apiKey.HasScope(scope) {
+			return apperror.Forbidden("api_key_scope_missing", "API key doesn't have %s scope.", scope)
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireSession is a middleware that rejects API keys. Used after the routes requiring a scope,
+// it denies API keys every route without one, such as managing API keys and sessions.
+func RequireSession(c *fiber.Ctx) error {
+	if Authenticated(c) {
+		return apperror.Forbidden("api_key_not_allowed", "Endpoint can't be used with an API key, sign in instead.")
+	}
+
+	return c.Next()
+}
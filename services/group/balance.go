@@ -0,0 +1,186 @@
+package group
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/config"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/services/notification"
+	"github.com/dinopuguh/mycap-backend/services/organization"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"gorm.io/gorm"
+)
+
+// LowBalanceThresholds are percentages of the monthly allowance used that notify the user by in-app
+// notification and email
+var LowBalanceThresholds = config.Default().Balance.LowBalanceThresholds
+
+// TimeWarnings are remaining times during a session that send a live warning to the group admin
+var TimeWarnings = config.Default().Balance.TimeWarnings
+
+// Configure sets the thresholds and warnings of remaining time
+func Configure(cfg config.Balance) {
+	LowBalanceThresholds = cfg.LowBalanceThresholds
+	TimeWarnings = cfg.TimeWarnings
+}
+
+// usedPercentage returns the percentage of the monthly allowance used, bonus and pooled time aren't
+// part of the allowance so only the allowance left is measured against it
+func usedPercentage(allowanceLeft int64) int64 {
+	switch {
+	case allowanceLeft >= user.MonthlyAllowance:
+		return 0
+	case allowanceLeft <= 0:
+		return 100
+	default:
+		return (user.MonthlyAllowance - allowanceLeft) * 100 / user.MonthlyAllowance
+	}
+}
+
+// allowanceLeft returns the monthly allowance an user has left once time used in a session is
+// consumed, pooled time is drawn first and the allowance before bonus time like organization.Draw
+// and user.Consume do when the session ends
+func allowanceLeft(u user.User, pooledTime, used int64) int64 {
+	if used -= pooledTime; used < 0 {
+		used = 0
+	}
+	if left := u.RemainingTime - used; left > 0 {
+		return left
+	}
+
+	return 0
+}
+
+// crossedThreshold returns the highest threshold reached by the allowance left, 0 if none
+func crossedThreshold(thresholds []int64, allowanceLeft int64) int64 {
+	used := usedPercentage(allowanceLeft)
+
+	var crossed int64
+	for _, threshold := range thresholds {
+		if used >= threshold && threshold > crossed {
+			crossed = threshold
+		}
+	}
+
+	return crossed
+}
+
+// dueWarning returns the smallest time warning reached by the remaining time and not sent yet, 0 if none
+func dueWarning(warnings []time.Duration, remainingTime int64, warnedAt int64) int64 {
+	sorted := append([]time.Duration(nil), warnings...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for _, warning := range sorted {
+		ms := warning.Milliseconds()
+		if remainingTime > ms {
+			continue
+		}
+		if warnedAt == 0 || ms < warnedAt {
+			return ms
+		}
+		return 0
+	}
+
+	return 0
+}
+
+// notifyLowBalance notifies an user once per threshold crossed by its monthly allowance left
+func notifyLowBalance(db *gorm.DB, u *user.User, allowanceLeft int64) error {
+	crossed := crossedThreshold(LowBalanceThresholds, allowanceLeft)
+	if crossed <= u.NotifiedThreshold {
+		return nil
+	}
+
+	res := db.Model(&user.User{}).Where("id = ? AND notified_threshold < ?", u.ID, crossed).UpdateColumn("notified_threshold", crossed)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return res.Error
+	}
+	u.NotifiedThreshold = crossed
+
+	return notification.Send(db, *u, notification.KindLowBalance,
+		"Your MyCap time is running low",
+		fmt.Sprintf("You have used %d%% of your monthly time, %d minutes remaining.", crossed, allowanceLeft/time.Minute.Milliseconds()),
+		true)
+}
+
+// warnAdmin sends a live warning to the group admin once per time warning reached during the session
+func warnAdmin(db *gorm.DB, group *Group, remainingTime int64) error {
+	warning := dueWarning(TimeWarnings, remainingTime, group.WarnedAt)
+	if warning == 0 {
+		return nil
+	}
+
+	res := db.Model(&Group{}).Where("id = ? AND (warned_at = 0 OR warned_at > ?)", group.ID, warning).UpdateColumn("warned_at", warning)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return res.Error
+	}
+	group.WarnedAt = warning
+
+	return notification.Send(db, group.Admin, notification.KindTimeWarning,
+		"Your group session is running out of time",
+		fmt.Sprintf("Your group session will end in %d minutes when your remaining time runs out.", (remainingTime+time.Minute.Milliseconds()-1)/time.Minute.Milliseconds()),
+		false)
+}
+
+// endExhausted ends a group session whose admin ran out of time and notifies its participants
+func endExhausted(db *gorm.DB, group *Group) error {
+	if err := end(gormStore{db: db}, group, &group.Admin, 0); err != nil {
+		if err == ErrGroupNotFound {
+			return nil
+		}
+		return err
+	}
+
+	ended := notification.Notification{
+		Kind:    notification.KindSessionEnded,
+		Title:   "Group session ended",
+		Message: fmt.Sprintf("The group session of %s ended because the admin ran out of time.", group.AdminUsername),
+	}
+	for _, participant := range group.Participants {
+		if participant.ID != group.AdminID {
+			notification.Live.Publish(participant.ID, ended)
+		}
+	}
+
+	if err := notification.Send(db, group.Admin, notification.KindSessionEnded,
+		"Your group session ended",
+		"Your group session ended because your remaining time ran out this month.",
+		true); err != nil {
+		return err
+	}
+
+	return notifyLowBalance(db, &group.Admin, 0)
+}
+
+// CheckBalances warns admins of active groups as their remaining time runs low, notifies crossed
+// low balance thresholds and gracefully ends sessions whose admin ran out of time
+func CheckBalances(db *gorm.DB, now time.Time) error {
+	var groups []Group
+	if err := db.Preload("Admin").Preload("Participants").Find(&groups).Error; err != nil {
+		return err
+	}
+
+	for i := range groups {
+		group := &groups[i]
+		pooledTime, err := organization.Pool(db, group.AdminID)
+		if err != nil {
+			logger.FromContext(db.Statement.Context).Error().Err(err).Uint("group_id", group.ID).Msg("Check balance of group failed.")
+			continue
+		}
+		used := now.Sub(group.CreatedAt).Milliseconds()
+		remainingTime := group.Admin.Balance() + pooledTime - used
+
+		if remainingTime <= 0 {
+			err = endExhausted(db, group)
+		} else if err = warnAdmin(db, group, remainingTime); err == nil {
+			err = notifyLowBalance(db, &group.Admin, allowanceLeft(group.Admin, pooledTime, used))
+		}
+		if err != nil {
+			logger.FromContext(db.Statement.Context).Error().Err(err).Uint("group_id", group.ID).Msg("Check balance of group failed.")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package group
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrossedThreshold(t *testing.T) {
+	thresholds := []int64{80, 95}
+
+	tests := []struct {
+		name          string
+		remainingTime int64
+		crossed       int64
+	}{
+		{"Full allowance", user.MonthlyAllowance, 0},
+		{"Below first threshold", user.MonthlyAllowance / 2, 0},
+		{"First threshold", user.MonthlyAllowance / 5, 80},
+		{"Second threshold", user.MonthlyAllowance / 20, 95},
+		{"Run out", 0, 95},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.crossed, crossedThreshold(thresholds, tt.remainingTime))
+		})
+	}
+}
+
+func TestAllowanceLeft(t *testing.T) {
+	minute := time.Minute.Milliseconds()
+	admin := user.User{RemainingTime: 100 * minute, BonusTime: 30 * minute}
+
+	tests := []struct {
+		name       string
+		pooledTime int64
+		used       int64
+		left       int64
+	}{
+		{"Allowance used first", 0, 40 * minute, 60 * minute},
+		{"Pool drawn before allowance", 50 * minute, 40 * minute, 100 * minute},
+		{"Pool partly covers", 10 * minute, 40 * minute, 70 * minute},
+		{"Bonus not counted", 0, 120 * minute, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.left, allowanceLeft(admin, tt.pooledTime, tt.used))
+		})
+	}
+}
+
+func TestDueWarning(t *testing.T) {
+	warnings := []time.Duration{10 * time.Minute, time.Minute, 5 * time.Minute}
+	minute := time.Minute.Milliseconds()
+
+	tests := []struct {
+		name          string
+		remainingTime int64
+		warnedAt      int64
+		due           int64
+	}{
+		{"Plenty of time", 30 * minute, 0, 0},
+		{"First warning", 9 * minute, 0, 10 * minute},
+		{"Already warned", 9 * minute, 10 * minute, 0},
+		{"Skipped warnings", 30 * 1000, 10 * minute, minute},
+		{"Last warning sent", 30 * 1000, minute, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.due, dueWarning(warnings, tt.remainingTime, tt.warnedAt))
+		})
+	}
+}
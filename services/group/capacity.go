@@ -0,0 +1,10 @@
+package group
+
+// ParticipantLimit returns maximum participants of the group by its admin's plan, 0 means unlimited
+func (g Group) ParticipantLimit() int64 {
+	if g.Type == ConferenceType {
+		return g.Admin.Type.MaxConferenceParticipants
+	}
+
+	return g.Admin.Type.MaxGroupParticipants
+}
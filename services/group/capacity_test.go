@@ -0,0 +1,17 @@
+package group_test
+
+import (
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/services/group"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParticipantLimit(t *testing.T) {
+	free := user.User{Type: user.Type{Name: "Free", MaxGroupParticipants: 5, MaxConferenceParticipants: 50}}
+
+	assert.Equal(t, int64(5), group.Group{Type: group.GroupType, Admin: free}.ParticipantLimit())
+	assert.Equal(t, int64(50), group.Group{Type: group.ConferenceType, Admin: free}.ParticipantLimit())
+	assert.Equal(t, int64(0), group.Group{Type: group.GroupType}.ParticipantLimit())
+}
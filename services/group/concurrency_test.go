@@ -0,0 +1,151 @@
+package group_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/database/databasetest"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/routes"
+	"github.com/dinopuguh/mycap-backend/services/group"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentOperations(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	app := routes.New(cfg)
+
+	request := func(method, endpoint, token string, data interface{}) *response.HTTP {
+		reqBody, _ := json.Marshal(data)
+		req, _ := http.NewRequest(method, endpoint, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resHTTP := new(response.HTTP)
+		res, err := app.Test(req, -1)
+		if err != nil {
+			resHTTP.Message = err.Error()
+			return resHTTP
+		}
+		defer res.Body.Close()
+		resBody, _ := ioutil.ReadAll(res.Body)
+		json.Unmarshal(resBody, &resHTTP)
+
+		return resHTTP
+	}
+
+	register := func(username string) *user.ResponseAuth {
+		resHTTP := request(http.MethodPost, "/api/v1/register", "", user.RegisterUser{
+			Name:     username,
+			Email:    username + "@mycap.com",
+			Username: username,
+			Password: "s3cr3tp45sw0rd",
+			TypeID:   1,
+		})
+		auth := new(user.ResponseAuth)
+		authJSON, _ := json.Marshal(resHTTP.Data)
+		json.Unmarshal(authJSON, &auth)
+
+		return auth
+	}
+
+	hammer := func(n int, fn func(i int) *response.HTTP) map[int]int {
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			statuses = map[int]int{}
+		)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				resHTTP := fn(i)
+
+				mu.Lock()
+				statuses[resHTTP.Status]++
+				mu.Unlock()
+			}(i)
+		}
+		wg.Wait()
+
+		return statuses
+	}
+
+	admin := register("dinohammer")
+	joiners := make([]*user.ResponseAuth, 8)
+	for i := range joiners {
+		joiners[i] = register(fmt.Sprintf("dinohammer%d", i))
+	}
+
+	defer func() {
+		for _, auth := range append(joiners, admin) {
+			endpoint := fmt.Sprintf("/api/v1/users/%d", auth.User.ID)
+			request(http.MethodDelete, endpoint, auth.AccessToken, nil)
+		}
+	}()
+
+	t.Run("Create once", func(t *testing.T) {
+		statuses := hammer(10, func(int) *response.HTTP {
+			return request(http.MethodPost, "/api/v1/groups", admin.AccessToken, group.CreateGroup{
+				Type: group.GroupType,
+			})
+		})
+
+		assert.Equalf(t, 1, statuses[http.StatusOK], "%v", statuses)
+		assert.Equalf(t, 9, statuses[http.StatusBadRequest], "%v", statuses)
+	})
+
+	t.Run("Join up to the limit", func(t *testing.T) {
+		statuses := hammer(len(joiners), func(i int) *response.HTTP {
+			return request(http.MethodPost, "/api/v1/join-groups", joiners[i].AccessToken, group.JoinGroup{
+				AdminUsername: "dinohammer",
+			})
+		})
+
+		// Free plan allows 5 participants including the admin
+		assert.Equalf(t, 4, statuses[http.StatusOK], "%v", statuses)
+		assert.Equalf(t, len(joiners)-4, statuses[http.StatusPaymentRequired], "%v", statuses)
+
+		var participants int64
+		database.DBConn.Table("group_participants").
+			Joins("JOIN groups ON groups.id = group_participants.group_id").
+			Where("groups.admin_id = ? AND groups.deleted_at IS NULL", admin.User.ID).
+			Count(&participants)
+		assert.Equal(t, int64(5), participants)
+	})
+
+	t.Run("Leave and end together", func(t *testing.T) {
+		hammer(len(joiners)+1, func(i int) *response.HTTP {
+			auth := admin
+			if i < len(joiners) {
+				auth = joiners[i]
+			}
+
+			return request(http.MethodPost, "/api/v1/leave-groups", auth.AccessToken, group.LeaveGroup{
+				AdminUsername: "dinohammer",
+				RemainingTime: 36000000,
+			})
+		})
+
+		var active int64
+		database.DBConn.Model(&group.Group{}).Where("admin_id = ?", admin.User.ID).Count(&active)
+		assert.Equal(t, int64(0), active)
+
+		var orphans int64
+		database.DBConn.Table("group_participants").
+			Joins("JOIN groups ON groups.id = group_participants.group_id").
+			Where("groups.admin_id = ?", admin.User.ID).
+			Count(&orphans)
+		assert.Equal(t, int64(0), orphans)
+	})
+}
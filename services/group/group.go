@@ -5,21 +5,65 @@ import (
 
 	"gorm.io/gorm"
 
-	"github.com/dgrijalva/jwt-go"
-	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/helpers"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/pagination"
+	"github.com/dinopuguh/mycap-backend/ratelimit"
 	"github.com/dinopuguh/mycap-backend/response"
 	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/dinopuguh/mycap-backend/validation"
 	"github.com/gofiber/fiber/v2"
 )
 
 // Group is a model for group chat
 type Group struct {
 	gorm.Model
-	AdminID       uint        `json:"admin_id"`
-	AdminUsername string      `json:"admin_username"`
+	AdminID       uint        `json:"admin_id" gorm:"index;"`
+	AdminUsername string      `json:"admin_username" gorm:"index;"`
 	Admin         user.User   `json:"admin"`
-	Type          string      `json:"type"`
+	Type          string      `json:"type" gorm:"index;"`
+	Visibility    string      `json:"visibility" gorm:"default:public;index;"`
+	PasscodeHash  string      `json:"-"`
 	Participants  []user.User `json:"participants" gorm:"many2many:group_participants;"`
+	WarnedAt      int64       `json:"-"` // smallest time warning in milliseconds sent to the admin
+}
+
+// PublicGroup represents group data with participants visible to other users
+type PublicGroup struct {
+	gorm.Model
+	AdminID       uint              `json:"admin_id"`
+	AdminUsername string            `json:"admin_username"`
+	Admin         user.PublicUser   `json:"admin"`
+	Type          string            `json:"type"`
+	Visibility    string            `json:"visibility"`
+	HasPasscode   bool              `json:"has_passcode"`
+	Participants  []user.PublicUser `json:"participants"`
+}
+
+// GroupSummary represents group data in group listing
+type GroupSummary struct {
+	gorm.Model
+	AdminID          uint   `json:"admin_id"`
+	AdminUsername    string `json:"admin_username"`
+	Type             string `json:"type"`
+	Visibility       string `json:"visibility"`
+	ParticipantCount int64  `json:"participant_count"`
+	Status           string `json:"status" gorm:"-"`
+}
+
+// Public converts group to representation visible to other users
+func (g Group) Public() PublicGroup {
+	return PublicGroup{
+		Model:         g.Model,
+		AdminID:       g.AdminID,
+		AdminUsername: g.AdminUsername,
+		Admin:         g.Admin.Public(),
+		Type:          g.Type,
+		Visibility:    g.Visibility,
+		HasPasscode:   g.PasscodeHash != "",
+		Participants:  user.PublicUsers(g.Participants),
+	}
 }
 
 const (
@@ -29,28 +73,119 @@ const (
 	ConferenceType = "Conference"
 )
 
-// GetAll is a function to get all groups from database
+const (
+	// VisibilityPublic is a visibility for group listed to everyone
+	VisibilityPublic = "public"
+	// VisibilityUnlisted is a visibility for group joinable by admin username but not listed
+	VisibilityUnlisted = "unlisted"
+	// VisibilityPrivate is a visibility for group not listed and only joinable with passcode
+	VisibilityPrivate = "private"
+)
+
+const (
+	// StatusActive is a status for ongoing group
+	StatusActive = "active"
+	// StatusEnded is a status for group ended by its admin
+	StatusEnded = "ended"
+)
+
+// Handler serves group endpoints with its dependencies
+type Handler struct {
+	Groups Repository
+	Users  user.Repository
+
+	passcodes passcodeLimiter
+}
+
+// NewHandler creates group endpoints backed by the repositories, passcode attempts are counted in
+// the rate limit store
+func NewHandler(groups Repository, users user.Repository, attempts ratelimit.Store) *Handler {
+	return &Handler{
+		Groups:    groups,
+		Users:     users,
+		passcodes: passcodeLimiter{store: attempts},
+	}
+}
+
+func (h *Handler) currentUser(c *fiber.Ctx) (*user.User, error) {
+	return user.Current(c, h.Users.WithContext(logger.Context(c)))
+}
+
+// groups returns the group repository running queries with the logger of the request
+func (h *Handler) groups(c *fiber.Ctx) Repository {
+	return h.Groups.WithContext(logger.Context(c))
+}
+
+var groupSortColumns = map[string]string{
+	"created_at":        "groups.created_at",
+	"participant_count": "participant_count",
+}
+
+// GetAll is a function to get groups visible to the current user with pagination and filters
 // @Summary Get all groups
-// @Description Get all groups
+// @Description Get public groups and groups the current user belongs to with participant counts
 // @Tags groups
 // @Accept json
 // @Produce json
-// @Success 200 {object} response.HTTP{data=[]Group}
+// @Param type query string false "Group type (Group or Conference)"
+// @Param admin query string false "Admin username"
+// @Param status query string false "Group status (active, ended or all)"
+// @Param sort query string false "Sort by created_at or participant_count, prefix with - for descending"
+// @Param limit query int false "Page size"
+// @Param cursor query string false "Next page cursor"
+// @Success 200 {object} response.HTTP{data=pagination.Response{items=[]GroupSummary}}
+// @Security ApiKeyAuth
 // @Router /v1/groups [get]
-func GetAll(c *fiber.Ctx) error {
-	db := database.DBConn
+func (h *Handler) GetAll(c *fiber.Ctx) error {
+	page, err := pagination.Parse(c)
+	if err != nil {
+		return err
+	}
+
+	order, err := pagination.Sort(c.Query("sort"), groupSortColumns, "groups.id asc")
+	if err != nil {
+		return err
+	}
 
-	var groups []Group
-	if res := db.Preload("Admin").Preload("Admin.Type").Preload("Participants").Preload("Participants.Type").Find(&groups); res.Error != nil {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusServiceUnavailable,
-			Message: res.Error.Error(),
-		})
+	currentUser, err := h.currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	filter := Filter{
+		ViewerID:      currentUser.ID,
+		AdminUsername: c.Query("admin"),
+		Status:        c.Query("status", StatusActive),
+	}
+
+	if groupType := c.Query("type"); groupType != "" {
+		if groupType != GroupType && groupType != ConferenceType {
+			return apperror.Invalid("group_type_invalid", "Group type invalid.")
+		}
+		filter.Type = groupType
+	}
+
+	switch filter.Status {
+	case StatusActive, StatusEnded, "all":
+	default:
+		return apperror.Invalid("group_status_invalid", "Group status invalid.")
+	}
+
+	groups, total, err := h.groups(c).List(filter, page, order)
+	if err != nil {
+		return err
+	}
+
+	for i := range groups {
+		groups[i].Status = StatusActive
+		if groups[i].DeletedAt.Valid {
+			groups[i].Status = StatusEnded
+		}
 	}
 
 	return c.JSON(response.HTTP{
 		Success: true,
-		Data:    groups,
+		Data:    page.Response(groups, len(groups), total),
 		Status:  http.StatusOK,
 		Message: "Success get all groups.",
 	})
@@ -63,70 +198,56 @@ func GetAll(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param group body CreateGroup true "Create group"
-// @Success 200 {object} response.HTTP{data=Group}
+// @Success 200 {object} response.HTTP{data=PublicGroup}
 // @Security ApiKeyAuth
 // @Router /v1/groups [post]
-func New(c *fiber.Ctx) error {
-	db := database.DBConn
-
-	token := c.Locals("user").(*jwt.Token)
-	claims := token.Claims.(jwt.MapClaims)
-	email := claims["email"].(string)
-
-	var admin = new(user.User)
-	if err := db.Preload("Type").Where("email = ?", email).First(&admin).Error; err != nil {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusServiceUnavailable,
-			Message: err.Error(),
-		})
+func (h *Handler) New(c *fiber.Ctx) error {
+	admin, err := h.currentUser(c)
+	if err != nil {
+		return err
 	}
 
-	var existingGroup = new(Group)
-	if res := db.Where("admin_id = ?", admin.ID).First(&existingGroup); res.RowsAffected != 0 {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusBadRequest,
-			Message: "You are already has a group chat or conference.",
-		})
+	createGroup := new(CreateGroup)
+	if err := c.BodyParser(&createGroup); err != nil {
+		return apperror.Invalid("body_invalid", err.Error())
 	}
 
-	if admin.ReachedTimeLimit {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusBadRequest,
-			Message: "This user already reached time limit this month.",
-		})
+	if err := validation.Struct(createGroup); err != nil {
+		return err
 	}
 
 	var group = new(Group)
+	group.AdminID = admin.ID
+	group.AdminUsername = admin.Username
 
-	participants := make([]user.User, 0)
-	participants = append(participants, *admin)
+	group.Type = createGroup.Type
 
-	createGroup := new(CreateGroup)
-	if err := c.BodyParser(&createGroup); err != nil {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusBadRequest,
-			Message: err.Error(),
-		})
+	group.Visibility = createGroup.Visibility
+	if group.Visibility == "" {
+		group.Visibility = VisibilityPublic
 	}
 
-	group.Admin = *admin
-	group.AdminUsername = admin.Username
-	group.Participants = participants
+	if group.Visibility == VisibilityPrivate && createGroup.Passcode == "" {
+		return apperror.Invalid("passcode_required", "Private group requires a passcode.")
+	}
 
-	if createGroup.Type != GroupType && createGroup.Type != ConferenceType {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusBadRequest,
-			Message: "Group type not specified.",
-		})
+	if createGroup.Passcode != "" {
+		group.PasscodeHash, err = helpers.HashPassword(createGroup.Passcode)
+		if err != nil {
+			return err
+		}
 	}
 
-	group.Type = createGroup.Type
+	if err := h.groups(c).Create(group); err != nil {
+		return err
+	}
 
-	db.Create(group)
+	group.Admin = *admin
+	group.Participants = []user.User{*admin}
 
 	return c.JSON(response.HTTP{
 		Success: true,
-		Data:    group,
+		Data:    group.Public(),
 		Status:  http.StatusOK,
 		Message: "Success create a new group.",
 	})
@@ -139,44 +260,55 @@ func New(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param group body JoinGroup true "Join group"
-// @Success 200 {object} response.HTTP{data=Group}
+// @Success 200 {object} response.HTTP{data=PublicGroup}
 // @Security ApiKeyAuth
 // @Router /v1/join-groups [post]
-func Join(c *fiber.Ctx) error {
-	db := database.DBConn
-
-	token := c.Locals("user").(*jwt.Token)
-	claims := token.Claims.(jwt.MapClaims)
-	email := claims["email"].(string)
-
-	var joiningUser = new(user.User)
-	db.Where("email = ?", email).First(&joiningUser)
+func (h *Handler) Join(c *fiber.Ctx) error {
+	joiningUser, err := h.currentUser(c)
+	if err != nil {
+		return err
+	}
 
 	joinGroup := new(JoinGroup)
 	if err := c.BodyParser(&joinGroup); err != nil {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusBadRequest,
-			Message: err.Error(),
-		})
+		return apperror.Invalid("body_invalid", err.Error())
 	}
 
-	var group = new(Group)
-	if res := db.Where("admin_username = ?", joinGroup.AdminUsername).First(&group); res.RowsAffected == 0 {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusNotFound,
-			Message: "Group not found.",
-		})
+	if err := validation.Struct(joinGroup); err != nil {
+		return err
+	}
+
+	group, err := h.groups(c).FindByAdminUsername(joinGroup.AdminUsername)
+	if err != nil {
+		return err
 	}
 
-	group.Participants = append(group.Participants, *joiningUser)
+	if group.PasscodeHash != "" && group.AdminID != joiningUser.ID {
+		retryAfter, blocked, err := h.passcodes.attempt(group.ID, joiningUser.ID)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return apperror.RateLimited("passcode_attempts_exceeded", "Too many passcode attempts, try again in %d minutes.", int(retryAfter.Minutes())+1)
+		}
+
+		if !helpers.CheckPasswordHash(joinGroup.Passcode, group.PasscodeHash) {
+			return apperror.Forbidden("passcode_incorrect", "Passcode incorrect.")
+		}
+	}
 
-	db.Save(&group)
+	if err := h.groups(c).Join(group, joiningUser); err != nil {
+		return err
+	}
 
-	db.Preload("Admin").Preload("Admin.Type").Preload("Participants").First(&group, group.ID)
+	group, err = h.groups(c).FindByID(group.ID)
+	if err != nil {
+		return err
+	}
 
 	return c.JSON(response.HTTP{
 		Success: true,
-		Data:    group,
+		Data:    group.Public(),
 		Status:  http.StatusOK,
 		Message: "Success joining a group.",
 	})
@@ -189,53 +321,48 @@ func Join(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param group body LeaveGroup true "Leave group"
-// @Success 200 {object} response.HTTP{data=Group}
+// @Success 200 {object} response.HTTP{data=PublicGroup}
 // @Security ApiKeyAuth
 // @Router /v1/leave-groups [post]
-func Leave(c *fiber.Ctx) error {
-	db := database.DBConn
-
-	token := c.Locals("user").(*jwt.Token)
-	claims := token.Claims.(jwt.MapClaims)
-	email := claims["email"].(string)
-
-	var leavingUser = new(user.User)
-	db.Where("email = ?", email).First(&leavingUser)
+func (h *Handler) Leave(c *fiber.Ctx) error {
+	leavingUser, err := h.currentUser(c)
+	if err != nil {
+		return err
+	}
 
 	leaveGroup := new(LeaveGroup)
 	if err := c.BodyParser(&leaveGroup); err != nil {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusBadRequest,
-			Message: err.Error(),
-		})
+		return apperror.Invalid("body_invalid", err.Error())
 	}
 
-	var group = new(Group)
-	if res := db.Preload("Admin").Preload("Admin.Type").Where("admin_username = ?", leaveGroup.AdminUsername).First(&group); res.RowsAffected == 0 {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusNotFound,
-			Message: "Group not found.",
-		})
+	if err := validation.Struct(leaveGroup); err != nil {
+		return err
+	}
+
+	group, err := h.groups(c).FindByAdminUsername(leaveGroup.AdminUsername)
+	if err != nil {
+		return err
 	}
 
 	if group.AdminID == leavingUser.ID {
-		leavingUser.RemainingTime = leaveGroup.RemainingTime
-		if leaveGroup.RemainingTime == 0 {
-			leavingUser.ReachedTimeLimit = true
+		if err := h.groups(c).End(group, leavingUser, leaveGroup.RemainingTime); err != nil {
+			return err
 		}
-		db.Save(&leavingUser)
 		group.Admin = *leavingUser
-
-		db.Model(&group).Association("Participants").Clear()
-		db.Delete(&group)
 	} else {
-		db.Model(&group).Association("Participants").Delete(leavingUser)
-		db.Preload("Admin").Preload("Admin.Type").Preload("Participants").First(&group, group.ID)
+		if err := h.groups(c).Leave(group, leavingUser); err != nil {
+			return err
+		}
+
+		group, err = h.groups(c).FindByID(group.ID)
+		if err != nil {
+			return err
+		}
 	}
 
 	return c.JSON(response.HTTP{
 		Success: true,
-		Data:    group,
+		Data:    group.Public(),
 		Status:  http.StatusOK,
 		Message: "Success leaving group.",
 	})
@@ -2,16 +2,47 @@ package group
 
 // CreateGroup is a data transfer object for create group
 type CreateGroup struct {
-	Type string `json:"type"`
+	Type       string `json:"type" validate:"required,oneof=Group Conference" example:"Group"`                // (Group, Conference)
+	Visibility string `json:"visibility" validate:"omitempty,oneof=public unlisted private" example:"public"` // (public, unlisted, private)
+	Passcode   string `json:"passcode" validate:"omitempty,min=4,max=32" example:"123456"`
 }
 
-// JoinGroup is a data transfer object for joining group
+// JoinGroup is a data transfer object for joining group, admin usernames aren't checked with the
+// username rule since users signed up before it or with social login may not match it
 type JoinGroup struct {
-	AdminUsername string `json:"admin_username"`
+	AdminUsername string `json:"admin_username" validate:"required,max=100" example:"dinopuguh"`
+	Passcode      string `json:"passcode" validate:"max=32" example:"123456"`
 }
 
-// LeaveGroup is a data transfer object for leaving group
+// LeaveGroup is a data transfer object for leaving group, admin usernames are checked like JoinGroup
 type LeaveGroup struct {
-	AdminUsername string `json:"admin_username"`
-	RemainingTime int64  `json:"remaining_time"`
+	AdminUsername string `json:"admin_username" validate:"required,max=100" example:"dinopuguh"`
+	RemainingTime int64  `json:"remaining_time" validate:"min=0" example:"1800"` // milliseconds
+}
+
+// UsageTotal represents time used and number of group sessions
+type UsageTotal struct {
+	TimeUsed int64 `json:"time_used"` // milliseconds
+	Duration int64 `json:"duration"`  // milliseconds
+	Sessions int64 `json:"sessions"`
+}
+
+// UsageByDay represents usage of a day
+type UsageByDay struct {
+	Date string `json:"date" example:"2020-10-01"`
+	UsageTotal
+}
+
+// UsageByType represents usage of a group type
+type UsageByType struct {
+	Type string `json:"type" example:"Group"`
+	UsageTotal
+}
+
+// UsageSummary represents monthly usage of an user
+type UsageSummary struct {
+	Month string `json:"month" example:"2020-10"`
+	UsageTotal
+	Days  []UsageByDay  `json:"days"`
+	Types []UsageByType `json:"types"`
 }
@@ -6,27 +6,30 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/dinopuguh/mycap-backend/mailer"
 	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/services/notification"
 	"github.com/dinopuguh/mycap-backend/services/user"
 
 	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/database/databasetest"
 	"github.com/dinopuguh/mycap-backend/routes"
 	"github.com/dinopuguh/mycap-backend/services/group"
 	"github.com/stretchr/testify/assert"
 )
 
 var (
-	createdGroup *group.Group
+	createdGroup *group.PublicGroup
 )
 
 func TestNew(t *testing.T) {
-	if err := database.Connect(); err != nil {
-		panic("Can't connect database.")
-	}
+	cfg := databasetest.Connect(t)
 
-	app := routes.New()
+	app := routes.New(cfg)
 
 	type args struct {
 		data          group.CreateGroup
@@ -60,6 +63,30 @@ func TestNew(t *testing.T) {
 			},
 			statusCode: http.StatusBadRequest,
 		}},
+		{"Group visibility invalid", args{
+			data: group.CreateGroup{
+				Type:       "Group",
+				Visibility: "hidden",
+			},
+			login: user.LoginUser{
+				Email:    "dinopuguh@mycap.com",
+				Password: "s3cr3tp45sw0rd",
+			},
+			statusCode:  http.StatusBadRequest,
+			contentType: "application/json",
+		}},
+		{"Private group without passcode", args{
+			data: group.CreateGroup{
+				Type:       "Group",
+				Visibility: group.VisibilityPrivate,
+			},
+			login: user.LoginUser{
+				Email:    "dinopuguh@mycap.com",
+				Password: "s3cr3tp45sw0rd",
+			},
+			statusCode:  http.StatusBadRequest,
+			contentType: "application/json",
+		}},
 		{"Valid create group", args{
 			data: group.CreateGroup{
 				Type: "Group",
@@ -147,13 +174,13 @@ func TestNew(t *testing.T) {
 }
 
 func TestGetAll(t *testing.T) {
-	if err := database.Connect(); err != nil {
-		panic("Can't connect database.")
-	}
+	cfg := databasetest.Connect(t)
 
-	app := routes.New()
+	app := routes.New(cfg)
 
 	type args struct {
+		query         string
+		login         user.LoginUser
 		expectDBError bool
 		statusCode    int
 	}
@@ -162,10 +189,42 @@ func TestGetAll(t *testing.T) {
 		args args
 	}{
 		{"Valid get all", args{
+			login: user.LoginUser{
+				Email:    "dinopuguh@email.com",
+				Password: "s3cr3tp45sw0rd",
+			},
 			expectDBError: false,
 			statusCode:    http.StatusOK,
 		}},
+		{"Valid filter", args{
+			query: "?type=Group&admin=dinopuguh&status=all&sort=-participant_count&limit=10",
+			login: user.LoginUser{
+				Email:    "dinopuguh@email.com",
+				Password: "s3cr3tp45sw0rd",
+			},
+			statusCode: http.StatusOK,
+		}},
+		{"Group type invalid", args{
+			query: "?type=Chat Room",
+			login: user.LoginUser{
+				Email:    "dinopuguh@email.com",
+				Password: "s3cr3tp45sw0rd",
+			},
+			statusCode: http.StatusBadRequest,
+		}},
+		{"Group status invalid", args{
+			query: "?status=paused",
+			login: user.LoginUser{
+				Email:    "dinopuguh@email.com",
+				Password: "s3cr3tp45sw0rd",
+			},
+			statusCode: http.StatusBadRequest,
+		}},
 		{"DB connection closed", args{
+			login: user.LoginUser{
+				Email:    "dinopuguh@email.com",
+				Password: "s3cr3tp45sw0rd",
+			},
 			expectDBError: true,
 			statusCode:    http.StatusServiceUnavailable,
 		}},
@@ -173,14 +232,27 @@ func TestGetAll(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			loginBody, _ := json.Marshal(tt.args.login)
+			reqLogin, _ := http.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewBuffer(loginBody))
+			reqLogin.Header.Set("Content-Type", "application/json")
+
+			resHTTP := new(response.HTTP)
+			login := new(user.ResponseAuth)
+			resLogin, _ := app.Test(reqLogin, -1)
+			defer resLogin.Body.Close()
+			resBodyLogin, _ := ioutil.ReadAll(resLogin.Body)
+			json.Unmarshal(resBodyLogin, &resHTTP)
+			loginJSON, _ := json.Marshal(resHTTP.Data)
+			json.Unmarshal(loginJSON, &login)
+
 			if tt.args.expectDBError {
 				db, _ := database.DBConn.DB()
 				db.Close()
 			}
 
-			req, _ := http.NewRequest(http.MethodGet, "/api/v1/groups", nil)
+			req, _ := http.NewRequest(http.MethodGet, "/api/v1/groups"+strings.ReplaceAll(tt.args.query, " ", "%20"), nil)
+			req.Header.Set("Authorization", "Bearer "+login.AccessToken)
 
-			resHTTP := new(response.HTTP)
 			res, _ := app.Test(req, -1)
 			defer res.Body.Close()
 			resBody, _ := ioutil.ReadAll(res.Body)
@@ -192,11 +264,9 @@ func TestGetAll(t *testing.T) {
 }
 
 func TestJoin(t *testing.T) {
-	if err := database.Connect(); err != nil {
-		panic("Can't connect database.")
-	}
+	cfg := databasetest.Connect(t)
 
-	app := routes.New()
+	app := routes.New(cfg)
 
 	type args struct {
 		data        group.JoinGroup
@@ -219,6 +289,17 @@ func TestJoin(t *testing.T) {
 			statusCode:  http.StatusOK,
 			contentType: "application/json",
 		}},
+		{"Already joined", args{
+			data: group.JoinGroup{
+				AdminUsername: "dinopuguh",
+			},
+			login: user.LoginUser{
+				Email:    "dinopuguh@email.com",
+				Password: "s3cr3tp45sw0rd",
+			},
+			statusCode:  http.StatusConflict,
+			contentType: "application/json",
+		}},
 		{"Group not found.", args{
 			data: group.JoinGroup{
 				AdminUsername: "anyusername99",
@@ -271,12 +352,94 @@ func TestJoin(t *testing.T) {
 	}
 }
 
-func TestLeave(t *testing.T) {
-	if err := database.Connect(); err != nil {
-		panic("Can't connect database.")
+func TestJoinPasscode(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	app := routes.New(cfg)
+
+	request := func(endpoint, token string, data interface{}) (*response.HTTP, string) {
+		reqBody, _ := json.Marshal(data)
+		req, _ := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resHTTP := new(response.HTTP)
+		res, _ := app.Test(req, -1)
+		defer res.Body.Close()
+		resBody, _ := ioutil.ReadAll(res.Body)
+		json.Unmarshal(resBody, &resHTTP)
+
+		return resHTTP, string(resBody)
+	}
+
+	resHTTP, _ := request("/api/v1/register", "", user.RegisterUser{
+		Name:     "Dino Private",
+		Email:    "dinoprivate@mycap.com",
+		Username: "dinoprivate",
+		Password: "s3cr3tp45sw0rd",
+	})
+	admin := new(user.ResponseAuth)
+	adminJSON, _ := json.Marshal(resHTTP.Data)
+	json.Unmarshal(adminJSON, &admin)
+
+	resHTTP, _ = request("/api/v1/login", "", user.LoginUser{
+		Email:    "dinopuguh@email.com",
+		Password: "s3cr3tp45sw0rd",
+	})
+	joining := new(user.ResponseAuth)
+	joiningJSON, _ := json.Marshal(resHTTP.Data)
+	json.Unmarshal(joiningJSON, &joining)
+
+	resHTTP, resBody := request("/api/v1/groups", admin.AccessToken, group.CreateGroup{
+		Type:       group.ConferenceType,
+		Visibility: group.VisibilityPrivate,
+		Passcode:   "123456",
+	})
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+	type args struct {
+		passcode   string
+		statusCode int
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{"Passcode incorrect", args{
+			passcode:   "000000",
+			statusCode: http.StatusForbidden,
+		}},
+		{"Valid join with passcode", args{
+			passcode:   "123456",
+			statusCode: http.StatusOK,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resHTTP, resBody := request("/api/v1/join-groups", joining.AccessToken, group.JoinGroup{
+				AdminUsername: "dinoprivate",
+				Passcode:      tt.args.passcode,
+			})
+
+			assert.Equalf(t, tt.args.statusCode, resHTTP.Status, resBody)
+		})
 	}
 
-	app := routes.New()
+	request("/api/v1/leave-groups", joining.AccessToken, group.LeaveGroup{AdminUsername: "dinoprivate"})
+	request("/api/v1/leave-groups", admin.AccessToken, group.LeaveGroup{AdminUsername: "dinoprivate", RemainingTime: 36000000})
+
+	endpoint := fmt.Sprintf("/api/v1/users/%d", admin.User.ID)
+	reqDeleteUser, _ := http.NewRequest(http.MethodDelete, endpoint, nil)
+	reqDeleteUser.Header.Set("Authorization", "Bearer "+admin.AccessToken)
+	app.Test(reqDeleteUser, -1)
+}
+
+func TestLeave(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	app := routes.New(cfg)
 
 	type args struct {
 		data        group.LeaveGroup
@@ -369,3 +532,87 @@ func TestLeave(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckBalances(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	app := routes.New(cfg)
+	recorder := new(mailer.Recorder)
+	mailer.Default = recorder
+
+	request := func(method, endpoint, token string, data interface{}) (*response.HTTP, string) {
+		reqBody, _ := json.Marshal(data)
+		req, _ := http.NewRequest(method, endpoint, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resHTTP := new(response.HTTP)
+		res, _ := app.Test(req, -1)
+		defer res.Body.Close()
+		resBody, _ := ioutil.ReadAll(res.Body)
+		json.Unmarshal(resBody, &resHTTP)
+
+		return resHTTP, string(resBody)
+	}
+
+	resHTTP, _ := request(http.MethodPost, "/api/v1/register", "", user.RegisterUser{
+		Name:     "Dino Balance",
+		Email:    "dinobalance@mycap.com",
+		Username: "dinobalance",
+		Password: "s3cr3tp45sw0rd",
+	})
+	admin := new(user.ResponseAuth)
+	adminJSON, _ := json.Marshal(resHTTP.Data)
+	json.Unmarshal(adminJSON, &admin)
+	defer request(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", admin.User.ID), admin.AccessToken, nil)
+
+	remainingTime := 3 * time.Minute.Milliseconds()
+	database.DBConn.Model(&user.User{}).Where("id = ?", admin.User.ID).Update("remaining_time", remainingTime)
+
+	resHTTP, resBody := request(http.MethodPost, "/api/v1/groups", admin.AccessToken, group.CreateGroup{Type: group.GroupType})
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+	events, unsubscribe := notification.Live.Subscribe(admin.User.ID)
+	defer unsubscribe()
+
+	t.Run("Warn admin", func(t *testing.T) {
+		err := group.CheckBalances(database.DBConn, time.Now().Add(time.Minute))
+		assert.NoError(t, err)
+
+		kinds := map[string]int{}
+		for len(events) > 0 {
+			kinds[(<-events).Kind]++
+		}
+		assert.Equal(t, 1, kinds[notification.KindTimeWarning])
+		assert.Equal(t, 1, kinds[notification.KindLowBalance])
+
+		_, sent := recorder.Last(admin.User.Email)
+		assert.True(t, sent, "low balance is emailed")
+	})
+
+	t.Run("Warn once", func(t *testing.T) {
+		err := group.CheckBalances(database.DBConn, time.Now().Add(time.Minute))
+		assert.NoError(t, err)
+		assert.Len(t, events, 0)
+	})
+
+	t.Run("End exhausted session", func(t *testing.T) {
+		err := group.CheckBalances(database.DBConn, time.Now().Add(4*time.Minute))
+		assert.NoError(t, err)
+
+		var active int64
+		database.DBConn.Model(&group.Group{}).Where("admin_id = ?", admin.User.ID).Count(&active)
+		assert.Equal(t, int64(0), active)
+
+		var exhausted user.User
+		database.DBConn.First(&exhausted, admin.User.ID)
+		assert.Equal(t, int64(0), exhausted.RemainingTime)
+		assert.True(t, exhausted.ReachedTimeLimit)
+
+		message, sent := recorder.Last(admin.User.Email)
+		assert.True(t, sent)
+		assert.Equal(t, "Your group session ended", message.Subject)
+	})
+}
@@ -0,0 +1,248 @@
+package group_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dinopuguh/mycap-backend/pagination"
+	"github.com/dinopuguh/mycap-backend/ratelimit"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/services/group"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMemoryApp serves group endpoints backed by in-memory repositories with an admin on a plan
+// limited to 2 group participants and three other users, requests are signed in as the user
+// with email from the X-Email header
+func newMemoryApp(t *testing.T) (*fiber.App, *user.MemoryRepository, *group.MemoryRepository) {
+	types := user.NewMemoryTypeRepository(user.Type{ID: 1, Name: "Free", MaxGroupParticipants: 2})
+	users := user.NewMemoryRepository(types)
+	for _, username := range []string{"admin", "alice", "bob", "carol"} {
+		if err := users.Create(&user.User{Name: username, Username: username, Email: username + "@mycap.com", TypeID: 1}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups := group.NewMemoryRepository(users)
+	h := group.NewHandler(groups, users, ratelimit.NewMemoryStore())
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", &jwt.Token{Claims: jwt.MapClaims{"email": c.Get("X-Email")}})
+		return c.Next()
+	})
+	app.Get("/groups", h.GetAll)
+	app.Post("/groups", h.New)
+	app.Post("/join-groups", h.Join)
+	app.Post("/leave-groups", h.Leave)
+	app.Get("/account/history", h.History)
+	app.Get("/account/usage", h.Usage)
+
+	return app, users, groups
+}
+
+func memoryRequest(app *fiber.App, method, endpoint, email string, data interface{}) (*response.HTTP, string) {
+	reqBody, _ := json.Marshal(data)
+	req, _ := http.NewRequest(method, endpoint, bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Email", email)
+
+	resHTTP := new(response.HTTP)
+	res, _ := app.Test(req, -1)
+	defer res.Body.Close()
+	resBody, _ := ioutil.ReadAll(res.Body)
+	json.Unmarshal(resBody, &resHTTP)
+
+	return resHTTP, string(resBody)
+}
+
+func TestMemoryGroupLifecycle(t *testing.T) {
+	app, users, _ := newMemoryApp(t)
+
+	tests := []struct {
+		name       string
+		endpoint   string
+		email      string
+		data       interface{}
+		statusCode int
+	}{
+		{"Group type not specified", "/groups", "admin@mycap.com", group.CreateGroup{Type: "Chat Room"}, http.StatusBadRequest},
+		{"Group visibility invalid", "/groups", "admin@mycap.com", group.CreateGroup{Type: group.GroupType, Visibility: "hidden"}, http.StatusBadRequest},
+		{"Admin username not specified", "/join-groups", "alice@mycap.com", group.JoinGroup{}, http.StatusBadRequest},
+		{"Valid create", "/groups", "admin@mycap.com", group.CreateGroup{Type: group.GroupType}, http.StatusOK},
+		{"Already has group", "/groups", "admin@mycap.com", group.CreateGroup{Type: group.ConferenceType}, http.StatusBadRequest},
+		{"Group not found", "/join-groups", "alice@mycap.com", group.JoinGroup{AdminUsername: "alice"}, http.StatusNotFound},
+		{"Valid join", "/join-groups", "alice@mycap.com", group.JoinGroup{AdminUsername: "admin"}, http.StatusOK},
+		{"Already joined", "/join-groups", "alice@mycap.com", group.JoinGroup{AdminUsername: "admin"}, http.StatusConflict},
+		{"Group full", "/join-groups", "bob@mycap.com", group.JoinGroup{AdminUsername: "admin"}, http.StatusPaymentRequired},
+		{"Not a participant", "/leave-groups", "bob@mycap.com", group.LeaveGroup{AdminUsername: "admin"}, http.StatusBadRequest},
+		{"Valid leave", "/leave-groups", "alice@mycap.com", group.LeaveGroup{AdminUsername: "admin"}, http.StatusOK},
+		{"Join after a participant left", "/join-groups", "bob@mycap.com", group.JoinGroup{AdminUsername: "admin"}, http.StatusOK},
+		{"Admin ends group", "/leave-groups", "admin@mycap.com", group.LeaveGroup{AdminUsername: "admin", RemainingTime: user.MonthlyAllowance - time.Hour.Milliseconds()}, http.StatusOK},
+		{"Ended group not found", "/join-groups", "carol@mycap.com", group.JoinGroup{AdminUsername: "admin"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resHTTP, resBody := memoryRequest(app, http.MethodPost, tt.endpoint, tt.email, tt.data)
+			assert.Equalf(t, tt.statusCode, resHTTP.Status, resBody)
+		})
+	}
+
+	admin, _ := users.FindByUsername("admin")
+	assert.Equal(t, user.MonthlyAllowance-time.Hour.Milliseconds(), admin.RemainingTime)
+}
+
+func TestMemoryEndDrawsPool(t *testing.T) {
+	app, users, groups := newMemoryApp(t)
+
+	admin, _ := users.FindByUsername("admin")
+	admin.RemainingTime = (20 * time.Minute).Milliseconds()
+	admin.ReachedTimeLimit = true
+	users.Save(admin)
+
+	resHTTP, resBody := memoryRequest(app, http.MethodPost, "/groups", "admin@mycap.com", group.CreateGroup{Type: group.GroupType})
+	assert.Equalf(t, http.StatusBadRequest, resHTTP.Status, resBody)
+
+	groups.SetPool(1, (30 * time.Minute).Milliseconds(), admin.ID)
+	resHTTP, resBody = memoryRequest(app, http.MethodPost, "/groups", "admin@mycap.com", group.CreateGroup{Type: group.GroupType})
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+	resHTTP, resBody = memoryRequest(app, http.MethodPost, "/leave-groups", "admin@mycap.com", group.LeaveGroup{AdminUsername: "admin"})
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+	assert.Equal(t, (10 * time.Minute).Milliseconds(), groups.Pool(admin.ID), "time used is drawn from the pool first")
+	admin, _ = users.FindByUsername("admin")
+	assert.Equal(t, (20 * time.Minute).Milliseconds(), admin.RemainingTime)
+}
+
+func TestMemoryHistory(t *testing.T) {
+	app, _, _ := newMemoryApp(t)
+
+	memoryRequest(app, http.MethodPost, "/groups", "admin@mycap.com", group.CreateGroup{Type: group.GroupType})
+	memoryRequest(app, http.MethodPost, "/join-groups", "alice@mycap.com", group.JoinGroup{AdminUsername: "admin"})
+	memoryRequest(app, http.MethodPost, "/leave-groups", "admin@mycap.com", group.LeaveGroup{
+		AdminUsername: "admin",
+		RemainingTime: user.MonthlyAllowance - time.Minute.Milliseconds(),
+	})
+	memoryRequest(app, http.MethodPost, "/groups", "bob@mycap.com", group.CreateGroup{Type: group.ConferenceType})
+
+	tests := []struct {
+		name       string
+		email      string
+		query      string
+		statusCode int
+		admins     []string
+	}{
+		{"Administered", "admin@mycap.com", "", http.StatusOK, []string{"admin"}},
+		{"Joined", "alice@mycap.com", "", http.StatusOK, []string{"admin"}},
+		{"Active session", "bob@mycap.com", "", http.StatusOK, []string{"bob"}},
+		{"Type filter", "admin@mycap.com", "?type=Conference", http.StatusOK, []string{}},
+		{"Not a participant", "carol@mycap.com", "", http.StatusOK, []string{}},
+		{"Date invalid", "admin@mycap.com", "?from=yesterday", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resHTTP, resBody := memoryRequest(app, http.MethodGet, "/account/history"+tt.query, tt.email, nil)
+			assert.Equalf(t, tt.statusCode, resHTTP.Status, resBody)
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+
+			var page struct {
+				pagination.Response
+				Items []group.GroupSession `json:"items"`
+			}
+			pageJSON, _ := json.Marshal(resHTTP.Data)
+			json.Unmarshal(pageJSON, &page)
+
+			admins := []string{}
+			for _, s := range page.Items {
+				admins = append(admins, s.AdminUsername)
+			}
+			assert.Equal(t, tt.admins, admins)
+		})
+	}
+
+	resHTTP, resBody := memoryRequest(app, http.MethodGet, "/account/usage", "admin@mycap.com", nil)
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+	usage := new(group.UsageSummary)
+	usageJSON, _ := json.Marshal(resHTTP.Data)
+	json.Unmarshal(usageJSON, &usage)
+	assert.Equal(t, time.Now().UTC().Format("2006-01"), usage.Month)
+	assert.Equal(t, int64(1), usage.Sessions)
+	assert.Equal(t, time.Minute.Milliseconds(), usage.TimeUsed)
+	if assert.Len(t, usage.Types, 1) {
+		assert.Equal(t, group.GroupType, usage.Types[0].Type)
+	}
+}
+
+func TestMemoryJoinLegacyUsername(t *testing.T) {
+	app, users, _ := newMemoryApp(t)
+
+	// usernames of users signed up before the username rule may not match it
+	if err := users.Create(&user.User{Name: "Dino", Username: "Dino Puguh", Email: "dino@mycap.com", TypeID: 1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	memoryRequest(app, http.MethodPost, "/groups", "dino@mycap.com", group.CreateGroup{Type: group.GroupType})
+
+	resHTTP, resBody := memoryRequest(app, http.MethodPost, "/join-groups", "alice@mycap.com", group.JoinGroup{AdminUsername: "Dino Puguh"})
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+	resHTTP, resBody = memoryRequest(app, http.MethodPost, "/leave-groups", "alice@mycap.com", group.LeaveGroup{AdminUsername: "Dino Puguh"})
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+}
+
+func TestMemoryGetAll(t *testing.T) {
+	app, _, _ := newMemoryApp(t)
+
+	memoryRequest(app, http.MethodPost, "/groups", "admin@mycap.com", group.CreateGroup{Type: group.GroupType})
+	memoryRequest(app, http.MethodPost, "/groups", "alice@mycap.com", group.CreateGroup{Type: group.ConferenceType, Visibility: group.VisibilityUnlisted})
+	memoryRequest(app, http.MethodPost, "/join-groups", "bob@mycap.com", group.JoinGroup{AdminUsername: "alice"})
+
+	tests := []struct {
+		name       string
+		email      string
+		query      string
+		statusCode int
+		admins     []string
+	}{
+		{"Public groups only", "carol@mycap.com", "", http.StatusOK, []string{"admin"}},
+		{"Unlisted group of participant", "bob@mycap.com", "", http.StatusOK, []string{"admin", "alice"}},
+		{"Sorted by participant count", "bob@mycap.com", "?sort=-participant_count", http.StatusOK, []string{"alice", "admin"}},
+		{"Type filter", "bob@mycap.com", "?type=Conference", http.StatusOK, []string{"alice"}},
+		{"Ended groups", "bob@mycap.com", "?status=ended", http.StatusOK, []string{}},
+		{"Status invalid", "bob@mycap.com", "?status=paused", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resHTTP, resBody := memoryRequest(app, http.MethodGet, "/groups"+tt.query, tt.email, nil)
+			assert.Equalf(t, tt.statusCode, resHTTP.Status, resBody)
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+
+			var page struct {
+				pagination.Response
+				Items []group.GroupSummary `json:"items"`
+			}
+			pageJSON, _ := json.Marshal(resHTTP.Data)
+			json.Unmarshal(pageJSON, &page)
+
+			admins := []string{}
+			for _, g := range page.Items {
+				admins = append(admins, g.AdminUsername)
+			}
+			assert.Equal(t, tt.admins, admins)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package group
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/helpers"
+	"github.com/dinopuguh/mycap-backend/pagination"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GroupSession is a model for history of a group chat or conference session
+type GroupSession struct {
+	gorm.Model
+	GroupID          uint        `json:"group_id" gorm:"uniqueIndex;"`
+	AdminID          uint        `json:"admin_id" gorm:"index;"`
+	AdminUsername    string      `json:"admin_username"`
+	Type             string      `json:"type"`
+	StartedAt        time.Time   `json:"started_at" gorm:"index;"`
+	EndedAt          *time.Time  `json:"ended_at"`
+	Duration         int64       `json:"duration"`  // milliseconds between start and end
+	TimeUsed         int64       `json:"time_used"` // milliseconds deducted from admin's remaining time
+	ParticipantCount int64       `json:"participant_count"`
+	Participants     []user.User `json:"-" gorm:"many2many:group_session_participants;"`
+}
+
+func (s gormStore) startSession(group *Group) error {
+	groupSession := &GroupSession{
+		GroupID:          group.ID,
+		AdminID:          group.AdminID,
+		AdminUsername:    group.AdminUsername,
+		Type:             group.Type,
+		StartedAt:        group.CreatedAt,
+		ParticipantCount: 1,
+	}
+	if err := s.db.Omit(clause.Associations).Create(groupSession).Error; err != nil {
+		return err
+	}
+
+	return s.db.Exec("INSERT INTO group_session_participants (group_session_id, user_id) VALUES (?, ?)", groupSession.ID, group.AdminID).Error
+}
+
+func (s gormStore) joinSession(groupID uint, userID uint) error {
+	res := s.db.Exec(`INSERT INTO group_session_participants (group_session_id, user_id)
+		SELECT id, ? FROM group_sessions WHERE group_id = ? AND deleted_at IS NULL
+		ON CONFLICT DO NOTHING`, userID, groupID)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return res.Error
+	}
+
+	return s.db.Model(&GroupSession{}).Where("group_id = ?", groupID).
+		UpdateColumn("participant_count", gorm.Expr("participant_count + 1")).Error
+}
+
+func (s gormStore) endSession(groupID uint, endedAt time.Time, timeUsed int64) error {
+	return s.db.Model(&GroupSession{}).Where("group_id = ? AND ended_at IS NULL", groupID).Updates(map[string]interface{}{
+		"ended_at":  endedAt,
+		"duration":  gorm.Expr("FLOOR(EXTRACT(EPOCH FROM (CAST(? AS timestamptz) - started_at)) * 1000)", endedAt),
+		"time_used": timeUsed,
+	}).Error
+}
+
+var historySortColumns = map[string]string{
+	"started_at": "started_at",
+	"duration":   "duration",
+	"time_used":  "time_used",
+}
+
+// History is a function to get group sessions the current user administered or joined
+// @Summary Get group session history
+// @Description Get group chat and conference sessions the current user administered or joined
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param type query string false "Group type (Group or Conference)"
+// @Param from query string false "Started from date (2006-01-02 or RFC3339)"
+// @Param to query string false "Started to date (2006-01-02 or RFC3339)"
+// @Param sort query string false "Sort by started_at, duration or time_used, prefix with - for descending"
+// @Param limit query int false "Page size"
+// @Param cursor query string false "Next page cursor"
+// @Success 200 {object} response.HTTP{data=pagination.Response{items=[]GroupSession}}
+// @Security ApiKeyAuth
+// @Router /v1/account/history [get]
+func (h *Handler) History(c *fiber.Ctx) error {
+	page, err := pagination.Parse(c)
+	if err != nil {
+		return err
+	}
+
+	order, err := pagination.Sort(c.Query("sort"), historySortColumns, "started_at desc")
+	if err != nil {
+		return err
+	}
+
+	currentUser, err := h.currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	filter := HistoryFilter{UserID: currentUser.ID}
+
+	if groupType := c.Query("type"); groupType != "" {
+		if groupType != GroupType && groupType != ConferenceType {
+			return apperror.Invalid("group_type_invalid", "Group type invalid.")
+		}
+		filter.Type = groupType
+	}
+
+	if from := c.Query("from"); from != "" {
+		t, err := helpers.ParseDate(from)
+		if err != nil {
+			return apperror.Invalid("date_invalid", "From date invalid.")
+		}
+		filter.From = &t
+	}
+
+	if to := c.Query("to"); to != "" {
+		t, err := helpers.ParseDate(to)
+		if err != nil {
+			return apperror.Invalid("date_invalid", "To date invalid.")
+		}
+		filter.To = &t
+	}
+
+	sessions, total, err := h.groups(c).History(filter, page, order)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    page.Response(sessions, len(sessions), total),
+		Status:  http.StatusOK,
+		Message: "Success get group session history.",
+	})
+}
+
+// Usage is a function to get monthly usage of the current user's remaining time by day and by group type
+// @Summary Get monthly usage
+// @Description Get time used by the current user as group admin in a month, aggregated by day and by group type
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param month query string false "Month (2006-01), default current month"
+// @Success 200 {object} response.HTTP{data=UsageSummary}
+// @Security ApiKeyAuth
+// @Router /v1/account/usage [get]
+func (h *Handler) Usage(c *fiber.Ctx) error {
+	month := time.Now().UTC()
+	if value := c.Query("month"); value != "" {
+		parsed, err := time.Parse("2006-01", value)
+		if err != nil {
+			return apperror.Invalid("month_invalid", "Month invalid.")
+		}
+		month = parsed
+	}
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	currentUser, err := h.currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	usage, err := h.groups(c).Usage(currentUser.ID, start, end)
+	if err != nil {
+		return err
+	}
+	usage.Month = start.Format("2006-01")
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    usage,
+		Status:  http.StatusOK,
+		Message: "Success get monthly usage.",
+	})
+}
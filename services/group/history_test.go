@@ -0,0 +1,119 @@
+package group_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/database/databasetest"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/routes"
+	"github.com/dinopuguh/mycap-backend/services/group"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	app := routes.New(cfg)
+
+	request := func(method, endpoint, token string, data interface{}) (*response.HTTP, string) {
+		reqBody, _ := json.Marshal(data)
+		req, _ := http.NewRequest(method, endpoint, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resHTTP := new(response.HTTP)
+		res, _ := app.Test(req, -1)
+		defer res.Body.Close()
+		resBody, _ := ioutil.ReadAll(res.Body)
+		json.Unmarshal(resBody, &resHTTP)
+
+		return resHTTP, string(resBody)
+	}
+
+	register := func(username string) *user.ResponseAuth {
+		resHTTP, _ := request(http.MethodPost, "/api/v1/register", "", user.RegisterUser{
+			Name:     username,
+			Email:    username + "@mycap.com",
+			Username: username,
+			Password: "s3cr3tp45sw0rd",
+			TypeID:   1,
+		})
+		auth := new(user.ResponseAuth)
+		authJSON, _ := json.Marshal(resHTTP.Data)
+		json.Unmarshal(authJSON, &auth)
+
+		return auth
+	}
+
+	admin := register("dinohistory")
+	joining := register("dinohistory1")
+	defer func() {
+		for _, auth := range []*user.ResponseAuth{admin, joining} {
+			endpoint := fmt.Sprintf("/api/v1/users/%d", auth.User.ID)
+			request(http.MethodDelete, endpoint, auth.AccessToken, nil)
+		}
+	}()
+
+	request(http.MethodPost, "/api/v1/groups", admin.AccessToken, group.CreateGroup{Type: group.ConferenceType})
+	request(http.MethodPost, "/api/v1/join-groups", joining.AccessToken, group.JoinGroup{AdminUsername: "dinohistory"})
+	request(http.MethodPost, "/api/v1/leave-groups", joining.AccessToken, group.LeaveGroup{AdminUsername: "dinohistory"})
+	request(http.MethodPost, "/api/v1/join-groups", joining.AccessToken, group.JoinGroup{AdminUsername: "dinohistory"})
+	resHTTP, resBody := request(http.MethodPost, "/api/v1/leave-groups", admin.AccessToken, group.LeaveGroup{
+		AdminUsername: "dinohistory",
+		RemainingTime: admin.User.RemainingTime - 60000,
+	})
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+	t.Run("Participant history", func(t *testing.T) {
+		resHTTP, resBody := request(http.MethodGet, "/api/v1/account/history", joining.AccessToken, nil)
+		assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+		var page struct {
+			Items []group.GroupSession `json:"items"`
+		}
+		pageJSON, _ := json.Marshal(resHTTP.Data)
+		json.Unmarshal(pageJSON, &page)
+
+		if assert.Len(t, page.Items, 1, resBody) {
+			assert.Equal(t, group.ConferenceType, page.Items[0].Type)
+			assert.Equal(t, int64(2), page.Items[0].ParticipantCount)
+			assert.Equal(t, int64(60000), page.Items[0].TimeUsed)
+			assert.NotNil(t, page.Items[0].EndedAt)
+		}
+	})
+
+	t.Run("Admin usage", func(t *testing.T) {
+		resHTTP, resBody := request(http.MethodGet, "/api/v1/account/usage", admin.AccessToken, nil)
+		assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+		usage := new(group.UsageSummary)
+		usageJSON, _ := json.Marshal(resHTTP.Data)
+		json.Unmarshal(usageJSON, &usage)
+
+		assert.Equal(t, int64(60000), usage.TimeUsed)
+		assert.Equal(t, int64(1), usage.Sessions)
+		assert.Len(t, usage.Days, 1)
+		if assert.Len(t, usage.Types, 1) {
+			assert.Equal(t, group.ConferenceType, usage.Types[0].Type)
+		}
+	})
+
+	t.Run("Participant usage", func(t *testing.T) {
+		resHTTP, resBody := request(http.MethodGet, "/api/v1/account/usage", joining.AccessToken, nil)
+		assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+		assert.Contains(t, resBody, `"sessions":0`)
+	})
+
+	t.Run("Month invalid", func(t *testing.T) {
+		resHTTP, resBody := request(http.MethodGet, "/api/v1/account/usage?month=october", admin.AccessToken, nil)
+		assert.Equalf(t, http.StatusBadRequest, resHTTP.Status, resBody)
+	})
+}
@@ -0,0 +1,512 @@
+package group
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/pagination"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"gorm.io/gorm"
+)
+
+// MemoryRepository stores groups in memory, it's meant for tests running without database.
+// Operations share their rules with the database repository and only store through memoryStore.
+// Admins and participants are read from and time used is consumed through the user repository.
+// Sessions are recorded in the history and time used is drawn from organization pools set by
+// SetPool like in database, only low balance notifications aren't sent.
+type MemoryRepository struct {
+	mu           sync.Mutex
+	users        *user.MemoryRepository
+	groups       map[uint]Group
+	participants map[uint][]uint
+	sessions     map[uint]GroupSession // by group ID
+	attendees    map[uint][]uint       // users who joined the session of a group, by group ID
+	pools        map[uint]int64        // pooled time by organization ID
+	members      map[uint]uint         // organization ID by member ID
+	nextID       uint
+}
+
+// NewMemoryRepository creates an empty in-memory group repository
+func NewMemoryRepository(users *user.MemoryRepository) *MemoryRepository {
+	return &MemoryRepository{
+		users:        users,
+		groups:       make(map[uint]Group),
+		participants: make(map[uint][]uint),
+		sessions:     make(map[uint]GroupSession),
+		attendees:    make(map[uint][]uint),
+		pools:        make(map[uint]int64),
+		members:      make(map[uint]uint),
+		nextID:       1,
+	}
+}
+
+// SetPool sets the pooled time of an organization and makes users its members
+func (r *MemoryRepository) SetPool(organizationID uint, pooledTime int64, memberIDs ...uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pools[organizationID] = pooledTime
+	for _, id := range memberIDs {
+		r.members[id] = organizationID
+	}
+}
+
+// Pool returns the pooled time of the organization an user is a member of
+func (r *MemoryRepository) Pool(userID uint) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.pool(userID)
+}
+
+func (r *MemoryRepository) pool(userID uint) int64 {
+	organizationID, ok := r.members[userID]
+	if !ok {
+		return 0
+	}
+
+	return r.pools[organizationID]
+}
+
+// draw takes time used from the pool of the user's organization, returns the amount drawn
+func (r *MemoryRepository) draw(userID uint, used int64) int64 {
+	organizationID, ok := r.members[userID]
+	if !ok || used <= 0 {
+		return 0
+	}
+
+	drawn := used
+	if drawn > r.pools[organizationID] {
+		drawn = r.pools[organizationID]
+	}
+	if drawn < 0 {
+		drawn = 0
+	}
+	r.pools[organizationID] -= drawn
+
+	return drawn
+}
+
+func (r *MemoryRepository) active(id uint) (Group, bool) {
+	group, ok := r.groups[id]
+	return group, ok && !group.DeletedAt.Valid
+}
+
+func (r *MemoryRepository) preload(group Group, participants bool) (*Group, error) {
+	admin, err := r.users.FindByID(group.AdminID)
+	if err != nil {
+		return nil, err
+	}
+	group.Admin = *admin
+
+	if participants {
+		group.Participants = []user.User{}
+		for _, id := range r.participants[group.ID] {
+			participant, err := r.users.FindByID(id)
+			if err != nil {
+				return nil, err
+			}
+			group.Participants = append(group.Participants, *participant)
+		}
+	}
+
+	return &group, nil
+}
+
+func (r *MemoryRepository) joined(groupID, userID uint) int {
+	for i, id := range r.participants[groupID] {
+		if id == userID {
+			return i
+		}
+	}
+
+	return -1
+}
+
+var memoryGroupColumns = map[string]func(a, b GroupSummary) int{
+	"id":                func(a, b GroupSummary) int { return compareInt(int64(a.ID), int64(b.ID)) },
+	"groups.id":         func(a, b GroupSummary) int { return compareInt(int64(a.ID), int64(b.ID)) },
+	"groups.created_at": func(a, b GroupSummary) int { return compareInt(a.CreatedAt.UnixNano(), b.CreatedAt.UnixNano()) },
+	"participant_count": func(a, b GroupSummary) int { return compareInt(a.ParticipantCount, b.ParticipantCount) },
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// WithContext returns the repository itself, contexts only matter to queries
+func (r *MemoryRepository) WithContext(ctx context.Context) Repository {
+	return r
+}
+
+// List filters, sorts and pages groups, order is an order clause made by pagination.Sort
+func (r *MemoryRepository) List(filter Filter, page pagination.Page, order string) ([]GroupSummary, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var groups []GroupSummary
+	for _, group := range r.groups {
+		if group.Visibility != VisibilityPublic && group.AdminID != filter.ViewerID && r.joined(group.ID, filter.ViewerID) < 0 {
+			continue
+		}
+		if filter.Type != "" && group.Type != filter.Type {
+			continue
+		}
+		if filter.AdminUsername != "" && group.AdminUsername != filter.AdminUsername {
+			continue
+		}
+		switch filter.Status {
+		case StatusEnded:
+			if !group.DeletedAt.Valid {
+				continue
+			}
+		case "all":
+		default:
+			if group.DeletedAt.Valid {
+				continue
+			}
+		}
+
+		groups = append(groups, GroupSummary{
+			Model:            group.Model,
+			AdminID:          group.AdminID,
+			AdminUsername:    group.AdminUsername,
+			Type:             group.Type,
+			Visibility:       group.Visibility,
+			ParticipantCount: int64(len(r.participants[group.ID])),
+		})
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		for _, term := range strings.Split(order, ",") {
+			fields := strings.Fields(term)
+			compare, ok := memoryGroupColumns[fields[0]]
+			if !ok {
+				continue
+			}
+			if c := compare(groups[i], groups[j]); c != 0 {
+				return (c < 0) != (len(fields) > 1 && fields[1] == "desc")
+			}
+		}
+		return false
+	})
+
+	total := int64(len(groups))
+	if page.Offset >= len(groups) {
+		return []GroupSummary{}, total, nil
+	}
+	groups = groups[page.Offset:]
+	if len(groups) > page.Limit {
+		groups = groups[:page.Limit]
+	}
+
+	return groups, total, nil
+}
+
+// FindByAdminUsername returns the active group of an admin with the admin preloaded
+func (r *MemoryRepository) FindByAdminUsername(username string) (*Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, group := range r.groups {
+		if group.AdminUsername == username && !group.DeletedAt.Valid {
+			return r.preload(group, false)
+		}
+	}
+
+	return nil, ErrGroupNotFound
+}
+
+// FindByID returns an active group with the admin and participants preloaded
+func (r *MemoryRepository) FindByID(id uint) (*Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, ok := r.active(id)
+	if !ok {
+		return nil, ErrGroupNotFound
+	}
+
+	return r.preload(group, true)
+}
+
+func (r *MemoryRepository) attended(groupID, userID uint) bool {
+	for _, id := range r.attendees[groupID] {
+		if id == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Create stores a group with its admin as the first participant
+func (r *MemoryRepository) Create(group *Group) error {
+	return create(memoryStore{r}, group)
+}
+
+// Join adds an user to group participants up to the participant limit
+func (r *MemoryRepository) Join(group *Group, joiningUser *user.User) error {
+	return join(memoryStore{r}, group, joiningUser)
+}
+
+// Leave removes a participant from the group
+func (r *MemoryRepository) Leave(group *Group, leavingUser *user.User) error {
+	return leave(memoryStore{r}, group, leavingUser)
+}
+
+// End ends the group, draws the time used from the admin's organization pool, then its allowance
+// and bonus time, and records the time used in the session history
+func (r *MemoryRepository) End(group *Group, admin *user.User, remainingTime int64) error {
+	return end(memoryStore{r}, group, admin, remainingTime)
+}
+
+// memoryStore runs group operations in memory, atomic holds the repository lock so operations
+// run one at a time. Writes aren't rolled back, operations only write once their checks passed.
+type memoryStore struct {
+	r *MemoryRepository
+}
+
+func (s memoryStore) atomic(fn func(tx store) error) error {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	return fn(s)
+}
+
+func (s memoryStore) lockUser(u *user.User, id uint) error {
+	found, err := s.r.users.FindByID(id)
+	if err != nil {
+		return err
+	}
+	*u = *found
+
+	return nil
+}
+
+func (s memoryStore) lockGroup(groupID uint) error {
+	if _, ok := s.r.active(groupID); !ok {
+		return ErrGroupNotFound
+	}
+
+	return nil
+}
+
+func (s memoryStore) activeGroups(adminID uint) (int64, error) {
+	var count int64
+	for _, group := range s.r.groups {
+		if group.AdminID == adminID && !group.DeletedAt.Valid {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (s memoryStore) insertGroup(group *Group) error {
+	now := time.Now()
+	group.ID = s.r.nextID
+	group.CreatedAt = now
+	group.UpdatedAt = now
+	s.r.nextID++
+
+	stored := *group
+	stored.Admin = user.User{}
+	stored.Participants = nil
+	s.r.groups[group.ID] = stored
+
+	return nil
+}
+
+func (s memoryStore) deleteGroup(groupID uint) error {
+	stored := s.r.groups[groupID]
+	stored.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	s.r.groups[groupID] = stored
+	delete(s.r.participants, groupID)
+
+	return nil
+}
+
+func (s memoryStore) participants(groupID uint) (int64, error) {
+	return int64(len(s.r.participants[groupID])), nil
+}
+
+func (s memoryStore) isParticipant(groupID, userID uint) (bool, error) {
+	return s.r.joined(groupID, userID) >= 0, nil
+}
+
+func (s memoryStore) addParticipant(groupID, userID uint) error {
+	s.r.participants[groupID] = append(s.r.participants[groupID], userID)
+	return nil
+}
+
+func (s memoryStore) removeParticipant(groupID, userID uint) (bool, error) {
+	i := s.r.joined(groupID, userID)
+	if i < 0 {
+		return false, nil
+	}
+	participants := s.r.participants[groupID]
+	s.r.participants[groupID] = append(participants[:i:i], participants[i+1:]...)
+
+	return true, nil
+}
+
+func (s memoryStore) startSession(group *Group) error {
+	s.r.sessions[group.ID] = GroupSession{
+		Model:            gorm.Model{ID: group.ID, CreatedAt: group.CreatedAt, UpdatedAt: group.CreatedAt},
+		GroupID:          group.ID,
+		AdminID:          group.AdminID,
+		AdminUsername:    group.AdminUsername,
+		Type:             group.Type,
+		StartedAt:        group.CreatedAt,
+		ParticipantCount: 1,
+	}
+	s.r.attendees[group.ID] = []uint{group.AdminID}
+
+	return nil
+}
+
+func (s memoryStore) joinSession(groupID, userID uint) error {
+	if s.r.attended(groupID, userID) {
+		return nil
+	}
+
+	s.r.attendees[groupID] = append(s.r.attendees[groupID], userID)
+	session := s.r.sessions[groupID]
+	session.ParticipantCount++
+	s.r.sessions[groupID] = session
+
+	return nil
+}
+
+func (s memoryStore) endSession(groupID uint, endedAt time.Time, timeUsed int64) error {
+	session := s.r.sessions[groupID]
+	session.EndedAt = &endedAt
+	session.Duration = endedAt.Sub(session.StartedAt).Milliseconds()
+	session.TimeUsed = timeUsed
+	s.r.sessions[groupID] = session
+
+	return nil
+}
+
+func (s memoryStore) pool(userID uint) (int64, error) {
+	return s.r.pool(userID), nil
+}
+
+func (s memoryStore) draw(userID uint, used int64, reference string) (int64, error) {
+	return s.r.draw(userID, used), nil
+}
+
+func (s memoryStore) consume(u *user.User, used int64, reference string) error {
+	return s.r.users.Consume(u, used, reference)
+}
+
+var memorySessionColumns = map[string]func(a, b GroupSession) int{
+	"id":         func(a, b GroupSession) int { return compareInt(int64(a.ID), int64(b.ID)) },
+	"started_at": func(a, b GroupSession) int { return compareInt(a.StartedAt.UnixNano(), b.StartedAt.UnixNano()) },
+	"duration":   func(a, b GroupSession) int { return compareInt(a.Duration, b.Duration) },
+	"time_used":  func(a, b GroupSession) int { return compareInt(a.TimeUsed, b.TimeUsed) },
+}
+
+// History filters, sorts and pages group sessions the user administered or joined
+func (r *MemoryRepository) History(filter HistoryFilter, page pagination.Page, order string) ([]GroupSession, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sessions := []GroupSession{}
+	for groupID, session := range r.sessions {
+		if session.AdminID != filter.UserID && !r.attended(groupID, filter.UserID) {
+			continue
+		}
+		if filter.Type != "" && session.Type != filter.Type {
+			continue
+		}
+		if filter.From != nil && session.StartedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && session.StartedAt.After(*filter.To) {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		for _, term := range strings.Split(order, ",") {
+			fields := strings.Fields(term)
+			compare, ok := memorySessionColumns[fields[0]]
+			if !ok {
+				continue
+			}
+			if c := compare(sessions[i], sessions[j]); c != 0 {
+				return (c < 0) != (len(fields) > 1 && fields[1] == "desc")
+			}
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+
+	total := int64(len(sessions))
+	if page.Offset >= len(sessions) {
+		return []GroupSession{}, total, nil
+	}
+	sessions = sessions[page.Offset:]
+	if len(sessions) > page.Limit {
+		sessions = sessions[:page.Limit]
+	}
+
+	return sessions, total, nil
+}
+
+// Usage sums up the time used by an admin in ended sessions started from start until end
+func (r *MemoryRepository) Usage(adminID uint, start, end time.Time) (UsageSummary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage := UsageSummary{Days: []UsageByDay{}, Types: []UsageByType{}}
+	days := map[string]*UsageTotal{}
+	types := map[string]*UsageTotal{}
+	add := func(total *UsageTotal, session GroupSession) {
+		total.TimeUsed += session.TimeUsed
+		total.Duration += session.Duration
+		total.Sessions++
+	}
+
+	for _, session := range r.sessions {
+		if session.AdminID != adminID || session.EndedAt == nil || session.StartedAt.Before(start) || !session.StartedAt.Before(end) {
+			continue
+		}
+		add(&usage.UsageTotal, session)
+
+		date := session.StartedAt.UTC().Format("2006-01-02")
+		if days[date] == nil {
+			days[date] = new(UsageTotal)
+		}
+		add(days[date], session)
+
+		if types[session.Type] == nil {
+			types[session.Type] = new(UsageTotal)
+		}
+		add(types[session.Type], session)
+	}
+
+	for date, total := range days {
+		usage.Days = append(usage.Days, UsageByDay{Date: date, UsageTotal: *total})
+	}
+	sort.Slice(usage.Days, func(i, j int) bool { return usage.Days[i].Date < usage.Days[j].Date })
+
+	for groupType, total := range types {
+		usage.Types = append(usage.Types, UsageByType{Type: groupType, UsageTotal: *total})
+	}
+	sort.Slice(usage.Types, func(i, j int) bool { return usage.Types[i].Type < usage.Types[j].Type })
+
+	return usage, nil
+}
@@ -0,0 +1,201 @@
+package group
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/metrics"
+	"github.com/dinopuguh/mycap-backend/services/user"
+)
+
+var (
+	// ErrGroupNotFound is returned when a group doesn't exist or already ended
+	ErrGroupNotFound = apperror.NotFound("group_not_found", "Group not found.")
+	// ErrAlreadyHasGroup is returned when an admin creates a second active group
+	ErrAlreadyHasGroup = apperror.Invalid("group_already_exists", "You are already has a group chat or conference.")
+	// ErrReachedTimeLimit is returned when an admin without remaining time creates a group
+	ErrReachedTimeLimit = apperror.Invalid("time_limit_reached", "This user already reached time limit this month.")
+	// ErrAlreadyJoined is returned when an user joins a group twice
+	ErrAlreadyJoined = apperror.Conflict("group_already_joined", "You already joined this group.")
+	// ErrGroupFull is returned when a group reached maximum participants of its admin's plan
+	ErrGroupFull = apperror.QuotaExceeded("group_full", "Group is full.")
+	// ErrNotParticipant is returned when an user leaves a group it didn't join
+	ErrNotParticipant = apperror.Invalid("group_not_participant", "You are not a participant of this group.")
+)
+
+// store is the storage group operations run on, the business rules of operations are shared so
+// database and memory repositories differ only by their store. Methods of the store passed to the
+// function run by atomic are part of one transaction and hold the locks they take until it ends.
+type store interface {
+	atomic(fn func(tx store) error) error
+	// lockUser loads an user into u and locks it
+	lockUser(u *user.User, id uint) error
+	// lockGroup locks an active group, ErrGroupNotFound when it doesn't exist or already ended
+	lockGroup(groupID uint) error
+	// activeGroups counts the active groups of an admin
+	activeGroups(adminID uint) (int64, error)
+	// insertGroup stores a group, ErrAlreadyHasGroup when its admin has another active group
+	insertGroup(group *Group) error
+	// deleteGroup ends a group and removes all its participants
+	deleteGroup(groupID uint) error
+	participants(groupID uint) (int64, error)
+	isParticipant(groupID, userID uint) (bool, error)
+	addParticipant(groupID, userID uint) error
+	// removeParticipant returns false when the user isn't a participant
+	removeParticipant(groupID, userID uint) (bool, error)
+	// startSession records the start of a group session with its admin as the first participant
+	startSession(group *Group) error
+	// joinSession records an user as a participant of a group session, rejoining doesn't count twice
+	joinSession(groupID, userID uint) error
+	// endSession records the end of a group session and the time used by its admin
+	endSession(groupID uint, endedAt time.Time, timeUsed int64) error
+	// pool returns the pooled time of the organization an user is a member of
+	pool(userID uint) (int64, error)
+	// draw takes time used from the pool of the user's organization, returns the amount drawn
+	draw(userID uint, used int64, reference string) (int64, error)
+	// consume deducts time used from the user's allowance, then its bonus time
+	consume(u *user.User, used int64, reference string) error
+}
+
+// create stores a group with its admin as the first participant, the admin is locked so
+// concurrent creates by the same admin can't both pass the active group check
+func create(s store, group *Group) error {
+	return s.atomic(func(tx store) error {
+		var admin user.User
+		if err := tx.lockUser(&admin, group.AdminID); err != nil {
+			return err
+		}
+
+		if admin.ReachedTimeLimit {
+			pooledTime, err := tx.pool(admin.ID)
+			if err != nil {
+				return err
+			}
+			if pooledTime <= 0 {
+				return ErrReachedTimeLimit
+			}
+		}
+
+		existing, err := tx.activeGroups(group.AdminID)
+		if err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrAlreadyHasGroup
+		}
+
+		if err := tx.insertGroup(group); err != nil {
+			return err
+		}
+		if err := tx.addParticipant(group.ID, group.AdminID); err != nil {
+			return err
+		}
+
+		return tx.startSession(group)
+	})
+}
+
+// join adds an user to group participants, the group is locked so concurrent joins
+// can't exceed the participant limit
+func join(s store, group *Group, joiningUser *user.User) error {
+	return s.atomic(func(tx store) error {
+		if err := tx.lockGroup(group.ID); err != nil {
+			return err
+		}
+
+		joined, err := tx.isParticipant(group.ID, joiningUser.ID)
+		if err != nil {
+			return err
+		}
+		if joined {
+			return ErrAlreadyJoined
+		}
+
+		participants, err := tx.participants(group.ID)
+		if err != nil {
+			return err
+		}
+		if limit := group.ParticipantLimit(); limit > 0 && participants >= limit {
+			return ErrGroupFull
+		}
+
+		if err := tx.addParticipant(group.ID, joiningUser.ID); err != nil {
+			return err
+		}
+
+		return tx.joinSession(group.ID, joiningUser.ID)
+	})
+}
+
+// leave removes a participant from the group
+func leave(s store, group *Group, leavingUser *user.User) error {
+	return s.atomic(func(tx store) error {
+		if err := tx.lockGroup(group.ID); err != nil {
+			return err
+		}
+
+		left, err := tx.removeParticipant(group.ID, leavingUser.ID)
+		if err != nil {
+			return err
+		}
+		if !left {
+			return ErrNotParticipant
+		}
+
+		return nil
+	})
+}
+
+// timeUsed returns the time used by a group session from the remaining allowance reported by its admin,
+// the elapsed time is used once the allowance ran out
+func timeUsed(group *Group, admin *user.User, remainingTime int64, now time.Time) int64 {
+	used := admin.RemainingTime - remainingTime
+	if elapsed := now.Sub(group.CreatedAt).Milliseconds(); remainingTime <= 0 && elapsed > used {
+		used = elapsed
+	}
+
+	return used
+}
+
+// end draws the time used from the admin's organization pool, then its allowance and bonus time, removes all participants,
+// ends the group and records the time used in the group session history. The admin reports the
+// remaining monthly allowance, once it ran out the time used beyond it is measured by the server.
+func end(s store, group *Group, admin *user.User, remainingTime int64) error {
+	var used int64
+	err := s.atomic(func(tx store) error {
+		if err := tx.lockGroup(group.ID); err != nil {
+			return err
+		}
+		if err := tx.lockUser(admin, admin.ID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		used = timeUsed(group, admin, remainingTime, now)
+
+		recorded := used
+		if recorded < 0 {
+			recorded = 0
+		}
+		if err := tx.endSession(group.ID, now, recorded); err != nil {
+			return err
+		}
+		reference := fmt.Sprintf("group:%d", group.ID)
+		drawn, err := tx.draw(admin.ID, used, reference)
+		if err != nil {
+			return err
+		}
+		if err := tx.consume(admin, used-drawn, reference); err != nil {
+			return err
+		}
+
+		return tx.deleteGroup(group.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	metrics.ConsumeMinutes(group.Type, used)
+	return nil
+}
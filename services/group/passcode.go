@@ -0,0 +1,36 @@
+package group
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/ratelimit"
+)
+
+const (
+	// maxPasscodeAttempts is the number of passcodes an user can try on a group within the window
+	maxPasscodeAttempts = 5
+	passcodeWindow      = 15 * time.Minute
+)
+
+// passcodeLimiter counts passcode attempts per user and group to prevent guessing, attempts are
+// counted in a rate limit store so a store shared by instances of the app limits all of them
+type passcodeLimiter struct {
+	store ratelimit.Store
+}
+
+func passcodeKey(groupID, userID uint) string {
+	return fmt.Sprintf("passcode:%d:%d", groupID, userID)
+}
+
+// attempt counts an attempt of the user on the group and reports whether it's over the limit and
+// when the user can try again. Checking and counting is a single increment of the store so
+// concurrent attempts can't exceed the limit.
+func (l passcodeLimiter) attempt(groupID, userID uint) (time.Duration, bool, error) {
+	count, reset, err := l.store.Increment(passcodeKey(groupID, userID), passcodeWindow)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return time.Until(reset), count > maxPasscodeAttempts, nil
+}
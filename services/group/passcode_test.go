@@ -0,0 +1,39 @@
+package group
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasscodeLimiter(t *testing.T) {
+	limiter := passcodeLimiter{store: ratelimit.NewMemoryStore()}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 2*maxPasscodeAttempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, blocked, err := limiter.attempt(1, 2)
+			assert.NoError(t, err)
+			if !blocked {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, maxPasscodeAttempts, allowed, "concurrent attempts don't exceed the limit")
+
+	retryAfter, blocked, _ := limiter.attempt(1, 2)
+	assert.True(t, blocked)
+	assert.True(t, retryAfter > 0)
+
+	_, blocked, _ = limiter.attempt(1, 3)
+	assert.False(t, blocked, "other users are not blocked")
+}
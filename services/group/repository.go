@@ -0,0 +1,315 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/pagination"
+	"github.com/dinopuguh/mycap-backend/services/organization"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Filter narrows down groups listed by a repository
+type Filter struct {
+	ViewerID      uint // groups not public are only listed to their admin and participants
+	Type          string
+	AdminUsername string
+	Status        string // StatusActive, StatusEnded or "all"
+}
+
+// HistoryFilter narrows down group sessions listed by a repository
+type HistoryFilter struct {
+	UserID uint // sessions the user administered or joined
+	Type   string
+	From   *time.Time
+	To     *time.Time
+}
+
+// Repository stores groups and runs group operations atomically, operations return the
+// errors of this package such as ErrGroupFull
+type Repository interface {
+	List(filter Filter, page pagination.Page, order string) ([]GroupSummary, int64, error)
+	// FindByAdminUsername returns the active group of an admin with the admin preloaded,
+	// ErrGroupNotFound when the admin has no active group
+	FindByAdminUsername(username string) (*Group, error)
+	// FindByID returns an active group with the admin and participants preloaded
+	FindByID(id uint) (*Group, error)
+	Create(group *Group) error
+	Join(group *Group, joiningUser *user.User) error
+	Leave(group *Group, leavingUser *user.User) error
+	// End ends the group and deducts the time used from its admin, remainingTime is
+	// the monthly allowance left reported by the admin
+	End(group *Group, admin *user.User, remainingTime int64) error
+	// History lists group sessions, order is an order clause made by pagination.Sort
+	History(filter HistoryFilter, page pagination.Page, order string) ([]GroupSession, int64, error)
+	// Usage sums up the time used by an admin in ended sessions started from start until end,
+	// by day and by group type
+	Usage(adminID uint, start, end time.Time) (UsageSummary, error)
+	// WithContext returns the repository running queries with a context such as logger.Context
+	WithContext(ctx context.Context) Repository
+}
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a group repository backed by database
+func NewRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) WithContext(ctx context.Context) Repository {
+	return &gormRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *gormRepository) List(filter Filter, page pagination.Page, order string) ([]GroupSummary, int64, error) {
+	filters := []func(*gorm.DB) *gorm.DB{
+		func(db *gorm.DB) *gorm.DB {
+			return db.Where("groups.visibility = ? OR groups.admin_id = ? OR EXISTS (SELECT 1 FROM group_participants gp WHERE gp.group_id = groups.id AND gp.user_id = ?)",
+				VisibilityPublic, filter.ViewerID, filter.ViewerID)
+		},
+	}
+	where := func(query string, args ...interface{}) {
+		filters = append(filters, func(db *gorm.DB) *gorm.DB {
+			return db.Where(query, args...)
+		})
+	}
+
+	if filter.Type != "" {
+		where("groups.type = ?", filter.Type)
+	}
+	if filter.AdminUsername != "" {
+		where("groups.admin_username = ?", filter.AdminUsername)
+	}
+
+	switch filter.Status {
+	case StatusEnded:
+		filters = append(filters, func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped().Where("groups.deleted_at IS NOT NULL")
+		})
+	case "all":
+		filters = append(filters, func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		})
+	}
+
+	var total int64
+	if err := r.db.Model(&Group{}).Scopes(filters...).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var groups []GroupSummary
+	if err := r.db.Model(&Group{}).Scopes(filters...).Scopes(page.Scope).
+		Select("groups.*, (SELECT COUNT(*) FROM group_participants gp WHERE gp.group_id = groups.id) AS participant_count").
+		Order(order).Find(&groups).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return groups, total, nil
+}
+
+func (r *gormRepository) FindByAdminUsername(username string) (*Group, error) {
+	var group = new(Group)
+	if res := r.db.Preload("Admin").Preload("Admin.Type").Where("admin_username = ?", username).Limit(1).Find(group); res.Error != nil {
+		return nil, res.Error
+	} else if res.RowsAffected == 0 {
+		return nil, ErrGroupNotFound
+	}
+
+	return group, nil
+}
+
+func (r *gormRepository) FindByID(id uint) (*Group, error) {
+	var group = new(Group)
+	if res := r.db.Preload("Admin").Preload("Admin.Type").Preload("Participants").Preload("Participants.Type").Limit(1).Find(group, id); res.Error != nil {
+		return nil, res.Error
+	} else if res.RowsAffected == 0 {
+		return nil, ErrGroupNotFound
+	}
+
+	return group, nil
+}
+
+func (r *gormRepository) Create(group *Group) error {
+	return create(gormStore{db: r.db}, group)
+}
+
+func (r *gormRepository) Join(group *Group, joiningUser *user.User) error {
+	return join(gormStore{db: r.db}, group, joiningUser)
+}
+
+func (r *gormRepository) Leave(group *Group, leavingUser *user.User) error {
+	return leave(gormStore{db: r.db}, group, leavingUser)
+}
+
+func (r *gormRepository) End(group *Group, admin *user.User, remainingTime int64) error {
+	if err := end(gormStore{db: r.db}, group, admin, remainingTime); err != nil {
+		return err
+	}
+
+	if err := notifyLowBalance(r.db, admin, admin.RemainingTime); err != nil {
+		logger.FromContext(r.db.Statement.Context).Error().Err(err).Uint("user_id", admin.ID).Msg("Notify low balance failed.")
+	}
+
+	return nil
+}
+
+// gormStore runs group operations on database, rows locked by lockUser and lockGroup stay locked
+// until the transaction of atomic ends
+type gormStore struct {
+	db *gorm.DB
+}
+
+func (s gormStore) atomic(fn func(tx store) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(gormStore{db: tx})
+	})
+}
+
+func (s gormStore) lockUser(u *user.User, id uint) error {
+	return s.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(u, id).Error
+}
+
+func (s gormStore) lockGroup(groupID uint) error {
+	var locked Group
+	if err := s.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, groupID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrGroupNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s gormStore) activeGroups(adminID uint) (int64, error) {
+	var count int64
+	err := s.db.Model(&Group{}).Where("admin_id = ?", adminID).Count(&count).Error
+
+	return count, err
+}
+
+func (s gormStore) insertGroup(group *Group) error {
+	if err := s.db.Omit(clause.Associations).Create(group).Error; err != nil {
+		if strings.Contains(err.Error(), "idx_groups_active_admin") {
+			return ErrAlreadyHasGroup
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s gormStore) deleteGroup(groupID uint) error {
+	if err := s.db.Exec("DELETE FROM group_participants WHERE group_id = ?", groupID).Error; err != nil {
+		return err
+	}
+
+	return s.db.Delete(&Group{}, groupID).Error
+}
+
+func (s gormStore) participants(groupID uint) (int64, error) {
+	var count int64
+	err := s.db.Table("group_participants").Where("group_id = ?", groupID).Count(&count).Error
+
+	return count, err
+}
+
+func (s gormStore) isParticipant(groupID, userID uint) (bool, error) {
+	var count int64
+	err := s.db.Table("group_participants").Where("group_id = ? AND user_id = ?", groupID, userID).Count(&count).Error
+
+	return count > 0, err
+}
+
+func (s gormStore) addParticipant(groupID, userID uint) error {
+	return s.db.Exec("INSERT INTO group_participants (group_id, user_id) VALUES (?, ?)", groupID, userID).Error
+}
+
+func (s gormStore) removeParticipant(groupID, userID uint) (bool, error) {
+	res := s.db.Exec("DELETE FROM group_participants WHERE group_id = ? AND user_id = ?", groupID, userID)
+
+	return res.RowsAffected > 0, res.Error
+}
+
+func (s gormStore) pool(userID uint) (int64, error) {
+	return organization.Pool(s.db, userID)
+}
+
+func (s gormStore) draw(userID uint, used int64, reference string) (int64, error) {
+	return organization.Draw(s.db, userID, used, reference)
+}
+
+func (s gormStore) consume(u *user.User, used int64, reference string) error {
+	return user.Consume(s.db, u, used, reference)
+}
+
+func (r *gormRepository) History(filter HistoryFilter, page pagination.Page, order string) ([]GroupSession, int64, error) {
+	filters := []func(*gorm.DB) *gorm.DB{
+		func(db *gorm.DB) *gorm.DB {
+			return db.Where("admin_id = ? OR EXISTS (SELECT 1 FROM group_session_participants gsp WHERE gsp.group_session_id = group_sessions.id AND gsp.user_id = ?)",
+				filter.UserID, filter.UserID)
+		},
+	}
+	where := func(query string, args ...interface{}) {
+		filters = append(filters, func(db *gorm.DB) *gorm.DB {
+			return db.Where(query, args...)
+		})
+	}
+
+	if filter.Type != "" {
+		where("type = ?", filter.Type)
+	}
+	if filter.From != nil {
+		where("started_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		where("started_at <= ?", *filter.To)
+	}
+
+	var total int64
+	if err := r.db.Model(&GroupSession{}).Scopes(filters...).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var sessions []GroupSession
+	if err := r.db.Scopes(filters...).Scopes(page.Scope).Order(order).Find(&sessions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return sessions, total, nil
+}
+
+func (r *gormRepository) Usage(adminID uint, start, end time.Time) (UsageSummary, error) {
+	monthly := func(db *gorm.DB) *gorm.DB {
+		return db.Model(&GroupSession{}).
+			Where("admin_id = ? AND ended_at IS NOT NULL AND started_at >= ? AND started_at < ?", adminID, start, end)
+	}
+	const totals = "COALESCE(SUM(time_used), 0) AS time_used, COALESCE(SUM(duration), 0) AS duration, COUNT(*) AS sessions"
+
+	var usage UsageSummary
+	if err := r.db.Scopes(monthly).Select(totals).Find(&usage.UsageTotal).Error; err != nil {
+		return usage, err
+	}
+
+	usage.Days = []UsageByDay{}
+	if err := r.db.Scopes(monthly).
+		Select("TO_CHAR(started_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS date, " + totals).
+		Group("date").Order("date").Find(&usage.Days).Error; err != nil {
+		return usage, err
+	}
+
+	usage.Types = []UsageByType{}
+	if err := r.db.Scopes(monthly).
+		Select("type, " + totals).
+		Group("type").Order("type").Find(&usage.Types).Error; err != nil {
+		return usage, err
+	}
+
+	return usage, nil
+}
@@ -0,0 +1,88 @@
+package notification
+
+import "sync"
+
+// Hub delivers notifications to live connections of users
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[uint]map[chan Notification]struct{}
+	closed      bool
+}
+
+// Live is the hub used by services to deliver notifications in real time
+var Live = NewHub()
+
+// NewHub creates an empty hub
+func NewHub() *Hub {
+	return &Hub{subscribers: map[uint]map[chan Notification]struct{}{}}
+}
+
+// Subscribe registers a live connection of an user, the returned function unsubscribes it
+func (h *Hub) Subscribe(userID uint) (<-chan Notification, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan Notification, 16)
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = map[chan Notification]struct{}{}
+	}
+	h.subscribers[userID][ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			delete(h.subscribers[userID], ch)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+		})
+	}
+}
+
+// Publish delivers a notification to all live connections of an user, slow connections miss it
+func (h *Hub) Publish(userID uint, n Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[userID] {
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+}
+
+// Close ends all live connections by closing their channels, connections subscribing
+// afterwards end immediately
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for userID, chs := range h.subscribers {
+		for ch := range chs {
+			close(ch)
+		}
+		delete(h.subscribers, userID)
+	}
+}
+
+// Connections returns the number of live connections
+func (h *Hub) Connections() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var count int
+	for _, chs := range h.subscribers {
+		count += len(chs)
+	}
+
+	return count
+}
@@ -0,0 +1,69 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHub(t *testing.T) {
+	hub := NewHub()
+
+	events, unsubscribe := hub.Subscribe(1)
+	other, unsubscribeOther := hub.Subscribe(2)
+	defer unsubscribeOther()
+	assert.Equal(t, 2, hub.Connections())
+
+	hub.Publish(1, Notification{Kind: KindTimeWarning})
+	select {
+	case n := <-events:
+		assert.Equal(t, KindTimeWarning, n.Kind)
+	default:
+		t.Fatal("notification not delivered")
+	}
+
+	select {
+	case <-other:
+		t.Fatal("notification delivered to other user")
+	default:
+	}
+
+	unsubscribe()
+	unsubscribe()
+	assert.Equal(t, 1, hub.Connections())
+
+	hub.Publish(1, Notification{Kind: KindTimeWarning})
+	assert.Len(t, events, 0)
+}
+
+func TestHubSlowConnection(t *testing.T) {
+	hub := NewHub()
+
+	events, unsubscribe := hub.Subscribe(1)
+	defer unsubscribe()
+
+	for i := 0; i < cap(events)+5; i++ {
+		hub.Publish(1, Notification{Kind: KindLowBalance})
+	}
+	assert.Len(t, events, cap(events))
+}
+
+func TestHubClose(t *testing.T) {
+	hub := NewHub()
+
+	events, unsubscribe := hub.Subscribe(1)
+	hub.Close()
+	assert.Equal(t, 0, hub.Connections())
+
+	_, ok := <-events
+	assert.False(t, ok)
+	unsubscribe()
+
+	hub.Publish(1, Notification{Kind: KindTimeWarning})
+
+	late, unsubscribeLate := hub.Subscribe(1)
+	defer unsubscribeLate()
+	_, ok = <-late
+	assert.False(t, ok)
+	assert.Equal(t, 0, hub.Connections())
+}
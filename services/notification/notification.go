@@ -0,0 +1,220 @@
+package notification
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/mailer"
+	"github.com/dinopuguh/mycap-backend/pagination"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Notification is a model for in-app notification of an user
+type Notification struct {
+	gorm.Model
+	UserID  uint       `json:"user_id" gorm:"index;"`
+	Kind    string     `json:"kind"`
+	Title   string     `json:"title"`
+	Message string     `json:"message"`
+	ReadAt  *time.Time `json:"read_at"`
+}
+
+const (
+	// KindLowBalance is a notification for remaining time crossing a threshold of the monthly allowance
+	KindLowBalance = "low_balance"
+	// KindTimeWarning is a live warning for the group admin when remaining time runs out during a session
+	KindTimeWarning = "time_warning"
+	// KindSessionEnded is a notification for a group session ended because remaining time ran out
+	KindSessionEnded = "session_ended"
+)
+
+// streamPing is the interval of keep-alive comments sent to live connections
+const streamPing = 25 * time.Second
+
+// Send saves an in-app notification, delivers it to live connections of the user and optionally emails it
+func Send(db *gorm.DB, recipient user.User, kind, title, message string, email bool) error {
+	n := Notification{
+		UserID:  recipient.ID,
+		Kind:    kind,
+		Title:   title,
+		Message: message,
+	}
+	if err := db.Create(&n).Error; err != nil {
+		return err
+	}
+
+	Live.Publish(recipient.ID, n)
+
+	if email && recipient.Email != "" {
+		return mailer.Send(db.Statement.Context, recipient.Email, title, message)
+	}
+
+	return nil
+}
+
+// GetAll is a function to get notifications of the current user with pagination
+// @Summary Get all notifications
+// @Description Get notifications of the current user, newest first
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param unread query bool false "Only unread notifications"
+// @Param limit query int false "Page size"
+// @Param cursor query string false "Next page cursor"
+// @Success 200 {object} response.HTTP{data=pagination.Response{items=[]Notification}}
+// @Security ApiKeyAuth
+// @Router /v1/notifications [get]
+func GetAll(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	page, err := pagination.Parse(c)
+	if err != nil {
+		return err
+	}
+
+	currentUser, err := user.Current(c, user.NewRepository(db))
+	if err != nil {
+		return err
+	}
+
+	filters := []func(*gorm.DB) *gorm.DB{
+		func(db *gorm.DB) *gorm.DB {
+			return db.Where("user_id = ?", currentUser.ID)
+		},
+	}
+
+	if unread := c.Query("unread"); unread != "" {
+		onlyUnread, err := strconv.ParseBool(unread)
+		if err != nil {
+			return apperror.Invalid("unread_invalid", "Unread invalid.")
+		}
+		if onlyUnread {
+			filters = append(filters, func(db *gorm.DB) *gorm.DB {
+				return db.Where("read_at IS NULL")
+			})
+		}
+	}
+
+	var total int64
+	if res := db.Model(&Notification{}).Scopes(filters...).Count(&total); res.Error != nil {
+		return res.Error
+	}
+
+	var notifications []Notification
+	if res := db.Scopes(filters...).Scopes(page.Scope).Order("id desc").Find(&notifications); res.Error != nil {
+		return res.Error
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    page.Response(notifications, len(notifications), total),
+		Status:  http.StatusOK,
+		Message: "Success get all notifications.",
+	})
+}
+
+// Read function marks a notification of the current user as read
+// @Summary Mark notification as read
+// @Description Mark notification as read by ID
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param id path int true "Notification ID"
+// @Success 200 {object} response.HTTP{data=Notification}
+// @Security ApiKeyAuth
+// @Router /v1/notifications/{id}/read [put]
+func Read(c *fiber.Ctx) error {
+	id := c.Params("id")
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	currentUser, err := user.Current(c, user.NewRepository(db))
+	if err != nil {
+		return err
+	}
+
+	var notification Notification
+	if err := db.Where("user_id = ?", currentUser.ID).First(&notification, id).Error; err != nil {
+		if apperror.IsNotFound(err) {
+			return apperror.NotFound("notification_not_found", "Notification with ID %v not found.", id)
+		}
+		return err
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		if err := db.Model(&notification).Update("read_at", now).Error; err != nil {
+			return err
+		}
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    notification,
+		Status:  http.StatusOK,
+		Message: "Success read notification.",
+	})
+}
+
+// Stream function sends notifications of the current user as server-sent events while the connection is open
+// @Summary Stream notifications
+// @Description Stream live notifications and group time warnings of the current user as server-sent events
+// @Tags notifications
+// @Produce text/event-stream
+// @Success 200 {object} Notification
+// @Security ApiKeyAuth
+// @Router /v1/notifications/stream [get]
+func Stream(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	currentUser, err := user.Current(c, user.NewRepository(db))
+	if err != nil {
+		return err
+	}
+
+	events, unsubscribe := Live.Subscribe(currentUser.ID)
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		ping := time.NewTicker(streamPing)
+		defer ping.Stop()
+
+		fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case n, ok := <-events:
+				if !ok {
+					return
+				}
+				data, _ := json.Marshal(n)
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", n.Kind, data)
+			case <-ping.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
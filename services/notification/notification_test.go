@@ -0,0 +1,115 @@
+package notification_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/database/databasetest"
+	"github.com/dinopuguh/mycap-backend/mailer"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/routes"
+	"github.com/dinopuguh/mycap-backend/services/notification"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifications(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	app := routes.New(cfg)
+	recorder := new(mailer.Recorder)
+	mailer.Default = recorder
+
+	request := func(method, endpoint, token string, data interface{}) (*response.HTTP, string) {
+		reqBody, _ := json.Marshal(data)
+		req, _ := http.NewRequest(method, endpoint, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resHTTP := new(response.HTTP)
+		res, _ := app.Test(req, -1)
+		defer res.Body.Close()
+		resBody, _ := ioutil.ReadAll(res.Body)
+		json.Unmarshal(resBody, &resHTTP)
+
+		return resHTTP, string(resBody)
+	}
+
+	resHTTP, _ := request(http.MethodPost, "/api/v1/register", "", user.RegisterUser{
+		Name:     "Dino Notified",
+		Email:    "dinonotified@mycap.com",
+		Username: "dinonotified",
+		Password: "s3cr3tp45sw0rd",
+	})
+	auth := new(user.ResponseAuth)
+	authJSON, _ := json.Marshal(resHTTP.Data)
+	json.Unmarshal(authJSON, &auth)
+	defer request(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", auth.User.ID), auth.AccessToken, nil)
+
+	recipient := user.User{Email: auth.User.Email}
+	recipient.ID = auth.User.ID
+
+	events, unsubscribe := notification.Live.Subscribe(recipient.ID)
+	defer unsubscribe()
+
+	err := notification.Send(database.DBConn, recipient, notification.KindLowBalance, "Low balance", "80% used.", true)
+	assert.NoError(t, err)
+	err = notification.Send(database.DBConn, recipient, notification.KindTimeWarning, "Time warning", "5 minutes left.", false)
+	assert.NoError(t, err)
+
+	assert.Len(t, events, 2)
+	message, sent := recorder.Last(recipient.Email)
+	assert.True(t, sent)
+	assert.Equal(t, "Low balance", message.Subject)
+
+	list := func(query string) []notification.Notification {
+		resHTTP, resBody := request(http.MethodGet, "/api/v1/notifications"+query, auth.AccessToken, nil)
+		assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+		var page struct {
+			Items []notification.Notification `json:"items"`
+		}
+		pageJSON, _ := json.Marshal(resHTTP.Data)
+		json.Unmarshal(pageJSON, &page)
+
+		return page.Items
+	}
+
+	notifications := list("")
+	if assert.Len(t, notifications, 2) {
+		assert.Equal(t, notification.KindTimeWarning, notifications[0].Kind)
+	}
+
+	type args struct {
+		id         uint
+		statusCode int
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{"Notification not found", args{
+			id:         0,
+			statusCode: http.StatusNotFound,
+		}},
+		{"Valid read notification", args{
+			id:         notifications[0].ID,
+			statusCode: http.StatusOK,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resHTTP, resBody := request(http.MethodPut, fmt.Sprintf("/api/v1/notifications/%d/read", tt.args.id), auth.AccessToken, nil)
+			assert.Equalf(t, tt.args.statusCode, resHTTP.Status, resBody)
+		})
+	}
+
+	assert.Len(t, list("?unread=true"), 1)
+}
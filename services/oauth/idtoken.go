@@ -0,0 +1,142 @@
+package oauth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// JWK is a public RSA key of the provider's JSON Web Key Set
+type JWK struct {
+	KeyID   string `json:"kid"`
+	KeyType string `json:"kty"`
+	Use     string `json:"use,omitempty"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+}
+
+// JWKS is the JSON Web Key Set published by the provider's jwks_uri
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK encodes a public RSA key identified by keyID
+func NewJWK(keyID string, key *rsa.PublicKey) JWK {
+	return JWK{
+		KeyID:   keyID,
+		KeyType: "RSA",
+		Use:     "sig",
+		N:       base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// keys fetches the signing keys of the provider by key ID
+func (p Provider) keys(discovery *Discovery) (map[string]*rsa.PublicKey, error) {
+	res, err := httpClient.Get(discovery.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to get keys of provider %s", p.Name)
+	}
+
+	jwks := new(JWKS)
+	if err := json.NewDecoder(res.Body).Decode(jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+
+		keys[jwk.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+// verifyIDToken checks the ID token is signed by a key of the provider, issued by the provider
+// for this client and bound to the nonce of the authorization request, and returns its subject
+func (p Provider) verifyIDToken(discovery *Discovery, idToken, nonce string) (string, error) {
+	keys, err := p.keys(discovery)
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("Signing method %v not supported", token.Header["alg"])
+		}
+
+		keyID, _ := token.Header["kid"].(string)
+		key, ok := keys[keyID]
+		if !ok {
+			return nil, fmt.Errorf("Signing key %s not found", keyID)
+		}
+
+		return key, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("ID token invalid: %v", err)
+	}
+
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return "", fmt.Errorf("ID token expired")
+	}
+
+	if !claims.VerifyIssuer(discovery.Issuer, true) {
+		return "", fmt.Errorf("ID token issuer invalid")
+	}
+
+	if !hasAudience(claims["aud"], p.ClientID) {
+		return "", fmt.Errorf("ID token audience invalid")
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); nonce == "" || tokenNonce != nonce {
+		return "", fmt.Errorf("ID token nonce invalid")
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return "", fmt.Errorf("ID token subject not specified")
+	}
+
+	return subject, nil
+}
+
+// hasAudience reports whether the aud claim, a string or a list of strings, contains the client ID
+func hasAudience(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
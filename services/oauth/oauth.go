@@ -0,0 +1,377 @@
+package oauth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/auth"
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/helpers"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/services/session"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/dinopuguh/mycap-backend/validation"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Identity is a model for user's linked identity provider account
+type Identity struct {
+	gorm.Model
+	UserID   uint      `json:"user_id"`
+	User     user.User `json:"-"`
+	Provider string    `json:"provider" gorm:"uniqueIndex:idx_identity_provider_subject;"`
+	Subject  string    `json:"subject" gorm:"uniqueIndex:idx_identity_provider_subject;"`
+	Email    string    `json:"email"`
+}
+
+// State is a model for pending authorization request
+type State struct {
+	gorm.Model
+	Provider     string `gorm:"index;"`
+	State        string `gorm:"uniqueIndex;"`
+	Nonce        string
+	CodeVerifier string
+	UserID       uint // signed in user who re-entered the password to link the identity, 0 to sign in
+	ExpiresAt    time.Time
+}
+
+// ResponseAuthorize represents response body for authorization request
+type ResponseAuthorize struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+// LinkIdentity is a data transfer object for linking an identity provider to the current user
+type LinkIdentity struct {
+	Password string `json:"password" validate:"required" example:"s3cr3tp45sw0rd"`
+}
+
+const stateLifetime = 10 * time.Minute
+
+// stateCookie ties the state to the browser that started authorization, so a callback with the
+// state of another browser can't sign this browser in
+const stateCookie = "mycap_oauth_state"
+
+var usernameCharset = regexp.MustCompile(`[^a-z0-9_]`)
+
+// Authorize starts authorization code flow with PKCE for an identity provider
+// @Summary Start social login
+// @Description Start OpenID Connect authorization code flow with PKCE, the state is also set to an HttpOnly cookie the callback checks
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param login_hint query string false "Login hint"
+// @Success 200 {object} response.HTTP{data=ResponseAuthorize}
+// @Router /v1/oauth/{provider}/authorize [get]
+func Authorize(c *fiber.Ctx) error {
+	return authorize(c, 0)
+}
+
+// Link starts authorization code flow linking an identity provider to the current user
+// @Summary Link identity provider
+// @Description Start OpenID Connect authorization code flow linking the provider account to the current user, the password of the user is required
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param password body LinkIdentity true "Password of the current user"
+// @Success 200 {object} response.HTTP{data=ResponseAuthorize}
+// @Security ApiKeyAuth
+// @Router /v1/oauth/{provider}/link [post]
+func Link(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	linkIdentity := new(LinkIdentity)
+	if err := c.BodyParser(&linkIdentity); err != nil {
+		return apperror.Invalid("body_invalid", err.Error())
+	}
+
+	if err := validation.Struct(linkIdentity); err != nil {
+		return err
+	}
+
+	current, err := user.Current(c, user.NewRepository(db))
+	if err != nil {
+		return err
+	}
+
+	if !helpers.CheckPasswordHash(linkIdentity.Password, current.Password) {
+		return apperror.Unauthorized("password_incorrect", "Password incorrect.")
+	}
+
+	return authorize(c, current.ID)
+}
+
+// authorize stores a pending authorization request of the user and responds the authorization URL
+func authorize(c *fiber.Ctx, userID uint) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	provider, ok := Providers()[c.Params("provider")]
+	if !ok {
+		return apperror.NotFound("oauth_provider_not_found", "Provider %s not found.", c.Params("provider"))
+	}
+
+	discovery, err := provider.Discover()
+	if err != nil {
+		return apperror.Upstream("oauth_provider_failed", err.Error())
+	}
+
+	state, err := helpers.RandomToken(16)
+	if err != nil {
+		return err
+	}
+
+	nonce, err := helpers.RandomToken(16)
+	if err != nil {
+		return err
+	}
+
+	codeVerifier, err := helpers.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	pending := &State{
+		Provider:     provider.Name,
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		UserID:       userID,
+		ExpiresAt:    time.Now().Add(stateLifetime),
+	}
+	if err := db.Create(pending).Error; err != nil {
+		return err
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     "/api",
+		Expires:  pending.ExpiresAt,
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: "Lax",
+	})
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data: ResponseAuthorize{
+			AuthorizationURL: provider.AuthorizationURL(discovery, state, nonce, codeVerifier, c.Query("login_hint")),
+			State:            state,
+		},
+		Status:  http.StatusOK,
+		Message: "Success start authorization.",
+	})
+}
+
+// Callback finishes authorization code flow and signs user to a session
+// @Summary Finish social login
+// @Description Exchange authorization code, link identity to an user by verified email and login. The state must match the state cookie set when authorization started.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} response.HTTP{data=user.ResponseAuth}
+// @Router /v1/oauth/{provider}/callback [get]
+func Callback(c *fiber.Ctx) error {
+	provider, ok := Providers()[c.Params("provider")]
+	if !ok {
+		return apperror.NotFound("oauth_provider_not_found", "Provider %s not found.", c.Params("provider"))
+	}
+
+	if c.Query("error") != "" {
+		logger.Ctx(c).Info().Str("provider", provider.Name).Str("error", c.Query("error")).Msg("Authorization denied by provider.")
+		return apperror.Unauthorized("oauth_denied", "Authorization denied by provider.")
+	}
+
+	state := c.Query("state")
+	if subtle.ConstantTimeCompare([]byte(c.Cookies(stateCookie)), []byte(state)) != 1 {
+		return apperror.Invalid("oauth_state_invalid", "Authorization state invalid.")
+	}
+	c.ClearCookie(stateCookie)
+
+	db := database.DBConn.WithContext(logger.Context(c))
+	var pending State
+	if err := db.Where("state = ? AND provider = ?", state, provider.Name).First(&pending).Error; err != nil {
+		if apperror.IsNotFound(err) {
+			return apperror.Invalid("oauth_state_invalid", "Authorization state invalid.")
+		}
+		return err
+	}
+	if err := db.Unscoped().Delete(&pending).Error; err != nil {
+		return err
+	}
+
+	if time.Now().After(pending.ExpiresAt) {
+		return apperror.Invalid("oauth_state_expired", "Authorization state expired.")
+	}
+
+	discovery, err := provider.Discover()
+	if err != nil {
+		return apperror.Upstream("oauth_provider_failed", err.Error())
+	}
+
+	userinfo, err := provider.Exchange(discovery, c.Query("code"), pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		return apperror.Unauthorized("oauth_exchange_failed", err.Error())
+	}
+
+	var linkedUser *user.User
+	if pending.UserID != 0 {
+		linkedUser, err = linkTo(db, provider.Name, userinfo, pending.UserID)
+	} else {
+		linkedUser, err = link(db, provider.Name, userinfo)
+	}
+	if err != nil {
+		return err
+	}
+
+	userSession, err := session.New(c, db, linkedUser.ID)
+	if err != nil {
+		return err
+	}
+
+	token, err := auth.GenerateJWT(linkedUser.Name, linkedUser.Email, userSession.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data: user.ResponseAuth{
+			User:        linkedUser.Private(),
+			AccessToken: token,
+		},
+		Status:  http.StatusOK,
+		Message: "Success login.",
+	})
+}
+
+// link finds the user of an identity, links it to an existing user with the same verified email
+// or registers a new user. Users with a password whose email wasn't confirmed must sign in and
+// link the identity with Link, otherwise anyone registering their email first could take over the
+// account.
+func link(db *gorm.DB, provider string, userinfo *Userinfo) (*user.User, error) {
+	var identity Identity
+	err := db.Preload("User").Preload("User.Type").Where("provider = ? AND subject = ?", provider, userinfo.Subject).First(&identity).Error
+	if err == nil {
+		return &identity.User, nil
+	}
+	if !apperror.IsNotFound(err) {
+		return nil, err
+	}
+
+	if userinfo.Email == "" || !userinfo.EmailVerified {
+		return nil, apperror.Forbidden("oauth_email_unverified", "Email from %s is not verified.", provider)
+	}
+
+	linkedUser := new(user.User)
+	err = db.Preload("Type").Where("email = ?", userinfo.Email).First(&linkedUser).Error
+	if err != nil && !apperror.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil && linkedUser.Password != "" && linkedUser.EmailConfirmedAt == nil {
+		return nil, apperror.Conflict("oauth_link_requires_password", "User with email %s already exists, sign in with the password to link %s.", userinfo.Email, provider)
+	}
+	if err != nil {
+		userType := new(user.Type)
+		if err := db.First(&userType, 1).Error; err != nil {
+			return nil, err
+		}
+
+		username, err := availableUsername(db, userinfo.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		linkedUser.Name = userinfo.Name
+		if linkedUser.Name == "" {
+			linkedUser.Name = username
+		}
+		confirmedAt := time.Now()
+		linkedUser.Email = userinfo.Email
+		linkedUser.EmailConfirmedAt = &confirmedAt
+		linkedUser.Username = username
+		linkedUser.Type = *userType
+
+		if err := db.Create(linkedUser).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	identity = Identity{
+		UserID:   linkedUser.ID,
+		Provider: provider,
+		Subject:  userinfo.Subject,
+		Email:    userinfo.Email,
+	}
+	if err := db.Create(&identity).Error; err != nil {
+		return nil, err
+	}
+
+	return linkedUser, nil
+}
+
+// linkTo links an identity to the user who started authorization with Link
+func linkTo(db *gorm.DB, provider string, userinfo *Userinfo, userID uint) (*user.User, error) {
+	var identity Identity
+	err := db.Where("provider = ? AND subject = ?", provider, userinfo.Subject).First(&identity).Error
+	if err == nil && identity.UserID != userID {
+		return nil, apperror.Conflict("oauth_identity_linked", "Account of %s is linked to another user.", provider)
+	}
+	if err != nil && !apperror.IsNotFound(err) {
+		return nil, err
+	}
+	if err != nil {
+		identity = Identity{
+			UserID:   userID,
+			Provider: provider,
+			Subject:  userinfo.Subject,
+			Email:    userinfo.Email,
+		}
+		if err := db.Create(&identity).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	linkedUser := new(user.User)
+	if err := db.Preload("Type").First(&linkedUser, userID).Error; err != nil {
+		return nil, err
+	}
+
+	return linkedUser, nil
+}
+
+func availableUsername(db *gorm.DB, email string) (string, error) {
+	username := usernameCharset.ReplaceAllString(strings.ToLower(strings.Split(email, "@")[0]), "")
+	if username == "" {
+		username = "user"
+	}
+
+	candidate := username
+	for {
+		var existing user.User
+		err := db.Where("username = ?", candidate).First(&existing).Error
+		if apperror.IsNotFound(err) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		suffix, err := helpers.RandomToken(2)
+		if err != nil {
+			return "", err
+		}
+		candidate = username + suffix
+	}
+}
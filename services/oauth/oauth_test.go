@@ -0,0 +1,321 @@
+package oauth_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/config"
+	"github.com/dinopuguh/mycap-backend/database/databasetest"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/routes"
+	"github.com/dinopuguh/mycap-backend/services/oauth"
+	"github.com/dinopuguh/mycap-backend/services/oauth/oauthtest"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// authorizationFlow runs authorization code flows of MyCap against the stub provider
+type authorizationFlow struct {
+	app        *fiber.App
+	noRedirect *http.Client
+}
+
+// run starts authorization with the request, signs in to the stub provider with the login hint
+// and returns the response of the callback
+func (f authorizationFlow) run(reqAuthorize *http.Request, provider string, emailVerified, tamperState, dropCookie bool) (*response.HTTP, string) {
+	resHTTP := new(response.HTTP)
+	resAuthorize, _ := f.app.Test(reqAuthorize, -1)
+	defer resAuthorize.Body.Close()
+	resBodyAuthorize, _ := ioutil.ReadAll(resAuthorize.Body)
+	json.Unmarshal(resBodyAuthorize, &resHTTP)
+	if resHTTP.Status != http.StatusOK {
+		return resHTTP, string(resBodyAuthorize)
+	}
+
+	authorize := new(oauth.ResponseAuthorize)
+	authorizeJSON, _ := json.Marshal(resHTTP.Data)
+	json.Unmarshal(authorizeJSON, &authorize)
+
+	authorizationURL := authorize.AuthorizationURL
+	if !emailVerified {
+		authorizationURL += "&email_verified=false"
+	}
+
+	resProvider, err := f.noRedirect.Get(authorizationURL)
+	if err != nil {
+		return &response.HTTP{Status: http.StatusBadGateway}, err.Error()
+	}
+	resProvider.Body.Close()
+
+	callback, _ := url.Parse(resProvider.Header.Get("Location"))
+	callbackQuery := callback.Query()
+	if tamperState {
+		callbackQuery.Set("state", "tampered")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/oauth/%s/callback?%s", provider, callbackQuery.Encode()), nil)
+	if !dropCookie {
+		for _, cookie := range resAuthorize.Cookies() {
+			req.AddCookie(cookie)
+		}
+	}
+
+	resHTTP = new(response.HTTP)
+	res, _ := f.app.Test(req, -1)
+	defer res.Body.Close()
+	resBody, _ := ioutil.ReadAll(res.Body)
+	json.Unmarshal(resBody, &resHTTP)
+
+	return resHTTP, string(resBody)
+}
+
+func newAuthorizationFlow(t *testing.T) (authorizationFlow, func()) {
+	cfg := databasetest.Connect(t)
+
+	stub := httptest.NewServer(oauthtest.NewStubProvider())
+
+	cfg.OIDC.Providers = []config.OIDCProvider{{
+		Name:         "stub",
+		Issuer:       stub.URL,
+		ClientID:     "mycap",
+		ClientSecret: "s3cr3t",
+		RedirectURL:  "http://localhost:3000/api/v1/oauth/stub/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	}}
+
+	return authorizationFlow{
+		app: routes.New(cfg),
+		noRedirect: &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, stub.Close
+}
+
+func authorizeRequest(provider, email string) *http.Request {
+	query := url.Values{}
+	query.Set("login_hint", email)
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/oauth/%s/authorize?%s", provider, query.Encode()), nil)
+	return req
+}
+
+func TestCallback(t *testing.T) {
+	flow, closeStub := newAuthorizationFlow(t)
+	defer closeStub()
+
+	type args struct {
+		provider      string
+		email         string
+		emailVerified bool
+		tamperState   bool
+		dropCookie    bool
+		statusCode    int
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{"Valid login new user", args{
+			provider:      "stub",
+			email:         "dinooidc@mycap.com",
+			emailVerified: true,
+			statusCode:    http.StatusOK,
+		}},
+		{"Valid login linked identity", args{
+			provider:      "stub",
+			email:         "dinooidc@mycap.com",
+			emailVerified: true,
+			statusCode:    http.StatusOK,
+		}},
+		{"Email not verified", args{
+			provider:   "stub",
+			email:      "dinounverified@mycap.com",
+			statusCode: http.StatusForbidden,
+		}},
+		{"State invalid", args{
+			provider:      "stub",
+			email:         "dinooidc@mycap.com",
+			emailVerified: true,
+			tamperState:   true,
+			statusCode:    http.StatusBadRequest,
+		}},
+		{"State cookie of another browser", args{
+			provider:      "stub",
+			email:         "dinooidc@mycap.com",
+			emailVerified: true,
+			dropCookie:    true,
+			statusCode:    http.StatusBadRequest,
+		}},
+		{"Provider not found", args{
+			provider:   "unknown",
+			statusCode: http.StatusNotFound,
+		}},
+	}
+
+	var linkedUserID uint
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resHTTP, resBody := flow.run(authorizeRequest(tt.args.provider, tt.args.email), tt.args.provider, tt.args.emailVerified, tt.args.tamperState, tt.args.dropCookie)
+
+			assert.Equalf(t, tt.args.statusCode, resHTTP.Status, resBody)
+
+			if tt.args.statusCode == http.StatusOK {
+				login := new(user.ResponseAuth)
+				loginJSON, _ := json.Marshal(resHTTP.Data)
+				json.Unmarshal(loginJSON, &login)
+
+				assert.Equal(t, tt.args.email, login.User.Email)
+				if linkedUserID != 0 {
+					assert.Equal(t, linkedUserID, login.User.ID)
+				}
+				linkedUserID = login.User.ID
+			}
+		})
+	}
+}
+
+func TestLink(t *testing.T) {
+	flow, closeStub := newAuthorizationFlow(t)
+	defer closeStub()
+
+	request := func(method, endpoint, token string, data interface{}) *response.HTTP {
+		reqBody, _ := json.Marshal(data)
+		req, _ := http.NewRequest(method, endpoint, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resHTTP := new(response.HTTP)
+		res, _ := flow.app.Test(req, -1)
+		defer res.Body.Close()
+		json.NewDecoder(res.Body).Decode(resHTTP)
+		return resHTTP
+	}
+
+	registerUser := user.RegisterUser{
+		Name:     "Dino Password",
+		Email:    "dinopassword@mycap.com",
+		Username: "dinopassword",
+		Password: "s3cr3tp45sw0rd",
+	}
+	resHTTP := request(http.MethodPost, "/api/v1/register", "", registerUser)
+	registered := new(user.ResponseAuth)
+	authJSON, _ := json.Marshal(resHTTP.Data)
+	json.Unmarshal(authJSON, &registered)
+	defer request(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", registered.User.ID), registered.AccessToken, nil)
+
+	resHTTP, resBody := flow.run(authorizeRequest("stub", registerUser.Email), "stub", true, false, false)
+	assert.Equalf(t, http.StatusConflict, resHTTP.Status, "provider can't sign in to an account with an unconfirmed email and a password: %s", resBody)
+
+	link := func(password string) *http.Request {
+		reqBody, _ := json.Marshal(oauth.LinkIdentity{Password: password})
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/oauth/stub/link?login_hint="+url.QueryEscape(registerUser.Email), bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+registered.AccessToken)
+		return req
+	}
+
+	resHTTP, resBody = flow.run(link("wr0ngp455w0rd"), "stub", true, false, false)
+	assert.Equalf(t, http.StatusUnauthorized, resHTTP.Status, resBody)
+
+	resHTTP, resBody = flow.run(link(registerUser.Password), "stub", true, false, false)
+	if assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody) {
+		login := new(user.ResponseAuth)
+		loginJSON, _ := json.Marshal(resHTTP.Data)
+		json.Unmarshal(loginJSON, &login)
+		assert.Equal(t, registered.User.ID, login.User.ID)
+	}
+
+	resHTTP, resBody = flow.run(authorizeRequest("stub", registerUser.Email), "stub", true, false, false)
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, "linked identity signs in: %s", resBody)
+}
+
+func TestExchange(t *testing.T) {
+	stub := oauthtest.NewStubProvider()
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	forged := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Host = "idp.example.com"
+		stub.ServeHTTP(w, r)
+	}))
+	defer forged.Close()
+
+	noRedirect := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	tests := []struct {
+		name    string
+		issuer  string
+		nonce   string
+		wantErr string
+	}{
+		{"Valid ID token", server.URL, "n0nc3", ""},
+		{"Nonce mismatched", server.URL, "replayed", "ID token nonce invalid"},
+		{"Issuer mismatched", forged.URL, "n0nc3", "Issuer of provider stub mismatched"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := oauth.Provider{
+				Name:        "stub",
+				Issuer:      tt.issuer,
+				ClientID:    "mycap",
+				RedirectURL: "http://localhost:3000/api/v1/oauth/stub/callback",
+			}
+
+			discovery, err := provider.Discover()
+			if tt.wantErr != "" && err != nil {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			resProvider, err := noRedirect.Get(provider.AuthorizationURL(discovery, "st4te", "n0nc3", "v3rifier", "dinooidc@mycap.com"))
+			if !assert.NoError(t, err) {
+				return
+			}
+			resProvider.Body.Close()
+			callback, _ := url.Parse(resProvider.Header.Get("Location"))
+
+			userinfo, err := provider.Exchange(discovery, callback.Query().Get("code"), "v3rifier", tt.nonce)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, "stub|dinooidc@mycap.com", userinfo.Subject)
+			}
+		})
+	}
+}
+
+func TestCallbackDenied(t *testing.T) {
+	oauth.Configure(config.OIDC{Providers: []config.OIDCProvider{{Name: "stub"}}})
+	defer oauth.Configure(config.OIDC{})
+
+	app := fiber.New(fiber.Config{ErrorHandler: response.ErrorHandler})
+	app.Get("/oauth/:provider/callback", oauth.Callback)
+
+	query := url.Values{}
+	query.Set("error", "<script>alert(1)</script>")
+	req, _ := http.NewRequest(http.MethodGet, "/oauth/stub/callback?"+query.Encode(), nil)
+
+	res, _ := app.Test(req, -1)
+	defer res.Body.Close()
+	resHTTP := new(response.HTTP)
+	json.NewDecoder(res.Body).Decode(resHTTP)
+
+	assert.Equal(t, http.StatusUnauthorized, resHTTP.Status)
+	assert.Equal(t, "Authorization denied by provider.", resHTTP.Message)
+}
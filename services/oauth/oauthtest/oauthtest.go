@@ -0,0 +1,202 @@
+// Package oauthtest serves a local OpenID Connect identity provider to tests and offline development
+package oauthtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dinopuguh/mycap-backend/helpers"
+	"github.com/dinopuguh/mycap-backend/services/oauth"
+)
+
+const stubKeyID = "stub"
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+}
+
+// StubProvider is a local OpenID Connect identity provider to test social login offline.
+// It approves every authorization request, login_hint is used as the user's email
+// and email_verified=false query marks the email as unverified. ID tokens are signed by a key
+// generated for the provider.
+type StubProvider struct {
+	mu     sync.Mutex
+	key    *rsa.PrivateKey
+	codes  map[string]stubGrant
+	tokens map[string]oauth.Userinfo
+}
+
+type stubGrant struct {
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	userinfo      oauth.Userinfo
+}
+
+// NewStubProvider creates a local identity provider
+func NewStubProvider() *StubProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	return &StubProvider{
+		key:    key,
+		codes:  make(map[string]stubGrant),
+		tokens: make(map[string]oauth.Userinfo),
+	}
+}
+
+func (s *StubProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		s.discovery(w, r)
+	case "/authorize":
+		s.authorize(w, r)
+	case "/token":
+		s.token(w, r)
+	case "/userinfo":
+		s.userinfo(w, r)
+	case "/jwks":
+		writeJSON(w, http.StatusOK, oauth.JWKS{Keys: []oauth.JWK{oauth.NewJWK(stubKeyID, &s.key.PublicKey)}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *StubProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	issuer := "http://" + r.Host
+	writeJSON(w, http.StatusOK, oauth.Discovery{
+		Issuer:                issuer,
+		AuthorizationEndpoint: issuer + "/authorize",
+		TokenEndpoint:         issuer + "/token",
+		UserinfoEndpoint:      issuer + "/userinfo",
+		JWKSURI:               issuer + "/jwks",
+	})
+}
+
+func (s *StubProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || query.Get("redirect_uri") == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	if query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	email := query.Get("login_hint")
+	if email == "" {
+		email = "stub@mycap.com"
+	}
+
+	code, err := helpers.RandomToken(16)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	s.mu.Lock()
+	s.codes[code] = stubGrant{
+		clientID:      query.Get("client_id"),
+		redirectURI:   query.Get("redirect_uri"),
+		codeChallenge: query.Get("code_challenge"),
+		nonce:         query.Get("nonce"),
+		userinfo: oauth.Userinfo{
+			Subject:       "stub|" + email,
+			Name:          strings.Split(email, "@")[0],
+			Email:         email,
+			EmailVerified: query.Get("email_verified") != "false",
+		},
+	}
+	s.mu.Unlock()
+
+	callback := redirectURI.Query()
+	callback.Set("code", code)
+	callback.Set("state", query.Get("state"))
+	redirectURI.RawQuery = callback.Encode()
+
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (s *StubProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	grant, ok := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	if !ok || grant.clientID != r.PostForm.Get("client_id") || grant.redirectURI != r.PostForm.Get("redirect_uri") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	if oauth.CodeChallenge(r.PostForm.Get("code_verifier")) != grant.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	accessToken, err := helpers.RandomToken(16)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	s.tokens[accessToken] = grant.userinfo
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   "http://" + r.Host,
+		"aud":   grant.clientID,
+		"sub":   grant.userinfo.Subject,
+		"nonce": grant.nonce,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+	})
+	idToken.Header["kid"] = stubKeyID
+	signedIDToken, err := idToken.SignedString(s.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		IDToken:     signedIDToken,
+	})
+}
+
+func (s *StubProvider) userinfo(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	userinfo, ok := s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, userinfo)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,176 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/config"
+)
+
+// Provider is an OpenID Connect identity provider configuration
+type Provider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Discovery represents OpenID Connect provider metadata
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Userinfo represents claims returned by the provider's userinfo endpoint
+type Userinfo struct {
+	Subject       string `json:"sub"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+var providers = map[string]Provider{}
+
+// Configure sets the identity providers users can sign in with
+func Configure(cfg config.OIDC) {
+	providers = make(map[string]Provider, len(cfg.Providers))
+	for _, p := range cfg.Providers {
+		providers[p.Name] = Provider(p)
+	}
+}
+
+// Providers returns the configured identity providers by name
+func Providers() map[string]Provider {
+	return providers
+}
+
+// Discover fetches provider metadata from the issuer's well-known endpoint
+func (p Provider) Discover() (*Discovery, error) {
+	res, err := httpClient.Get(p.Issuer + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to discover provider %s", p.Name)
+	}
+
+	discovery := new(Discovery)
+	if err := json.NewDecoder(res.Body).Decode(discovery); err != nil {
+		return nil, err
+	}
+
+	if discovery.Issuer != p.Issuer {
+		return nil, fmt.Errorf("Issuer of provider %s mismatched", p.Name)
+	}
+
+	return discovery, nil
+}
+
+// AuthorizationURL builds the authorization code request with PKCE challenge and the nonce the ID token
+// must be bound to
+func (p Provider) AuthorizationURL(discovery *Discovery, state, nonce, codeVerifier, loginHint string) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.ClientID)
+	query.Set("redirect_uri", p.RedirectURL)
+	query.Set("scope", strings.Join(p.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+	if loginHint != "" {
+		query.Set("login_hint", loginHint)
+	}
+
+	return discovery.AuthorizationEndpoint + "?" + query.Encode()
+}
+
+// Exchange trades an authorization code for the provider's userinfo claims, the ID token returned
+// with the access token must be valid and bound to the nonce of the authorization request
+func (p Provider) Exchange(discovery *Discovery, code, codeVerifier, nonce string) (*Userinfo, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.RedirectURL)
+	form.Set("client_id", p.ClientID)
+	form.Set("client_secret", p.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	res, err := httpClient.PostForm(discovery.TokenEndpoint, form)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to exchange authorization code")
+	}
+
+	token := new(tokenResponse)
+	if err := json.NewDecoder(res.Body).Decode(token); err != nil {
+		return nil, err
+	}
+
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("ID token not specified")
+	}
+
+	subject, err := p.verifyIDToken(discovery, token.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, discovery.UserinfoEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	resUserinfo, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resUserinfo.Body.Close()
+
+	if resUserinfo.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to get userinfo")
+	}
+
+	userinfo := new(Userinfo)
+	if err := json.NewDecoder(resUserinfo.Body).Decode(userinfo); err != nil {
+		return nil, err
+	}
+
+	if userinfo.Subject != subject {
+		return nil, fmt.Errorf("Userinfo subject mismatched")
+	}
+
+	return userinfo, nil
+}
+
+// CodeChallenge derives PKCE S256 code challenge from code verifier
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
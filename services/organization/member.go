@@ -0,0 +1,331 @@
+package organization
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/config"
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/helpers"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/mailer"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/dinopuguh/mycap-backend/validation"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Invitation is a model for email invitation to join an organization
+type Invitation struct {
+	gorm.Model
+	OrganizationID uint       `json:"organization_id" gorm:"index;"`
+	Email          string     `json:"email"`
+	Role           string     `json:"role"`
+	TokenHash      string     `json:"-" gorm:"uniqueIndex;"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+}
+
+// invitationLifetime is how long an invitation can be accepted
+const invitationLifetime = 7 * 24 * time.Hour
+
+// appURL is the web app linked from invitation emails
+var appURL string
+
+// Configure sets the web app linked from invitation emails
+func Configure(cfg config.Config) {
+	appURL = cfg.AppURL
+}
+
+var (
+	// ErrInvitationNotFound is returned when an invitation token doesn't exist or was accepted
+	ErrInvitationNotFound = apperror.NotFound("invitation_not_found", "Invitation not found.")
+	// ErrInvitationExpired is returned when an invitation token is expired
+	ErrInvitationExpired = apperror.Invalid("invitation_expired", "Invitation expired.")
+	// ErrInvitationEmail is returned when an invitation is accepted by an user with another email
+	ErrInvitationEmail = apperror.Forbidden("invitation_email_mismatch", "Invitation was sent to another email.")
+	// ErrLastOwner is returned when the only owner of an organization leaves or is demoted
+	ErrLastOwner = apperror.Invalid("organization_last_owner", "Organization needs at least one owner.")
+)
+
+// Invite function sends an email invitation to join the current user's organization
+// @Summary Invite member
+// @Description Send an email invitation to join the organization, owners and admins only
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param invitation body InviteMember true "Invite member"
+// @Success 200 {object} response.HTTP{data=Invitation}
+// @Security ApiKeyAuth
+// @Router /v1/organization/invitations [post]
+func Invite(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	inviteMember := new(InviteMember)
+	if err := c.BodyParser(&inviteMember); err != nil {
+		return apperror.Invalid("body_invalid", err.Error())
+	}
+
+	if err := validation.Struct(inviteMember); err != nil {
+		return err
+	}
+
+	if inviteMember.Role == "" {
+		inviteMember.Role = RoleMember
+	}
+	inviter, member, err := currentMember(c, db)
+	if err == nil && (!canManage(member.Role) || (inviteMember.Role == RoleOwner && member.Role != RoleOwner)) {
+		err = ErrForbidden
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := helpers.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	var organization Organization
+	if err := db.First(&organization, member.OrganizationID).Error; err != nil {
+		return err
+	}
+
+	invitation := &Invitation{
+		OrganizationID: member.OrganizationID,
+		Email:          strings.ToLower(inviteMember.Email),
+		Role:           inviteMember.Role,
+		TokenHash:      helpers.HashToken(token),
+		ExpiresAt:      time.Now().Add(invitationLifetime),
+	}
+	if err := db.Create(invitation).Error; err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Hi,\n\n%s invited you to join %s on MyCap as %s. Accept the invitation with this token: %s\n%s/accept-invitation?token=%s\n\nThe token expires in 7 days.",
+		inviter.Name, organization.Name, invitation.Role, token, appURL, token)
+	if err := mailer.Send(logger.Context(c), invitation.Email, "Join "+organization.Name+" on MyCap", body); err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    invitation,
+		Status:  http.StatusOK,
+		Message: "Invitation sent.",
+	})
+}
+
+// Accept function joins the current user to an organization with an invitation token
+// @Summary Accept invitation
+// @Description Join an organization with an invitation token sent to the current user's email
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param invitation body AcceptInvitation true "Accept invitation"
+// @Success 200 {object} response.HTTP{data=Organization}
+// @Security ApiKeyAuth
+// @Router /v1/organization/invitations/accept [post]
+func Accept(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	acceptInvitation := new(AcceptInvitation)
+	if err := c.BodyParser(&acceptInvitation); err != nil {
+		return apperror.Invalid("body_invalid", err.Error())
+	}
+
+	if err := validation.Struct(acceptInvitation); err != nil {
+		return err
+	}
+
+	invitee, err := user.Current(c, user.NewRepository(db))
+	if err != nil {
+		return err
+	}
+
+	var invitation Invitation
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND accepted_at IS NULL", helpers.HashToken(acceptInvitation.Token)).
+			First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvitationNotFound
+			}
+			return err
+		}
+
+		if time.Now().After(invitation.ExpiresAt) {
+			return ErrInvitationExpired
+		}
+		if !strings.EqualFold(invitation.Email, invitee.Email) {
+			return ErrInvitationEmail
+		}
+
+		if _, err := Membership(tx, invitee.ID); err != ErrNotMember {
+			if err == nil {
+				return ErrAlreadyMember
+			}
+			return err
+		}
+
+		if err := tx.Create(&Member{OrganizationID: invitation.OrganizationID, UserID: invitee.ID, Role: invitation.Role}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&invitation).Update("accepted_at", time.Now()).Error
+	}); err != nil {
+		return err
+	}
+
+	organization, err := load(db, invitation.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    organization,
+		Status:  http.StatusOK,
+		Message: "Success join organization.",
+	})
+}
+
+// otherOwners counts owners of an organization other than the user
+func otherOwners(tx *gorm.DB, organizationID, userID uint) (int64, error) {
+	var owners int64
+	err := tx.Model(&Member{}).Where("organization_id = ? AND role = ? AND user_id <> ?", organizationID, RoleOwner, userID).Count(&owners).Error
+
+	return owners, err
+}
+
+// findMember returns a member of an organization by user ID
+func findMember(tx *gorm.DB, organizationID uint, userID string) (*Member, error) {
+	var member Member
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).First(&member).Error; err != nil {
+		if apperror.IsNotFound(err) {
+			return nil, apperror.NotFound("member_not_found", "Member with user ID %v not found.", userID)
+		}
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// UpdateMember function changes the role of a member of the current user's organization
+// @Summary Change member role
+// @Description Change the role of a member by user ID, owners only
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param member body ChangeRole true "Change role"
+// @Success 200 {object} response.HTTP{data=Member}
+// @Security ApiKeyAuth
+// @Router /v1/organization/members/{user_id} [put]
+func UpdateMember(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	changeRole := new(ChangeRole)
+	if err := c.BodyParser(&changeRole); err != nil {
+		return apperror.Invalid("body_invalid", err.Error())
+	}
+
+	if err := validation.Struct(changeRole); err != nil {
+		return err
+	}
+
+	_, current, err := currentMember(c, db)
+	if err == nil && current.Role != RoleOwner {
+		err = ErrForbidden
+	}
+	if err != nil {
+		return err
+	}
+
+	var member *Member
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if member, err = findMember(tx, current.OrganizationID, c.Params("user_id")); err != nil {
+			return err
+		}
+
+		if member.Role == RoleOwner && changeRole.Role != RoleOwner {
+			owners, err := otherOwners(tx, member.OrganizationID, member.UserID)
+			if err != nil {
+				return err
+			}
+			if owners == 0 {
+				return ErrLastOwner
+			}
+		}
+
+		member.Role = changeRole.Role
+		return tx.Model(member).Update("role", member.Role).Error
+	}); err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    member,
+		Status:  http.StatusOK,
+		Message: "Success update member.",
+	})
+}
+
+// RemoveMember function removes a member from the current user's organization, members can remove themselves
+// @Summary Remove member
+// @Description Remove a member by user ID, owners and admins remove others and members leave by removing themselves
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {object} response.HTTP
+// @Security ApiKeyAuth
+// @Router /v1/organization/members/{user_id} [delete]
+func RemoveMember(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	_, current, err := currentMember(c, db)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		member, err := findMember(tx, current.OrganizationID, c.Params("user_id"))
+		if err != nil {
+			return err
+		}
+
+		self := member.UserID == current.UserID
+		if !self && (!canManage(current.Role) || (member.Role == RoleOwner && current.Role != RoleOwner)) {
+			return ErrForbidden
+		}
+
+		if member.Role == RoleOwner {
+			owners, err := otherOwners(tx, member.OrganizationID, member.UserID)
+			if err != nil {
+				return err
+			}
+			if owners == 0 {
+				return ErrLastOwner
+			}
+		}
+
+		return tx.Unscoped().Delete(member).Error
+	}); err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Status:  http.StatusOK,
+		Message: "Success remove member.",
+	})
+}
@@ -0,0 +1,273 @@
+package organization
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/dinopuguh/mycap-backend/validation"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Organization is a model for school or company account sharing a pool of minutes between its members
+type Organization struct {
+	gorm.Model
+	Name       string   `json:"name"`
+	PooledTime int64    `json:"pooled_time"` // milliseconds drawn by members' group sessions before their own remaining time
+	Members    []Member `json:"members"`
+}
+
+// Member is a model for membership of an user in an organization, an user belongs to one organization at most
+type Member struct {
+	gorm.Model
+	OrganizationID uint            `json:"organization_id" gorm:"index;"`
+	UserID         uint            `json:"user_id" gorm:"uniqueIndex;"`
+	User           user.User       `json:"-"`
+	Role           string          `json:"role"`
+	Profile        user.PublicUser `json:"user" gorm:"-"`
+}
+
+// PoolEntry is a model for ledger of an organization's pooled time, topped up or drawn by members' sessions
+type PoolEntry struct {
+	gorm.Model
+	OrganizationID uint   `json:"organization_id" gorm:"index;"`
+	UserID         uint   `json:"user_id" gorm:"index;"` // 0 for top ups
+	Amount         int64  `json:"amount"`                // milliseconds, negative when drawn
+	TimeUsed       int64  `json:"time_used"`             // milliseconds used by the session drawing from the pool
+	Reference      string `json:"reference"`
+}
+
+const (
+	// RoleOwner manages the organization, its members and their roles
+	RoleOwner = "owner"
+	// RoleAdmin invites and removes members and reads usage reports
+	RoleAdmin = "admin"
+	// RoleMember draws from the organization's pooled time
+	RoleMember = "member"
+)
+
+var (
+	// ErrNotMember is returned when the current user doesn't belong to an organization
+	ErrNotMember = apperror.NotFound("organization_not_member", "You are not a member of an organization.")
+	// ErrAlreadyMember is returned when an user joins a second organization
+	ErrAlreadyMember = apperror.Conflict("organization_already_member", "User is already a member of an organization.")
+	// ErrForbidden is returned when the current user's role can't manage the organization
+	ErrForbidden = apperror.Forbidden("organization_forbidden", "Your organization role can't do this.")
+)
+
+// canManage reports whether a role can invite and remove members
+func canManage(role string) bool {
+	return role == RoleOwner || role == RoleAdmin
+}
+
+// Membership returns the organization membership of an user
+func Membership(db *gorm.DB, userID uint) (*Member, error) {
+	var member Member
+	if err := db.Where("user_id = ?", userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotMember
+		}
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// Pool returns pooled time an user can draw from its organization, 0 when it doesn't belong to one
+func Pool(db *gorm.DB, userID uint) (int64, error) {
+	var pooledTime int64
+	err := db.Model(&Organization{}).
+		Joins("JOIN members ON members.organization_id = organizations.id AND members.deleted_at IS NULL").
+		Where("members.user_id = ?", userID).
+		Pluck("organizations.pooled_time", &pooledTime).Error
+
+	return pooledTime, err
+}
+
+// Draw takes time used by a member's group session from its organization's pooled time and records it,
+// returns the amount drawn so the rest is consumed from the member's own remaining time
+func Draw(tx *gorm.DB, userID uint, used int64, reference string) (int64, error) {
+	if used <= 0 {
+		return 0, nil
+	}
+
+	member, err := Membership(tx, userID)
+	if err == ErrNotMember {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var organization Organization
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&organization, member.OrganizationID).Error; err != nil {
+		return 0, err
+	}
+
+	drawn := used
+	if drawn > organization.PooledTime {
+		drawn = organization.PooledTime
+	}
+	if drawn < 0 {
+		drawn = 0
+	}
+
+	entry := PoolEntry{
+		OrganizationID: organization.ID,
+		UserID:         userID,
+		Amount:         -drawn,
+		TimeUsed:       used,
+		Reference:      reference,
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return 0, err
+	}
+
+	if drawn > 0 {
+		if err := tx.Model(&organization).UpdateColumn("pooled_time", gorm.Expr("pooled_time - ?", drawn)).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	return drawn, nil
+}
+
+// TopUp adds pooled time to an organization and records it
+func TopUp(db *gorm.DB, organizationID uint, amount int64, reference string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Organization{}).Where("id = ?", organizationID).UpdateColumn("pooled_time", gorm.Expr("pooled_time + ?", amount))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Create(&PoolEntry{
+			OrganizationID: organizationID,
+			Amount:         amount,
+			Reference:      reference,
+		}).Error
+	})
+}
+
+// currentMember returns the current user and its organization membership
+func currentMember(c *fiber.Ctx, db *gorm.DB) (*user.User, *Member, error) {
+	currentUser, err := user.Current(c, user.NewRepository(db))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	member, err := Membership(db, currentUser.ID)
+	return currentUser, member, err
+}
+
+// load returns an organization with its members
+func load(db *gorm.DB, organizationID uint) (*Organization, error) {
+	var organization Organization
+	if err := db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id asc")
+	}).Preload("Members.User.Type").First(&organization, organizationID).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range organization.Members {
+		organization.Members[i].Profile = organization.Members[i].User.Public()
+	}
+
+	return &organization, nil
+}
+
+// New function creates an organization owned by the current user
+// @Summary Create an organization
+// @Description Create an organization with the current user as owner
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param organization body CreateOrganization true "Create organization"
+// @Success 200 {object} response.HTTP{data=Organization}
+// @Security ApiKeyAuth
+// @Router /v1/organizations [post]
+func New(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	createOrganization := new(CreateOrganization)
+	if err := c.BodyParser(&createOrganization); err != nil {
+		return apperror.Invalid("body_invalid", err.Error())
+	}
+
+	if err := validation.Struct(createOrganization); err != nil {
+		return err
+	}
+
+	owner, err := user.Current(c, user.NewRepository(db))
+	if err != nil {
+		return err
+	}
+
+	organization := &Organization{Name: createOrganization.Name}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := Membership(tx, owner.ID); err != ErrNotMember {
+			if err == nil {
+				return ErrAlreadyMember
+			}
+			return err
+		}
+
+		if err := tx.Omit(clause.Associations).Create(organization).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&Member{OrganizationID: organization.ID, UserID: owner.ID, Role: RoleOwner}).Error
+	}); err != nil {
+		return err
+	}
+
+	organization, err = load(db, organization.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    organization,
+		Status:  http.StatusOK,
+		Message: "Success create organization.",
+	})
+}
+
+// Get function shows the current user's organization with its members
+// @Summary Get current organization
+// @Description Get the organization of the current user with its members and pooled time
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.HTTP{data=Organization}
+// @Security ApiKeyAuth
+// @Router /v1/organization [get]
+func Get(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	_, member, err := currentMember(c, db)
+	if err != nil {
+		return err
+	}
+
+	organization, err := load(db, member.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    organization,
+		Status:  http.StatusOK,
+		Message: "Success get organization.",
+	})
+}
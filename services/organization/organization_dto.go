@@ -0,0 +1,52 @@
+package organization
+
+// CreateOrganization is a data transfer object for create organization
+type CreateOrganization struct {
+	Name string `json:"name" validate:"required,max=100" example:"MyCap School"`
+}
+
+// InviteMember is a data transfer object for inviting member
+type InviteMember struct {
+	Email string `json:"email" validate:"required,email" example:"teacher@mycap.com"`
+	Role  string `json:"role" validate:"omitempty,oneof=owner admin member" example:"member"` // (owner, admin, member)
+}
+
+// AcceptInvitation is a data transfer object for accepting invitation
+type AcceptInvitation struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ChangeRole is a data transfer object for changing member role
+type ChangeRole struct {
+	Role string `json:"role" validate:"required,oneof=owner admin member" example:"admin"` // (owner, admin, member)
+}
+
+// UsageTotal represents number of sessions, time used and time drawn from pooled time
+type UsageTotal struct {
+	Sessions int64 `json:"sessions"`
+	TimeUsed int64 `json:"time_used"` // milliseconds
+	FromPool int64 `json:"from_pool"` // milliseconds
+}
+
+// MemberUsage represents usage of an organization member
+type MemberUsage struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	UsageTotal
+}
+
+// DayUsage represents usage of an organization in a day
+type DayUsage struct {
+	Date string `json:"date" example:"2020-10-01"`
+	UsageTotal
+}
+
+// UsageReport represents monthly usage of an organization
+type UsageReport struct {
+	Month      string `json:"month" example:"2020-10"`
+	PooledTime int64  `json:"pooled_time"`
+	ToppedUp   int64  `json:"topped_up"`
+	UsageTotal
+	Members []MemberUsage `json:"members"`
+	Days    []DayUsage    `json:"days"`
+}
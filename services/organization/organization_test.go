@@ -0,0 +1,183 @@
+package organization_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/database/databasetest"
+	"github.com/dinopuguh/mycap-backend/mailer"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/routes"
+	"github.com/dinopuguh/mycap-backend/services/group"
+	"github.com/dinopuguh/mycap-backend/services/organization"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganization(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	app := routes.New(cfg)
+	db := database.DBConn
+	recorder := new(mailer.Recorder)
+	mailer.Default = recorder
+
+	request := func(method, endpoint, token string, data interface{}) (*response.HTTP, string) {
+		reqBody, _ := json.Marshal(data)
+		req, _ := http.NewRequest(method, endpoint, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resHTTP := new(response.HTTP)
+		res, _ := app.Test(req, -1)
+		defer res.Body.Close()
+		resBody, _ := ioutil.ReadAll(res.Body)
+		json.Unmarshal(resBody, &resHTTP)
+
+		return resHTTP, string(resBody)
+	}
+
+	register := func(username string) *user.ResponseAuth {
+		resHTTP, _ := request(http.MethodPost, "/api/v1/register", "", user.RegisterUser{
+			Name:     username,
+			Email:    username + "@mycap.com",
+			Username: username,
+			Password: "s3cr3tp45sw0rd",
+		})
+		auth := new(user.ResponseAuth)
+		authJSON, _ := json.Marshal(resHTTP.Data)
+		json.Unmarshal(authJSON, &auth)
+
+		return auth
+	}
+
+	owner := register("dinoowner")
+	invitee := register("dinoinvitee")
+	outsider := register("dinooutsider")
+
+	var organizationID uint
+	defer func() {
+		db.Unscoped().Where("organization_id = ?", organizationID).Delete(&organization.PoolEntry{})
+		db.Unscoped().Where("organization_id = ?", organizationID).Delete(&organization.Invitation{})
+		db.Unscoped().Where("organization_id = ?", organizationID).Delete(&organization.Member{})
+		db.Unscoped().Delete(&organization.Organization{}, organizationID)
+		for _, auth := range []*user.ResponseAuth{owner, invitee, outsider} {
+			request(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", auth.User.ID), auth.AccessToken, nil)
+		}
+	}()
+
+	t.Run("Create", func(t *testing.T) {
+		resHTTP, resBody := request(http.MethodPost, "/api/v1/organizations", owner.AccessToken, organization.CreateOrganization{})
+		assert.Equalf(t, http.StatusBadRequest, resHTTP.Status, resBody)
+
+		resHTTP, resBody = request(http.MethodPost, "/api/v1/organizations", owner.AccessToken, organization.CreateOrganization{Name: "MyCap School"})
+		assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+		created := new(organization.Organization)
+		createdJSON, _ := json.Marshal(resHTTP.Data)
+		json.Unmarshal(createdJSON, &created)
+		organizationID = created.ID
+		if assert.Len(t, created.Members, 1) {
+			assert.Equal(t, organization.RoleOwner, created.Members[0].Role)
+		}
+
+		resHTTP, resBody = request(http.MethodPost, "/api/v1/organizations", owner.AccessToken, organization.CreateOrganization{Name: "Another"})
+		assert.Equalf(t, http.StatusConflict, resHTTP.Status, resBody)
+	})
+
+	var token string
+	t.Run("Invite", func(t *testing.T) {
+		resHTTP, resBody := request(http.MethodPost, "/api/v1/organization/invitations", outsider.AccessToken, organization.InviteMember{Email: invitee.User.Email})
+		assert.Equalf(t, http.StatusNotFound, resHTTP.Status, resBody)
+
+		resHTTP, resBody = request(http.MethodPost, "/api/v1/organization/invitations", owner.AccessToken, organization.InviteMember{Email: invitee.User.Email, Role: "boss"})
+		assert.Equalf(t, http.StatusBadRequest, resHTTP.Status, resBody)
+
+		resHTTP, resBody = request(http.MethodPost, "/api/v1/organization/invitations", owner.AccessToken, organization.InviteMember{Email: invitee.User.Email})
+		assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+		message, sent := recorder.Last(invitee.User.Email)
+		assert.True(t, sent)
+		token = strings.TrimPrefix(regexp.MustCompile(`token: [0-9a-f]+`).FindString(message.Body), "token: ")
+	})
+
+	t.Run("Accept", func(t *testing.T) {
+		resHTTP, resBody := request(http.MethodPost, "/api/v1/organization/invitations/accept", outsider.AccessToken, organization.AcceptInvitation{Token: token})
+		assert.Equalf(t, http.StatusForbidden, resHTTP.Status, resBody)
+
+		resHTTP, resBody = request(http.MethodPost, "/api/v1/organization/invitations/accept", invitee.AccessToken, organization.AcceptInvitation{Token: token})
+		assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+		resHTTP, resBody = request(http.MethodPost, "/api/v1/organization/invitations/accept", invitee.AccessToken, organization.AcceptInvitation{Token: token})
+		assert.Equalf(t, http.StatusNotFound, resHTTP.Status, resBody)
+	})
+
+	t.Run("Change role", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/api/v1/organization/members/%d", invitee.User.ID)
+		resHTTP, resBody := request(http.MethodPut, endpoint, invitee.AccessToken, organization.ChangeRole{Role: organization.RoleAdmin})
+		assert.Equalf(t, http.StatusForbidden, resHTTP.Status, resBody)
+
+		resHTTP, resBody = request(http.MethodPut, endpoint, owner.AccessToken, organization.ChangeRole{Role: organization.RoleAdmin})
+		assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+		endpoint = fmt.Sprintf("/api/v1/organization/members/%d", owner.User.ID)
+		resHTTP, resBody = request(http.MethodPut, endpoint, owner.AccessToken, organization.ChangeRole{Role: organization.RoleMember})
+		assert.Equalf(t, http.StatusBadRequest, resHTTP.Status, resBody)
+	})
+
+	t.Run("Draw pooled time", func(t *testing.T) {
+		assert.NoError(t, organization.TopUp(db, organizationID, 600000, "INV-1"))
+
+		resHTTP, resBody := request(http.MethodPost, "/api/v1/groups", invitee.AccessToken, group.CreateGroup{Type: group.GroupType})
+		assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+		resHTTP, resBody = request(http.MethodPost, "/api/v1/leave-groups", invitee.AccessToken, group.LeaveGroup{
+			AdminUsername: invitee.User.Username,
+			RemainingTime: invitee.User.RemainingTime - 60000,
+		})
+		assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+		var member user.User
+		db.First(&member, invitee.User.ID)
+		assert.Equal(t, invitee.User.RemainingTime, member.RemainingTime, "personal time is untouched")
+
+		pooledTime, err := organization.Pool(db, invitee.User.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(540000), pooledTime)
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		resHTTP, resBody := request(http.MethodGet, "/api/v1/organization/usage", owner.AccessToken, nil)
+		assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+		report := new(organization.UsageReport)
+		reportJSON, _ := json.Marshal(resHTTP.Data)
+		json.Unmarshal(reportJSON, &report)
+		assert.Equal(t, int64(600000), report.ToppedUp)
+		assert.Equal(t, int64(60000), report.FromPool)
+		if assert.Len(t, report.Members, 1) {
+			assert.Equal(t, invitee.User.Username, report.Members[0].Username)
+		}
+	})
+
+	t.Run("Leave", func(t *testing.T) {
+		endpoint := fmt.Sprintf("/api/v1/organization/members/%d", owner.User.ID)
+		resHTTP, resBody := request(http.MethodDelete, endpoint, invitee.AccessToken, nil)
+		assert.Equalf(t, http.StatusForbidden, resHTTP.Status, resBody)
+
+		endpoint = fmt.Sprintf("/api/v1/organization/members/%d", invitee.User.ID)
+		resHTTP, resBody = request(http.MethodDelete, endpoint, invitee.AccessToken, nil)
+		assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+		resHTTP, resBody = request(http.MethodGet, "/api/v1/organization", invitee.AccessToken, nil)
+		assert.Equalf(t, http.StatusNotFound, resHTTP.Status, resBody)
+	})
+}
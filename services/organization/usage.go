@@ -0,0 +1,97 @@
+package organization
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Usage function reports monthly usage of the current user's organization by member and by day
+// @Summary Get organization usage
+// @Description Get time used by members' group sessions and drawn from pooled time in a month, owners and admins only
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param month query string false "Month (2006-01), default current month"
+// @Success 200 {object} response.HTTP{data=UsageReport}
+// @Security ApiKeyAuth
+// @Router /v1/organization/usage [get]
+func Usage(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	month := time.Now().UTC()
+	if value := c.Query("month"); value != "" {
+		parsed, err := time.Parse("2006-01", value)
+		if err != nil {
+			return apperror.Invalid("month_invalid", "Month invalid.")
+		}
+		month = parsed
+	}
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	_, member, err := currentMember(c, db)
+	if err == nil && !canManage(member.Role) {
+		err = ErrForbidden
+	}
+	if err != nil {
+		return err
+	}
+
+	var organization Organization
+	if err := db.First(&organization, member.OrganizationID).Error; err != nil {
+		return err
+	}
+
+	monthly := func(db *gorm.DB) *gorm.DB {
+		return db.Model(&PoolEntry{}).
+			Where("pool_entries.organization_id = ? AND pool_entries.created_at >= ? AND pool_entries.created_at < ?", organization.ID, start, end)
+	}
+	draws := func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(monthly).Where("pool_entries.user_id <> 0")
+	}
+	const totals = "COUNT(*) AS sessions, COALESCE(SUM(pool_entries.time_used), 0) AS time_used, COALESCE(SUM(-pool_entries.amount), 0) AS from_pool"
+
+	report := UsageReport{
+		Month:      start.Format("2006-01"),
+		PooledTime: organization.PooledTime,
+		Members:    []MemberUsage{},
+		Days:       []DayUsage{},
+	}
+
+	if err := db.Scopes(draws).Select(totals).Find(&report.UsageTotal).Error; err != nil {
+		return err
+	}
+
+	var topUps struct{ ToppedUp int64 }
+	if err := db.Scopes(monthly).Where("pool_entries.user_id = 0").
+		Select("COALESCE(SUM(pool_entries.amount), 0) AS topped_up").Find(&topUps).Error; err != nil {
+		return err
+	}
+	report.ToppedUp = topUps.ToppedUp
+
+	if err := db.Scopes(draws).Joins("JOIN users ON users.id = pool_entries.user_id").
+		Select("pool_entries.user_id, users.username, " + totals).
+		Group("pool_entries.user_id, users.username").Order("time_used desc").Find(&report.Members).Error; err != nil {
+		return err
+	}
+
+	if err := db.Scopes(draws).
+		Select("TO_CHAR(pool_entries.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS date, " + totals).
+		Group("date").Order("date").Find(&report.Days).Error; err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    report,
+		Status:  http.StatusOK,
+		Message: "Success get organization usage.",
+	})
+}
@@ -0,0 +1,207 @@
+package promo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/dinopuguh/mycap-backend/validation"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PromoCode is a model for promo code giving bonus time and/or a temporary plan upgrade
+type PromoCode struct {
+	gorm.Model
+	Code           string     `json:"code" gorm:"uniqueIndex;"`
+	BonusTime      int64      `json:"bonus_time"`      // milliseconds credited as bonus time
+	UpgradeTypeID  uint       `json:"upgrade_type_id"` // plan given temporarily, 0 for none
+	UpgradeDays    int        `json:"upgrade_days"`
+	MaxRedemptions int64      `json:"max_redemptions"` // 0 means unlimited
+	Redemptions    int64      `json:"redemptions"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+}
+
+// Redemption is a model for promo code redeemed by an user
+type Redemption struct {
+	gorm.Model
+	PromoCodeID    uint       `json:"promo_code_id" gorm:"uniqueIndex:idx_redemptions_promo_user;"`
+	UserID         uint       `json:"user_id" gorm:"uniqueIndex:idx_redemptions_promo_user;index;"`
+	BonusTime      int64      `json:"bonus_time"`
+	UpgradeTypeID  uint       `json:"upgrade_type_id"`
+	PreviousTypeID uint       `json:"-"`
+	UpgradeEndsAt  *time.Time `json:"upgrade_ends_at" gorm:"index;"`
+	Reverted       bool       `json:"-" gorm:"default:false;"`
+}
+
+var (
+	// ErrNotFound is returned when a promo code doesn't exist
+	ErrNotFound = apperror.NotFound("promo_code_not_found", "Promo code not found.")
+	// ErrExpired is returned when a promo code is expired
+	ErrExpired = apperror.Invalid("promo_code_expired", "Promo code expired.")
+	// ErrFullyRedeemed is returned when a promo code reached its maximum redemptions
+	ErrFullyRedeemed = apperror.Invalid("promo_code_fully_redeemed", "Promo code fully redeemed.")
+	// ErrAlreadyRedeemed is returned when an user redeems a promo code twice
+	ErrAlreadyRedeemed = apperror.Conflict("promo_code_already_redeemed", "You already redeemed this promo code.")
+	// ErrUpgradeActive is returned when an user redeems a plan upgrade while another one is active
+	ErrUpgradeActive = apperror.Conflict("plan_upgrade_active", "You already have an active plan upgrade.")
+)
+
+// Normalize formats a promo code as stored
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Redeem applies a promo code to an user, the promo code row is locked so maximum redemptions can't be exceeded
+func Redeem(db *gorm.DB, code string, u *user.User, now time.Time) (*Redemption, error) {
+	var redemption *Redemption
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var promoCode PromoCode
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", Normalize(code)).First(&promoCode).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		if promoCode.ExpiresAt != nil && !now.Before(*promoCode.ExpiresAt) {
+			return ErrExpired
+		}
+		if promoCode.MaxRedemptions > 0 && promoCode.Redemptions >= promoCode.MaxRedemptions {
+			return ErrFullyRedeemed
+		}
+
+		var redeemed int64
+		if err := tx.Model(&Redemption{}).Where("promo_code_id = ? AND user_id = ?", promoCode.ID, u.ID).Count(&redeemed).Error; err != nil {
+			return err
+		}
+		if redeemed > 0 {
+			return ErrAlreadyRedeemed
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(u, u.ID).Error; err != nil {
+			return err
+		}
+
+		redemption = &Redemption{
+			PromoCodeID: promoCode.ID,
+			UserID:      u.ID,
+			BonusTime:   promoCode.BonusTime,
+		}
+
+		if promoCode.UpgradeTypeID != 0 {
+			var active int64
+			if err := tx.Model(&Redemption{}).Where("user_id = ? AND upgrade_ends_at IS NOT NULL AND reverted = ?", u.ID, false).Count(&active).Error; err != nil {
+				return err
+			}
+			if active > 0 {
+				return ErrUpgradeActive
+			}
+
+			upgradeEndsAt := now.AddDate(0, 0, promoCode.UpgradeDays)
+			redemption.UpgradeTypeID = promoCode.UpgradeTypeID
+			redemption.PreviousTypeID = u.TypeID
+			redemption.UpgradeEndsAt = &upgradeEndsAt
+
+			if err := tx.Model(u).UpdateColumn("type_id", promoCode.UpgradeTypeID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(redemption).Error; err != nil {
+			return err
+		}
+
+		if promoCode.BonusTime > 0 {
+			if err := user.Credit(tx, u, promoCode.BonusTime, user.BonusReasonPromo, promoCode.Code); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&promoCode).UpdateColumn("redemptions", gorm.Expr("redemptions + 1")).Error
+	})
+
+	return redemption, err
+}
+
+// ExpireUpgrades reverts plans of users whose temporary upgrade ended, returns number of upgrades reverted.
+// Users who changed plan since the upgrade keep their plan.
+func ExpireUpgrades(db *gorm.DB, now time.Time) (int, error) {
+	var redemptions []Redemption
+	if err := db.Where("upgrade_ends_at <= ? AND reverted = ?", now, false).Find(&redemptions).Error; err != nil {
+		return 0, err
+	}
+
+	var reverted int
+	for _, redemption := range redemptions {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			res := tx.Model(&Redemption{}).Where("id = ? AND reverted = ?", redemption.ID, false).UpdateColumn("reverted", true)
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error
+			}
+			reverted++
+
+			return tx.Model(&user.User{}).Where("id = ? AND type_id = ?", redemption.UserID, redemption.UpgradeTypeID).
+				UpdateColumn("type_id", redemption.PreviousTypeID).Error
+		}); err != nil {
+			return reverted, err
+		}
+	}
+
+	return reverted, nil
+}
+
+// RedeemCode function applies a promo code to the current user
+// @Summary Redeem promo code
+// @Description Redeem promo code for bonus time and/or a temporary plan upgrade
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param promo body RedeemPromoCode true "Redeem promo code"
+// @Success 200 {object} response.HTTP{data=ResponseRedeem}
+// @Security ApiKeyAuth
+// @Router /v1/promo-codes/redeem [post]
+func RedeemCode(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	redeem := new(RedeemPromoCode)
+	if err := c.BodyParser(&redeem); err != nil {
+		return apperror.Invalid("body_invalid", err.Error())
+	}
+
+	if err := validation.Struct(redeem); err != nil {
+		return err
+	}
+
+	currentUser, err := user.Current(c, user.NewRepository(db))
+	if err != nil {
+		return err
+	}
+
+	redemption, err := Redeem(db, redeem.Code, currentUser, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := db.Preload("Type").First(&currentUser, currentUser.ID).Error; err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data: ResponseRedeem{
+			Redemption: *redemption,
+			User:       currentUser.Private(),
+		},
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("Success redeem promo code %s.", Normalize(redeem.Code)),
+	})
+}
@@ -0,0 +1,14 @@
+package promo
+
+import "github.com/dinopuguh/mycap-backend/services/user"
+
+// RedeemPromoCode is a data transfer object for redeeming promo code
+type RedeemPromoCode struct {
+	Code string `json:"code" validate:"required,max=32" example:"LAUNCH2020"`
+}
+
+// ResponseRedeem represents response body for redeemed promo code
+type ResponseRedeem struct {
+	Redemption Redemption       `json:"redemption"`
+	User       user.PrivateUser `json:"user"`
+}
@@ -0,0 +1,155 @@
+package promo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/database/databasetest"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/routes"
+	"github.com/dinopuguh/mycap-backend/services/promo"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedeem(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	app := routes.New(cfg)
+	db := database.DBConn
+
+	request := func(method, endpoint, token string, data interface{}) (*response.HTTP, string) {
+		reqBody, _ := json.Marshal(data)
+		req, _ := http.NewRequest(method, endpoint, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resHTTP := new(response.HTTP)
+		res, _ := app.Test(req, -1)
+		defer res.Body.Close()
+		resBody, _ := ioutil.ReadAll(res.Body)
+		json.Unmarshal(resBody, &resHTTP)
+
+		return resHTTP, string(resBody)
+	}
+
+	register := func(username string) *user.ResponseAuth {
+		resHTTP, _ := request(http.MethodPost, "/api/v1/register", "", user.RegisterUser{
+			Name:     username,
+			Email:    username + "@mycap.com",
+			Username: username,
+			Password: "s3cr3tp45sw0rd",
+		})
+		auth := new(user.ResponseAuth)
+		authJSON, _ := json.Marshal(resHTTP.Data)
+		json.Unmarshal(authJSON, &auth)
+
+		return auth
+	}
+
+	redeemer := register("dinoredeemer")
+	other := register("dinoredeemer2")
+	defer func() {
+		for _, auth := range []*user.ResponseAuth{redeemer, other} {
+			request(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", auth.User.ID), auth.AccessToken, nil)
+		}
+	}()
+
+	expired := time.Now().Add(-time.Hour)
+	promoCodes := []promo.PromoCode{
+		{Code: "TESTMINUTES", BonusTime: 600000},
+		{Code: "TESTEXPIRED", BonusTime: 600000, ExpiresAt: &expired},
+		{Code: "TESTONCE", BonusTime: 600000, MaxRedemptions: 1},
+		{Code: "TESTUPGRADE", UpgradeTypeID: 2, UpgradeDays: 7},
+	}
+	for i := range promoCodes {
+		db.Create(&promoCodes[i])
+	}
+	defer func() {
+		for _, promoCode := range promoCodes {
+			db.Unscoped().Where("promo_code_id = ?", promoCode.ID).Delete(&promo.Redemption{})
+			db.Unscoped().Delete(&promoCode)
+		}
+	}()
+
+	type args struct {
+		code       string
+		token      string
+		statusCode int
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{"Promo code not specified", args{
+			token:      redeemer.AccessToken,
+			statusCode: http.StatusBadRequest,
+		}},
+		{"Promo code not found", args{
+			code:       "NOPE",
+			token:      redeemer.AccessToken,
+			statusCode: http.StatusNotFound,
+		}},
+		{"Promo code expired", args{
+			code:       "testexpired",
+			token:      redeemer.AccessToken,
+			statusCode: http.StatusBadRequest,
+		}},
+		{"Valid redeem bonus minutes", args{
+			code:       "testminutes",
+			token:      redeemer.AccessToken,
+			statusCode: http.StatusOK,
+		}},
+		{"Already redeemed", args{
+			code:       "TESTMINUTES",
+			token:      redeemer.AccessToken,
+			statusCode: http.StatusConflict,
+		}},
+		{"Valid redeem once", args{
+			code:       "TESTONCE",
+			token:      redeemer.AccessToken,
+			statusCode: http.StatusOK,
+		}},
+		{"Fully redeemed", args{
+			code:       "TESTONCE",
+			token:      other.AccessToken,
+			statusCode: http.StatusBadRequest,
+		}},
+		{"Valid redeem upgrade", args{
+			code:       "TESTUPGRADE",
+			token:      redeemer.AccessToken,
+			statusCode: http.StatusOK,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resHTTP, resBody := request(http.MethodPost, "/api/v1/promo-codes/redeem", tt.args.token, promo.RedeemPromoCode{
+				Code: tt.args.code,
+			})
+			assert.Equalf(t, tt.args.statusCode, resHTTP.Status, resBody)
+		})
+	}
+
+	var redeemed user.User
+	db.First(&redeemed, redeemer.User.ID)
+	assert.Equal(t, int64(1200000), redeemed.BonusTime)
+	assert.Equal(t, uint(2), redeemed.TypeID)
+
+	t.Run("Expire upgrade", func(t *testing.T) {
+		reverted, err := promo.ExpireUpgrades(db, time.Now().AddDate(0, 0, 8))
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, reverted, 1)
+
+		db.First(&redeemed, redeemer.User.ID)
+		assert.Equal(t, redeemer.User.TypeID, redeemed.TypeID)
+		assert.Equal(t, int64(1200000), redeemed.BonusTime, "bonus time is kept")
+	})
+}
@@ -0,0 +1,218 @@
+package session
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/auth"
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Session is a model for user's login on a device
+type Session struct {
+	gorm.Model
+	UserID     uint      `json:"user_id" gorm:"index;"`
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current" gorm:"-"`
+}
+
+// HeaderDeviceName is a request header to name the device signing in
+const HeaderDeviceName = "X-Device-Name"
+
+const (
+	localsSession    = "session"
+	lastSeenInterval = time.Minute
+)
+
+// New creates a session for an user signing in from the request
+func New(c *fiber.Ctx, db *gorm.DB, userID uint) (*Session, error) {
+	deviceName := c.Get(HeaderDeviceName)
+	if deviceName == "" {
+		deviceName = c.Get(fiber.HeaderUserAgent)
+	}
+
+	session := &Session{
+		UserID:     userID,
+		DeviceName: deviceName,
+		UserAgent:  c.Get(fiber.HeaderUserAgent),
+		IP:         c.IP(),
+		LastSeenAt: time.Now(),
+	}
+	if err := db.Create(session).Error; err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Store creates sessions in database
+type Store struct {
+	DB *gorm.DB
+}
+
+// New creates a session for an user signing in from the request
+func (s Store) New(c *fiber.Ctx, userID uint) (*Session, error) {
+	return New(c, s.DB.WithContext(logger.Context(c)), userID)
+}
+
+// RevokeAll revokes sessions of an user except the session keepID
+func (s Store) RevokeAll(c *fiber.Ctx, userID, keepID uint) error {
+	return RevokeAll(s.DB.WithContext(logger.Context(c)), userID, keepID)
+}
+
+// Verify is a middleware that rejects JWT of revoked sessions and tracks session's last seen
+func Verify(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	token := c.Locals("user").(*jwt.Token)
+	claims := token.Claims.(jwt.MapClaims)
+	sid, ok := claims["sid"].(float64)
+	if !ok {
+		return apperror.Unauthorized("session_not_found", "Session not found.")
+	}
+
+	var session Session
+	if err := db.First(&session, uint(sid)).Error; err != nil {
+		if apperror.IsNotFound(err) {
+			return apperror.Unauthorized("session_revoked", "Session has been revoked.")
+		}
+		return err
+	}
+
+	if time.Since(session.LastSeenAt) > lastSeenInterval {
+		session.LastSeenAt = time.Now()
+		if err := db.Model(&session).UpdateColumn("last_seen_at", session.LastSeenAt).Error; err != nil {
+			return err
+		}
+	}
+
+	SetCurrent(c, &session)
+	auth.SetUser(c, session.UserID)
+
+	return c.Next()
+}
+
+// SetCurrent sets the session the request is signed in with
+func SetCurrent(c *fiber.Ctx, session *Session) {
+	c.Locals(localsSession, session)
+}
+
+// Current returns the session of the request, nil when the request is not signed with JWT
+func Current(c *fiber.Ctx) *Session {
+	session, _ := c.Locals(localsSession).(*Session)
+	return session
+}
+
+// GetAll is a function to get all active sessions of the current user
+// @Summary Get all sessions
+// @Description Get all active sessions and devices of the current user
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.HTTP{data=[]Session}
+// @Security ApiKeyAuth
+// @Router /v1/sessions [get]
+func GetAll(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	current := Current(c)
+	if current == nil {
+		return apperror.Forbidden("api_key_not_allowed", "Sessions can't be managed with an API key.")
+	}
+
+	var sessions []Session
+	if res := db.Where("user_id = ?", current.UserID).Order("last_seen_at desc").Find(&sessions); res.Error != nil {
+		return res.Error
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current.ID
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    sessions,
+		Status:  http.StatusOK,
+		Message: "Success get all sessions.",
+	})
+}
+
+// Revoke function signs out a session of the current user by ID
+// @Summary Revoke session by ID
+// @Description Revoke session by ID
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param id path int true "Session ID"
+// @Success 200 {object} response.HTTP
+// @Security ApiKeyAuth
+// @Router /v1/sessions/{id} [delete]
+func Revoke(c *fiber.Ctx) error {
+	id := c.Params("id")
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	current := Current(c)
+	if current == nil {
+		return apperror.Forbidden("api_key_not_allowed", "Sessions can't be managed with an API key.")
+	}
+
+	var session Session
+	if err := db.Where("user_id = ?", current.UserID).First(&session, id).Error; err != nil {
+		if apperror.IsNotFound(err) {
+			return apperror.NotFound("session_not_found", "Session with ID %v not found.", id)
+		}
+		return err
+	}
+
+	if err := db.Delete(&session).Error; err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Status:  http.StatusOK,
+		Message: "Success revoke session.",
+	})
+}
+
+// RevokeOthers function signs out all sessions of the current user except the current one
+// @Summary Revoke all other sessions
+// @Description Revoke all sessions except the current one
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.HTTP
+// @Security ApiKeyAuth
+// @Router /v1/sessions [delete]
+func RevokeOthers(c *fiber.Ctx) error {
+	db := database.DBConn.WithContext(logger.Context(c))
+
+	current := Current(c)
+	if current == nil {
+		return apperror.Forbidden("api_key_not_allowed", "Sessions can't be managed with an API key.")
+	}
+
+	if err := RevokeAll(db, current.UserID, current.ID); err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Status:  http.StatusOK,
+		Message: "Success revoke all other sessions.",
+	})
+}
+
+// RevokeAll signs out all sessions of an user except the kept session
+func RevokeAll(db *gorm.DB, userID, keepID uint) error {
+	return db.Where("user_id = ? AND id <> ?", userID, keepID).Delete(&Session{}).Error
+}
@@ -0,0 +1,135 @@
+package session_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/database/databasetest"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/routes"
+	"github.com/dinopuguh/mycap-backend/services/session"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	phone        *user.ResponseAuth
+	laptop       *user.ResponseAuth
+	registerUser = user.RegisterUser{
+		Name:     "Dino Session",
+		Email:    "dinosession@mycap.com",
+		Username: "dinosession",
+		Password: "s3cr3tp45sw0rd",
+		TypeID:   1,
+	}
+)
+
+func login(app *fiber.App, endpoint string, data interface{}, deviceName string) *user.ResponseAuth {
+	reqBody, _ := json.Marshal(data)
+	req, _ := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(session.HeaderDeviceName, deviceName)
+
+	resHTTP := new(response.HTTP)
+	auth := new(user.ResponseAuth)
+	res, _ := app.Test(req, -1)
+	defer res.Body.Close()
+	resBody, _ := ioutil.ReadAll(res.Body)
+	json.Unmarshal(resBody, &resHTTP)
+	authJSON, _ := json.Marshal(resHTTP.Data)
+	json.Unmarshal(authJSON, &auth)
+
+	return auth
+}
+
+func TestGetAll(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	app := routes.New(cfg)
+
+	phone = login(app, "/api/v1/register", registerUser, "Dino's phone")
+	laptop = login(app, "/api/v1/login", user.LoginUser{
+		Email:    registerUser.Email,
+		Password: registerUser.Password,
+	}, "Dino's laptop")
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+laptop.AccessToken)
+
+	resHTTP := new(response.HTTP)
+	res, _ := app.Test(req, -1)
+	defer res.Body.Close()
+	resBody, _ := ioutil.ReadAll(res.Body)
+	json.Unmarshal(resBody, &resHTTP)
+
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, string(resBody))
+
+	var sessions []session.Session
+	sessionsJSON, _ := json.Marshal(resHTTP.Data)
+	json.Unmarshal(sessionsJSON, &sessions)
+
+	assert.Len(t, sessions, 2)
+	for _, s := range sessions {
+		assert.Equal(t, s.DeviceName == "Dino's laptop", s.Current)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	app := routes.New(cfg)
+
+	type args struct {
+		method     string
+		endpoint   string
+		token      string
+		statusCode int
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{"Valid revoke other sessions", args{
+			method:     http.MethodDelete,
+			endpoint:   "/api/v1/sessions",
+			token:      laptop.AccessToken,
+			statusCode: http.StatusOK,
+		}},
+		{"Revoked session rejected", args{
+			method:     http.MethodGet,
+			endpoint:   "/api/v1/sessions",
+			token:      phone.AccessToken,
+			statusCode: http.StatusUnauthorized,
+		}},
+		{"Session not found", args{
+			method:     http.MethodDelete,
+			endpoint:   "/api/v1/sessions/0",
+			token:      laptop.AccessToken,
+			statusCode: http.StatusNotFound,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.args.method, tt.args.endpoint, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.args.token)
+
+			resHTTP := new(response.HTTP)
+			res, _ := app.Test(req, -1)
+			defer res.Body.Close()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			json.Unmarshal(resBody, &resHTTP)
+
+			assert.Equalf(t, tt.args.statusCode, resHTTP.Status, string(resBody))
+		})
+	}
+
+	endpoint := fmt.Sprintf("/api/v1/users/%d", laptop.User.ID)
+	reqDeleteUser, _ := http.NewRequest(http.MethodDelete, endpoint, nil)
+	reqDeleteUser.Header.Set("Authorization", "Bearer "+laptop.AccessToken)
+	app.Test(reqDeleteUser, -1)
+}
@@ -0,0 +1,118 @@
+package user
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/pagination"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// adminUserSortColumns sorts user listings of admins, remaining time isn't public
+var adminUserSortColumns = map[string]string{
+	"name":           "name",
+	"username":       "username",
+	"created_at":     "created_at",
+	"remaining_time": "remaining_time",
+}
+
+// AdminGetAll function lists users with their remaining time for operators, users are filtered
+// like GetAll and by time limit status, and can be sorted by remaining time
+func (h *Handler) AdminGetAll(c *fiber.Ctx) error {
+	page, err := pagination.Parse(c)
+	if err != nil {
+		return err
+	}
+
+	order, err := pagination.Sort(c.Query("sort"), adminUserSortColumns, "id asc")
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		return err
+	}
+
+	if reachedTimeLimit := c.Query("reached_time_limit"); reachedTimeLimit != "" {
+		reached, err := strconv.ParseBool(reachedTimeLimit)
+		if err != nil {
+			return apperror.Invalid("reached_time_limit_invalid", "Reached time limit invalid.")
+		}
+		filter.ReachedTimeLimit = &reached
+	}
+
+	users, total, err := h.users(c).List(filter, page, order)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    page.Response(PrivateUsers(users), len(users), total),
+		Status:  http.StatusOK,
+		Message: "Success get all users.",
+	})
+}
+
+// AdminUpdate function edits the plan and remaining time of an user by ID for operators
+func (h *Handler) AdminUpdate(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	updatedUser := new(AdminUpdateUser)
+	if err := c.BodyParser(&updatedUser); err != nil {
+		return apperror.Invalid("body_invalid", err.Error())
+	}
+
+	if err := validation.Struct(updatedUser); err != nil {
+		return err
+	}
+
+	userID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return apperror.NotFound("user_not_found", "User with ID %v not found.", id)
+	}
+
+	user, err := h.users(c).FindByID(uint(userID))
+	if err != nil {
+		if apperror.IsNotFound(err) {
+			return apperror.NotFound("user_not_found", "User with ID %v not found.", id)
+		}
+		return err
+	}
+
+	if updatedUser.TypeID != 0 {
+		userType, err := h.types(c).FindByID(updatedUser.TypeID)
+		if err != nil {
+			if apperror.IsNotFound(err) {
+				return apperror.NotFound("user_type_not_found", "User type with ID %v not found.", updatedUser.TypeID)
+			}
+			return err
+		}
+
+		if user.TypeID != updatedUser.TypeID {
+			user.StartCycle(time.Now())
+		}
+
+		user.TypeID = updatedUser.TypeID
+		user.Type = *userType
+	}
+
+	user.ReachedTimeLimit = updatedUser.ReachedTimeLimit
+	user.RemainingTime = updatedUser.RemainingTime
+
+	if err := h.users(c).Save(user); err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    user.Private(),
+		Status:  http.StatusOK,
+		Message: "Success update user.",
+	})
+}
@@ -3,17 +3,18 @@ package user
 import (
 	"net/http"
 
+	"github.com/dinopuguh/mycap-backend/apperror"
 	"github.com/dinopuguh/mycap-backend/auth"
-	"github.com/dinopuguh/mycap-backend/database"
 	"github.com/dinopuguh/mycap-backend/helpers"
 	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/validation"
 	"github.com/gofiber/fiber/v2"
 )
 
 // ResponseAuth represents response body for authenticated user
 type ResponseAuth struct {
-	User        User   `json:"user"`
-	AccessToken string `json:"access_token"`
+	User        PrivateUser `json:"user"`
+	AccessToken string      `json:"access_token"`
 }
 
 // New registers a new user data
@@ -25,50 +26,51 @@ type ResponseAuth struct {
 // @Param user body RegisterUser true "Register user"
 // @Success 200 {object} response.HTTP{data=ResponseAuth}
 // @Router /v1/register [post]
-func New(c *fiber.Ctx) error {
-	db := database.DBConn
-
+func (h *Handler) New(c *fiber.Ctx) error {
 	registerUser := new(RegisterUser)
 	if err := c.BodyParser(&registerUser); err != nil {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusBadRequest,
-			Message: err.Error(),
-		})
+		return apperror.Invalid("body_invalid", err.Error())
+	}
+
+	if err := validation.Struct(registerUser); err != nil {
+		return err
 	}
 
 	if registerUser.TypeID == 0 {
 		registerUser.TypeID = 1
 	}
 
-	userType := new(Type)
-	if err := db.First(&userType, registerUser.TypeID).Error; err != nil {
-		switch err.Error() {
-		case "record not found":
-			return c.JSON(response.HTTP{
-				Status:  http.StatusBadRequest,
-				Message: "User type with this ID not exist.",
-			})
-		default:
-			return c.JSON(response.HTTP{
-				Status:  http.StatusServiceUnavailable,
-				Message: err.Error(),
-			})
+	userType, err := h.types(c).FindByID(registerUser.TypeID)
+	if err != nil {
+		if apperror.IsNotFound(err) {
+			return apperror.Invalid("user_type_not_found", "User type with this ID not exist.")
 		}
+		return err
 	}
 
-	user := new(User)
-	if res := db.Where("email = ?", registerUser.Email).First(&user); res.RowsAffected > 0 {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusBadRequest,
-			Message: "User with this email is already exist.",
-		})
+	if _, err := h.users(c).FindByEmail(registerUser.Email); err == nil {
+		return apperror.Invalid("email_taken", "User with this email is already exist.")
+	} else if !apperror.IsNotFound(err) {
+		return err
 	}
 
-	if res := db.Where("username = ?", registerUser.Username).First(&user); res.RowsAffected > 0 {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusBadRequest,
-			Message: "User with this username is already exist.",
-		})
+	if _, err := h.users(c).FindByUsername(registerUser.Username); err == nil {
+		return apperror.Invalid("username_taken", "User with this username is already exist.")
+	} else if !apperror.IsNotFound(err) {
+		return err
+	}
+
+	user := new(User)
+	var referrer *User
+	if registerUser.ReferralCode != "" {
+		referrer, err = h.users(c).FindByReferralCode(registerUser.ReferralCode)
+		if err != nil {
+			if apperror.IsNotFound(err) {
+				return apperror.Invalid("referral_code_invalid", "Referral code invalid.")
+			}
+			return err
+		}
+		user.ReferredByID = &referrer.ID
 	}
 
 	user.Name = registerUser.Name
@@ -76,29 +78,29 @@ func New(c *fiber.Ctx) error {
 	user.Username = registerUser.Username
 	user.Type = *userType
 
-	var err error
 	user.Password, err = helpers.HashPassword(registerUser.Password)
 	if err != nil {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusServiceUnavailable,
-			Message: err.Error(),
-		})
+		return err
 	}
 
-	db.Create(user)
+	if err := h.users(c).Create(user, referrer); err != nil {
+		return err
+	}
 
-	token, err := auth.GenerateJWT(user.Name, user.Email)
+	userSession, err := h.Sessions.New(c, user.ID)
 	if err != nil {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusServiceUnavailable,
-			Message: err.Error(),
-		})
+		return err
+	}
+
+	token, err := auth.GenerateJWT(user.Name, user.Email, userSession.ID)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(response.HTTP{
 		Success: true,
 		Data: ResponseAuth{
-			User:        *user,
+			User:        user.Private(),
 			AccessToken: token,
 		},
 		Status:  http.StatusOK,
@@ -115,44 +117,42 @@ func New(c *fiber.Ctx) error {
 // @Param user body LoginUser true "User login"
 // @Success 200 {object} response.HTTP{data=ResponseAuth}
 // @Router /v1/login [post]
-func Login(c *fiber.Ctx) error {
-	db := database.DBConn
-
+func (h *Handler) Login(c *fiber.Ctx) error {
 	login := new(LoginUser)
 	if err := c.BodyParser(&login); err != nil {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusBadRequest,
-			Message: err.Error(),
-		})
+		return apperror.Invalid("body_invalid", err.Error())
+	}
+
+	if err := validation.Struct(login); err != nil {
+		return err
 	}
 
-	var user User
-	if res := db.Preload("Type").Where("email = ?", login.Email).First(&user); res.RowsAffected == 0 {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusNotFound,
-			Message: "User with this email not found.",
-		})
+	user, err := h.users(c).FindByEmail(login.Email)
+	if err != nil {
+		if apperror.IsNotFound(err) {
+			return apperror.NotFound("user_not_found", "User with this email not found.")
+		}
+		return err
 	}
 
 	if !helpers.CheckPasswordHash(login.Password, user.Password) {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusUnauthorized,
-			Message: "Password incorrect.",
-		})
+		return apperror.Unauthorized("password_incorrect", "Password incorrect.")
+	}
+
+	userSession, err := h.Sessions.New(c, user.ID)
+	if err != nil {
+		return err
 	}
 
-	token, err := auth.GenerateJWT(user.Name, user.Email)
+	token, err := auth.GenerateJWT(user.Name, user.Email, userSession.ID)
 	if err != nil {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusServiceUnavailable,
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(response.HTTP{
 		Success: true,
 		Data: ResponseAuth{
-			User:        user,
+			User:        user.Private(),
 			AccessToken: token,
 		},
 		Status:  http.StatusOK,
@@ -0,0 +1,160 @@
+package user
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/config"
+	"github.com/dinopuguh/mycap-backend/pagination"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// BonusEntry is a model for ledger of bonus time credited to or consumed by an user, bonus time
+// is kept apart from the monthly allowance so billing cycle resets don't wipe it
+type BonusEntry struct {
+	gorm.Model
+	UserID    uint   `json:"user_id" gorm:"index;"`
+	Amount    int64  `json:"amount"` // milliseconds, negative when consumed
+	Reason    string `json:"reason"`
+	Reference string `json:"reference"`
+}
+
+const (
+	// BonusReasonPromo is a bonus credited by redeeming a promo code
+	BonusReasonPromo = "promo"
+	// BonusReasonReferral is a bonus credited for a referral on registration
+	BonusReasonReferral = "referral"
+	// BonusReasonUsage is bonus time consumed by a group session after the monthly allowance ran out
+	BonusReasonUsage = "usage"
+)
+
+// ReferralBonus is bonus time in milliseconds credited to both the referrer and the new user
+var ReferralBonus = referralBonus(config.Default())
+
+// appURL is the web app linked from emails
+var appURL string
+
+// Configure sets the referral bonus and the web app linked from emails
+func Configure(cfg config.Config) {
+	ReferralBonus = referralBonus(cfg)
+	appURL = cfg.AppURL
+}
+
+func referralBonus(cfg config.Config) int64 {
+	return (time.Duration(cfg.Balance.ReferralBonusMinutes) * time.Minute).Milliseconds()
+}
+
+// Balance returns the time an user can still use, the monthly allowance left plus bonus time
+func (u User) Balance() int64 {
+	return u.RemainingTime + u.BonusTime
+}
+
+// Deduct takes time used from the monthly allowance first and then from bonus time,
+// it returns the bonus time taken
+func (u *User) Deduct(used int64) int64 {
+	if used < 0 {
+		used = 0
+	}
+
+	fromAllowance := used
+	if fromAllowance > u.RemainingTime {
+		fromAllowance = u.RemainingTime
+	}
+	if fromAllowance < 0 {
+		fromAllowance = 0
+	}
+	fromBonus := used - fromAllowance
+	if fromBonus > u.BonusTime {
+		fromBonus = u.BonusTime
+	}
+
+	u.RemainingTime -= fromAllowance
+	u.BonusTime -= fromBonus
+	u.ReachedTimeLimit = u.Balance() <= 0
+
+	return fromBonus
+}
+
+// Credit adds bonus time to an user and records it in the ledger
+func Credit(tx *gorm.DB, u *User, amount int64, reason, reference string) error {
+	entry := BonusEntry{
+		UserID:    u.ID,
+		Amount:    amount,
+		Reason:    reason,
+		Reference: reference,
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&User{}).Where("id = ?", u.ID).UpdateColumns(map[string]interface{}{
+		"bonus_time":         gorm.Expr("bonus_time + ?", amount),
+		"reached_time_limit": false,
+	}).Error; err != nil {
+		return err
+	}
+	u.BonusTime += amount
+	u.ReachedTimeLimit = false
+
+	return nil
+}
+
+// Consume deducts time used from the monthly allowance first and then from bonus time,
+// the user row should be locked by the transaction
+func Consume(tx *gorm.DB, u *User, used int64, reference string) error {
+	fromBonus := u.Deduct(used)
+	if fromBonus > 0 {
+		entry := BonusEntry{
+			UserID:    u.ID,
+			Amount:    -fromBonus,
+			Reason:    BonusReasonUsage,
+			Reference: reference,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&User{}).Where("id = ?", u.ID).UpdateColumns(map[string]interface{}{
+		"remaining_time":     u.RemainingTime,
+		"bonus_time":         u.BonusTime,
+		"reached_time_limit": u.ReachedTimeLimit,
+	}).Error
+}
+
+// GetBonus is a function to get the bonus time ledger of the current user
+// @Summary Get bonus time ledger
+// @Description Get bonus time credited to and consumed by the current user, newest first
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param limit query int false "Page size"
+// @Param cursor query string false "Next page cursor"
+// @Success 200 {object} response.HTTP{data=pagination.Response{items=[]BonusEntry}}
+// @Security ApiKeyAuth
+// @Router /v1/account/bonus [get]
+func (h *Handler) GetBonus(c *fiber.Ctx) error {
+	page, err := pagination.Parse(c)
+	if err != nil {
+		return err
+	}
+
+	currentUser, err := Current(c, h.users(c))
+	if err != nil {
+		return err
+	}
+
+	entries, total, err := h.users(c).ListBonus(currentUser.ID, page)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    page.Response(entries, len(entries), total),
+		Status:  http.StatusOK,
+		Message: "Success get bonus time.",
+	})
+}
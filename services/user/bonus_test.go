@@ -0,0 +1,127 @@
+package user_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/database/databasetest"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/routes"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestReferral(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	app := routes.New(cfg)
+
+	request := func(method, endpoint, token string, data interface{}) (*response.HTTP, string) {
+		reqBody, _ := json.Marshal(data)
+		req, _ := http.NewRequest(method, endpoint, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resHTTP := new(response.HTTP)
+		res, _ := app.Test(req, -1)
+		defer res.Body.Close()
+		resBody, _ := ioutil.ReadAll(res.Body)
+		json.Unmarshal(resBody, &resHTTP)
+
+		return resHTTP, string(resBody)
+	}
+
+	register := func(username, referralCode string) (*user.ResponseAuth, *response.HTTP, string) {
+		resHTTP, resBody := request(http.MethodPost, "/api/v1/register", "", user.RegisterUser{
+			Name:         username,
+			Email:        username + "@mycap.com",
+			Username:     username,
+			Password:     "s3cr3tp45sw0rd",
+			ReferralCode: referralCode,
+		})
+		auth := new(user.ResponseAuth)
+		authJSON, _ := json.Marshal(resHTTP.Data)
+		json.Unmarshal(authJSON, &auth)
+
+		return auth, resHTTP, resBody
+	}
+
+	referrer, _, _ := register("dinoreferrer", "")
+	defer request(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", referrer.User.ID), referrer.AccessToken, nil)
+	assert.NotEmpty(t, referrer.User.ReferralCode)
+
+	t.Run("Referral code invalid", func(t *testing.T) {
+		_, resHTTP, resBody := register("dinoreferred", "NOPE0000")
+		assert.Equalf(t, http.StatusBadRequest, resHTTP.Status, resBody)
+	})
+
+	t.Run("Valid referral", func(t *testing.T) {
+		referred, resHTTP, resBody := register("dinoreferred", referrer.User.ReferralCode)
+		defer request(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", referred.User.ID), referred.AccessToken, nil)
+
+		assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+		assert.Equal(t, user.ReferralBonus, referred.User.BonusTime)
+
+		var credited user.User
+		database.DBConn.First(&credited, referrer.User.ID)
+		assert.Equal(t, user.ReferralBonus, credited.BonusTime)
+
+		resHTTP, resBody = request(http.MethodGet, "/api/v1/account/bonus", referrer.AccessToken, nil)
+		assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+		assert.Contains(t, resBody, `"reason":"referral"`)
+		assert.Contains(t, resBody, `"reference":"dinoreferred"`)
+	})
+}
+
+func TestConsume(t *testing.T) {
+	databasetest.Connect(t)
+
+	db := database.DBConn
+	consumer := &user.User{Name: "Dino Consumer", Username: "dinoconsumer", Email: "dinoconsumer@mycap.com", TypeID: 1}
+	db.Create(consumer)
+	defer db.Unscoped().Delete(consumer)
+
+	db.Transaction(func(tx *gorm.DB) error {
+		return user.Credit(tx, consumer, 60000, user.BonusReasonPromo, "TEST")
+	})
+
+	tests := []struct {
+		name          string
+		remainingTime int64
+		used          int64
+		expectBonus   int64
+		expectRemain  int64
+		reached       bool
+	}{
+		{"From allowance", 100000, 40000, 60000, 60000, false},
+		{"Allowance then bonus", 60000, 90000, 30000, 0, false},
+		{"Run out", 0, 90000, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db.Model(consumer).UpdateColumn("remaining_time", tt.remainingTime)
+			consumer.RemainingTime = tt.remainingTime
+
+			err := db.Transaction(func(tx *gorm.DB) error {
+				return user.Consume(tx, consumer, tt.used, "group:0")
+			})
+			assert.NoError(t, err)
+
+			var consumed user.User
+			db.First(&consumed, consumer.ID)
+			assert.Equal(t, tt.expectRemain, consumed.RemainingTime)
+			assert.Equal(t, tt.expectBonus, consumed.BonusTime)
+			assert.Equal(t, tt.reached, consumed.ReachedTimeLimit)
+		})
+	}
+
+	db.Unscoped().Where("user_id = ?", consumer.ID).Delete(&user.BonusEntry{})
+}
@@ -0,0 +1,267 @@
+package user
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/helpers"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/mailer"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/services/session"
+	"github.com/dinopuguh/mycap-backend/validation"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// EmailChange is a model for pending user's email change
+type EmailChange struct {
+	gorm.Model
+	UserID    uint `gorm:"index;"`
+	NewEmail  string
+	TokenHash string `gorm:"uniqueIndex;"`
+	ExpiresAt time.Time
+}
+
+const emailChangeLifetime = 24 * time.Hour
+
+// UpdatePassword function changes the current user's password
+// @Summary Change password
+// @Description Change password of the current user, all other sessions are signed out
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param password body ChangePassword true "Change password"
+// @Success 200 {object} response.HTTP
+// @Security ApiKeyAuth
+// @Router /v1/account/password [put]
+func (h *Handler) UpdatePassword(c *fiber.Ctx) error {
+	current := session.Current(c)
+	if current == nil {
+		return apperror.Forbidden("api_key_not_allowed", "Password can't be changed with an API key.")
+	}
+
+	changePassword := new(ChangePassword)
+	if err := c.BodyParser(&changePassword); err != nil {
+		return apperror.Invalid("body_invalid", err.Error())
+	}
+
+	if err := validation.Struct(changePassword); err != nil {
+		return err
+	}
+
+	user, err := Current(c, h.users(c))
+	if err != nil {
+		return err
+	}
+
+	if !helpers.CheckPasswordHash(changePassword.CurrentPassword, user.Password) {
+		return apperror.Unauthorized("password_incorrect", "Password incorrect.")
+	}
+
+	user.Password, err = helpers.HashPassword(changePassword.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := h.users(c).UpdatePassword(user); err != nil {
+		return err
+	}
+
+	if err := h.Sessions.RevokeAll(c, user.ID, current.ID); err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Status:  http.StatusOK,
+		Message: "Success change password.",
+	})
+}
+
+// SetPassword function sets the first password of an user registered by an identity provider, who
+// signs in only with the provider until then
+// @Summary Set password
+// @Description Set password of the current user registered by an identity provider to also login with email
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param password body SetPassword true "Set password"
+// @Success 200 {object} response.HTTP
+// @Security ApiKeyAuth
+// @Router /v1/account/password [post]
+func (h *Handler) SetPassword(c *fiber.Ctx) error {
+	if session.Current(c) == nil {
+		return apperror.Forbidden("api_key_not_allowed", "Password can't be set with an API key.")
+	}
+
+	setPassword := new(SetPassword)
+	if err := c.BodyParser(&setPassword); err != nil {
+		return apperror.Invalid("body_invalid", err.Error())
+	}
+
+	if err := validation.Struct(setPassword); err != nil {
+		return err
+	}
+
+	user, err := Current(c, h.users(c))
+	if err != nil {
+		return err
+	}
+
+	if user.Password != "" {
+		return apperror.Invalid("password_already_set", "Password is already set, change it with the current password.")
+	}
+
+	user.Password, err = helpers.HashPassword(setPassword.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := h.users(c).UpdatePassword(user); err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Status:  http.StatusOK,
+		Message: "Success set password.",
+	})
+}
+
+// UpdateEmail function requests an email change confirmed from the new address
+// @Summary Change email
+// @Description Send confirmation to the new email of the current user
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param email body ChangeEmail true "Change email"
+// @Success 200 {object} response.HTTP
+// @Security ApiKeyAuth
+// @Router /v1/account/email [put]
+func (h *Handler) UpdateEmail(c *fiber.Ctx) error {
+	if session.Current(c) == nil {
+		return apperror.Forbidden("api_key_not_allowed", "Email can't be changed with an API key.")
+	}
+
+	changeEmail := new(ChangeEmail)
+	if err := c.BodyParser(&changeEmail); err != nil {
+		return apperror.Invalid("body_invalid", err.Error())
+	}
+
+	if err := validation.Struct(changeEmail); err != nil {
+		return err
+	}
+
+	user, err := Current(c, h.users(c))
+	if err != nil {
+		return err
+	}
+
+	if !helpers.CheckPasswordHash(changeEmail.Password, user.Password) {
+		return apperror.Unauthorized("password_incorrect", "Password incorrect.")
+	}
+
+	_, err = h.users(c).FindByEmail(changeEmail.NewEmail)
+	if err == nil {
+		return apperror.Invalid("email_taken", "User with this email is already exist.")
+	}
+	if !apperror.IsNotFound(err) {
+		return err
+	}
+
+	token, err := helpers.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	emailChange := &EmailChange{
+		UserID:    user.ID,
+		NewEmail:  changeEmail.NewEmail,
+		TokenHash: helpers.HashToken(token),
+		ExpiresAt: time.Now().Add(emailChangeLifetime),
+	}
+	if err := h.users(c).CreateEmailChange(emailChange); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nConfirm your new MyCap email with this token: %s\n%s/confirm-email?token=%s\n\nThe token expires in 24 hours.",
+		user.Name, token, appURL, token)
+	if err := mailer.Send(logger.Context(c), changeEmail.NewEmail, "Confirm your new MyCap email", body); err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Status:  http.StatusOK,
+		Message: "Confirmation sent to the new email.",
+	})
+}
+
+// ConfirmUpdateEmail function applies a pending email change
+// @Summary Confirm email change
+// @Description Confirm email change with token sent to the new email, all sessions are signed out
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param token body ConfirmEmail true "Confirm email"
+// @Success 200 {object} response.HTTP{data=PrivateUser}
+// @Router /v1/account/email/confirm [post]
+func (h *Handler) ConfirmUpdateEmail(c *fiber.Ctx) error {
+	confirmEmail := new(ConfirmEmail)
+	if err := c.BodyParser(&confirmEmail); err != nil {
+		return apperror.Invalid("body_invalid", err.Error())
+	}
+
+	if err := validation.Struct(confirmEmail); err != nil {
+		return err
+	}
+
+	emailChange, err := h.users(c).TakeEmailChange(helpers.HashToken(confirmEmail.Token))
+	if err != nil {
+		if apperror.IsNotFound(err) {
+			return apperror.NotFound("email_confirmation_not_found", "Email confirmation not found.")
+		}
+		return err
+	}
+
+	if time.Now().After(emailChange.ExpiresAt) {
+		return apperror.Invalid("email_confirmation_expired", "Email confirmation expired.")
+	}
+
+	_, err = h.users(c).FindByEmail(emailChange.NewEmail)
+	if err == nil {
+		return apperror.Invalid("email_taken", "User with this email is already exist.")
+	}
+	if !apperror.IsNotFound(err) {
+		return err
+	}
+
+	user, err := h.users(c).FindByID(emailChange.UserID)
+	if err != nil {
+		if apperror.IsNotFound(err) {
+			return apperror.NotFound("user_not_found", "User with ID %v not found.", emailChange.UserID)
+		}
+		return err
+	}
+
+	confirmedAt := time.Now()
+	user.Email = emailChange.NewEmail
+	user.EmailConfirmedAt = &confirmedAt
+	if err := h.users(c).UpdateEmail(user); err != nil {
+		return err
+	}
+
+	if err := h.Sessions.RevokeAll(c, user.ID, 0); err != nil {
+		return err
+	}
+
+	return c.JSON(response.HTTP{
+		Success: true,
+		Data:    user.Private(),
+		Status:  http.StatusOK,
+		Message: "Success change email, please login again.",
+	})
+}
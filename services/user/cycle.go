@@ -0,0 +1,120 @@
+package user
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CycleReset is a model for record of an user's remaining time reset at the start of a billing cycle
+type CycleReset struct {
+	gorm.Model
+	UserID                uint      `json:"user_id" gorm:"uniqueIndex:idx_cycle_resets_user_cycle;"`
+	CycleStart            time.Time `json:"cycle_start" gorm:"uniqueIndex:idx_cycle_resets_user_cycle;"`
+	PreviousRemainingTime int64     `json:"previous_remaining_time"`
+	RemainingTime         int64     `json:"remaining_time"`
+}
+
+// cycleBatchSize is the number of due users loaded at once by ResetCycles
+const cycleBatchSize = 100
+
+// CycleStart returns the start of the nth billing cycle after the anchor, the day is clamped
+// to the last day of months shorter than the anchor's month
+func CycleStart(anchor time.Time, n int) time.Time {
+	year, month, day := anchor.Date()
+	first := time.Date(year, month+time.Month(n), 1, anchor.Hour(), anchor.Minute(), anchor.Second(), anchor.Nanosecond(), anchor.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+
+	return first.AddDate(0, 0, day-1)
+}
+
+// CurrentCycle returns the start and end of the billing cycle containing now
+func CurrentCycle(anchor, now time.Time) (time.Time, time.Time) {
+	if now.Before(anchor) {
+		return anchor, CycleStart(anchor, 1)
+	}
+
+	n := (now.Year()-anchor.Year())*12 + int(now.Month()-anchor.Month())
+	if CycleStart(anchor, n).After(now) {
+		n--
+	}
+
+	return CycleStart(anchor, n), CycleStart(anchor, n+1)
+}
+
+// StartCycle anchors the billing cycle of the user at the given time, e.g. a subscription start
+func (u *User) StartCycle(anchor time.Time) {
+	u.CycleAnchor = anchor.UTC()
+	u.CycleEndsAt = CycleStart(u.CycleAnchor, 1)
+}
+
+// ResetCycles gives a full allowance to users whose billing cycle rolled over, returns number of users reset.
+// Runs are idempotent, a cycle is reset at most once per user.
+func ResetCycles(db *gorm.DB, now time.Time) (int, error) {
+	var (
+		reset  int
+		lastID uint
+	)
+	for {
+		var ids []uint
+		if err := db.Model(&User{}).Where("cycle_ends_at <= ? AND id > ?", now, lastID).
+			Order("id").Limit(cycleBatchSize).Pluck("id", &ids).Error; err != nil {
+			return reset, err
+		}
+		if len(ids) == 0 {
+			return reset, nil
+		}
+
+		for _, id := range ids {
+			done, err := resetCycle(db, id, now)
+			if err != nil {
+				return reset, err
+			}
+			if done {
+				reset++
+			}
+		}
+		lastID = ids[len(ids)-1]
+	}
+}
+
+// resetCycle resets remaining time of an user if its cycle rolled over and records the reset
+func resetCycle(db *gorm.DB, userID uint, now time.Time) (bool, error) {
+	var done bool
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var u User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&u, userID).Error; err != nil {
+			return err
+		}
+		if u.CycleEndsAt.After(now) {
+			return nil
+		}
+
+		start, end := CurrentCycle(u.CycleAnchor, now)
+		record := CycleReset{
+			UserID:                u.ID,
+			CycleStart:            start,
+			PreviousRemainingTime: u.RemainingTime,
+			RemainingTime:         MonthlyAllowance,
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		updates := map[string]interface{}{"cycle_ends_at": end}
+		if res.RowsAffected > 0 {
+			updates["remaining_time"] = MonthlyAllowance
+			updates["reached_time_limit"] = false
+			updates["notified_threshold"] = 0
+			done = true
+		}
+
+		return tx.Model(&u).UpdateColumns(updates).Error
+	})
+
+	return done, err
+}
@@ -0,0 +1,110 @@
+package user_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/database/databasetest"
+	"github.com/dinopuguh/mycap-backend/helpers"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 30, 0, 0, time.UTC)
+}
+
+func TestCycleStart(t *testing.T) {
+	tests := []struct {
+		name   string
+		anchor time.Time
+		n      int
+		start  time.Time
+	}{
+		{"Same day next month", date(2020, time.March, 15), 1, date(2020, time.April, 15)},
+		{"Short month", date(2021, time.January, 31), 1, date(2021, time.February, 28)},
+		{"Leap year", date(2020, time.January, 31), 1, date(2020, time.February, 29)},
+		{"Back to long month", date(2021, time.January, 31), 2, date(2021, time.March, 31)},
+		{"Thirty days month", date(2021, time.March, 31), 1, date(2021, time.April, 30)},
+		{"Next year", date(2020, time.December, 31), 2, date(2021, time.February, 28)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.start, user.CycleStart(tt.anchor, tt.n))
+		})
+	}
+}
+
+func TestCurrentCycle(t *testing.T) {
+	anchor := date(2021, time.January, 31)
+
+	tests := []struct {
+		name  string
+		now   time.Time
+		start time.Time
+		end   time.Time
+	}{
+		{"First cycle", date(2021, time.February, 10), anchor, date(2021, time.February, 28)},
+		{"Rolled over on short month", date(2021, time.February, 28).Add(time.Minute), date(2021, time.February, 28), date(2021, time.March, 31)},
+		{"Before day of anchor", date(2021, time.April, 15), date(2021, time.March, 31), date(2021, time.April, 30)},
+		{"Before anchor", date(2020, time.December, 1), anchor, date(2021, time.February, 28)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := user.CurrentCycle(anchor, tt.now)
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.end, end)
+		})
+	}
+}
+
+func TestResetCycles(t *testing.T) {
+	databasetest.Connect(t)
+
+	db := database.DBConn
+	password, _ := helpers.HashPassword("s3cr3tp45sw0rd")
+
+	due := &user.User{Name: "Dino Due", Username: "dinodue", Email: "dinodue@mycap.com", Password: password, TypeID: 1}
+	due.StartCycle(date(2021, time.January, 31))
+	notDue := &user.User{Name: "Dino Not Due", Username: "dinonotdue", Email: "dinonotdue@mycap.com", Password: password, TypeID: 1}
+	notDue.StartCycle(date(2021, time.February, 20))
+	db.Create(due)
+	db.Create(notDue)
+	db.Model(&user.User{}).Where("id IN ?", []uint{due.ID, notDue.ID}).
+		Updates(map[string]interface{}{"remaining_time": 0, "reached_time_limit": true})
+	defer func() {
+		db.Unscoped().Where("user_id IN ?", []uint{due.ID, notDue.ID}).Delete(&user.CycleReset{})
+		db.Unscoped().Delete(&user.User{}, []uint{due.ID, notDue.ID})
+	}()
+
+	now := date(2021, time.March, 1)
+	reset, err := user.ResetCycles(db, now)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, reset, 1)
+
+	db.First(due, due.ID)
+	assert.Equal(t, user.MonthlyAllowance, due.RemainingTime)
+	assert.False(t, due.ReachedTimeLimit)
+	assert.True(t, due.CycleEndsAt.Equal(date(2021, time.March, 31)))
+
+	db.First(notDue, notDue.ID)
+	assert.Equal(t, int64(0), notDue.RemainingTime)
+	assert.True(t, notDue.ReachedTimeLimit)
+
+	t.Run("Idempotent", func(t *testing.T) {
+		db.Model(due).Update("remaining_time", 1000)
+		db.Model(due).UpdateColumn("cycle_ends_at", date(2021, time.February, 28))
+
+		_, err := user.ResetCycles(db, now)
+		assert.NoError(t, err)
+
+		var records int64
+		db.Model(&user.CycleReset{}).Where("user_id = ?", due.ID).Count(&records)
+		assert.Equal(t, int64(1), records)
+
+		db.First(due, due.ID)
+		assert.Equal(t, int64(1000), due.RemainingTime, "cycle is reset once")
+		assert.True(t, due.CycleEndsAt.Equal(date(2021, time.March, 31)))
+	})
+}
@@ -0,0 +1,446 @@
+package user_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dinopuguh/mycap-backend/helpers"
+	"github.com/dinopuguh/mycap-backend/pagination"
+	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/services/session"
+	"github.com/dinopuguh/mycap-backend/services/user"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// memorySessions signs users in without database and records users signed out of all sessions
+type memorySessions struct {
+	revoked []uint
+}
+
+func (*memorySessions) New(c *fiber.Ctx, userID uint) (*session.Session, error) {
+	return &session.Session{Model: gorm.Model{ID: userID}, UserID: userID}, nil
+}
+
+func (s *memorySessions) RevokeAll(c *fiber.Ctx, userID, keepID uint) error {
+	s.revoked = append(s.revoked, userID)
+	return nil
+}
+
+// memoryAPIKeys records users whose API keys are revoked
+type memoryAPIKeys struct {
+	revoked []uint
+}
+
+func (k *memoryAPIKeys) RevokeAll(c *fiber.Ctx, userID uint) error {
+	k.revoked = append(k.revoked, userID)
+	return nil
+}
+
+// newMemoryApp serves user endpoints backed by in-memory repositories, requests are signed
+// in with a session as the user with email from the X-Email header
+func newMemoryApp() (*fiber.App, *user.MemoryRepository, *memorySessions, *memoryAPIKeys) {
+	types := user.NewMemoryTypeRepository(
+		user.Type{ID: 1, Name: "Free"},
+		user.Type{ID: 2, Name: "Premium"},
+	)
+	users := user.NewMemoryRepository(types)
+	sessions := new(memorySessions)
+	apiKeys := new(memoryAPIKeys)
+	h := user.NewHandler(users, types, sessions, apiKeys)
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
+	app.Post("/register", h.New)
+	app.Post("/login", h.Login)
+	app.Get("/users", h.GetAll)
+	app.Post("/account/email/confirm", h.ConfirmUpdateEmail)
+	app.Get("/admin/users", h.AdminGetAll)
+	app.Put("/admin/users/:id", h.AdminUpdate)
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", &jwt.Token{Claims: jwt.MapClaims{"email": c.Get("X-Email")}})
+		session.SetCurrent(c, &session.Session{Model: gorm.Model{ID: 1}})
+		return c.Next()
+	})
+	app.Put("/users/:id", h.Update)
+	app.Delete("/users/:id", h.Delete)
+	app.Get("/account/bonus", h.GetBonus)
+	app.Post("/account/password", h.SetPassword)
+
+	return app, users, sessions, apiKeys
+}
+
+func memoryRequest(app *fiber.App, method, endpoint, email string, data interface{}) (*response.HTTP, string) {
+	reqBody, _ := json.Marshal(data)
+	req, _ := http.NewRequest(method, endpoint, bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Email", email)
+
+	resHTTP := new(response.HTTP)
+	res, _ := app.Test(req, -1)
+	defer res.Body.Close()
+	resBody, _ := ioutil.ReadAll(res.Body)
+	json.Unmarshal(resBody, &resHTTP)
+
+	return resHTTP, string(resBody)
+}
+
+func TestMemoryRegister(t *testing.T) {
+	app, users, _, _ := newMemoryApp()
+
+	resHTTP, resBody := memoryRequest(app, http.MethodPost, "/register", "", user.RegisterUser{
+		Name:     "Dino Puguh",
+		Email:    "dinopuguh@mycap.com",
+		Username: "dinopuguh",
+		Password: "s3cr3tp45sw0rd",
+	})
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+	referrer, err := users.FindByEmail("dinopuguh@mycap.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "Free", referrer.Type.Name)
+	assert.Equal(t, user.MonthlyAllowance, referrer.RemainingTime)
+
+	tests := []struct {
+		name       string
+		data       user.RegisterUser
+		statusCode int
+	}{
+		{"Email already exist", user.RegisterUser{Name: "Dino", Email: "dinopuguh@mycap.com", Username: "dino", Password: "s3cr3tp45sw0rd"}, http.StatusBadRequest},
+		{"Username already exist", user.RegisterUser{Name: "Dino", Email: "dino@mycap.com", Username: "dinopuguh", Password: "s3cr3tp45sw0rd"}, http.StatusBadRequest},
+		{"User's type not found", user.RegisterUser{Name: "Dino", Email: "dino@mycap.com", Username: "dino", Password: "s3cr3tp45sw0rd", TypeID: 99}, http.StatusBadRequest},
+		{"Referral code invalid", user.RegisterUser{Name: "Dino", Email: "dino@mycap.com", Username: "dino", Password: "s3cr3tp45sw0rd", ReferralCode: "NOTACODE"}, http.StatusBadRequest},
+		{"Body invalid", user.RegisterUser{Name: "Dino", Email: "dino", Username: "dino puguh", Password: "12345678"}, http.StatusBadRequest},
+		{"Valid register with referral", user.RegisterUser{Name: "Dino", Email: "dino@mycap.com", Username: "dino", Password: "s3cr3tp45sw0rd", TypeID: 2, ReferralCode: referrer.ReferralCode}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resHTTP, resBody := memoryRequest(app, http.MethodPost, "/register", "", tt.data)
+			assert.Equalf(t, tt.statusCode, resHTTP.Status, resBody)
+		})
+	}
+
+	referred, err := users.FindByEmail("dino@mycap.com")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ReferralBonus, referred.BonusTime)
+	assert.Equal(t, "Premium", referred.Type.Name)
+
+	referrer, _ = users.FindByID(referrer.ID)
+	assert.Equal(t, user.ReferralBonus, referrer.BonusTime)
+
+	resHTTP, resBody = memoryRequest(app, http.MethodGet, "/account/bonus", "dino@mycap.com", nil)
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+	var page struct {
+		pagination.Response
+		Items []user.BonusEntry `json:"items"`
+	}
+	pageJSON, _ := json.Marshal(resHTTP.Data)
+	json.Unmarshal(pageJSON, &page)
+	if assert.Len(t, page.Items, 1) {
+		assert.Equal(t, user.BonusReasonReferral, page.Items[0].Reason)
+		assert.Equal(t, "dinopuguh", page.Items[0].Reference)
+	}
+}
+
+func TestMemoryConfirmUpdateEmail(t *testing.T) {
+	app, users, sessions, _ := newMemoryApp()
+
+	memoryRequest(app, http.MethodPost, "/register", "", user.RegisterUser{
+		Name:     "Dino Puguh",
+		Email:    "dinopuguh@mycap.com",
+		Username: "dinopuguh",
+		Password: "s3cr3tp45sw0rd",
+	})
+	registered, _ := users.FindByEmail("dinopuguh@mycap.com")
+
+	users.CreateEmailChange(&user.EmailChange{
+		UserID:    registered.ID,
+		NewEmail:  "dino@mycap.com",
+		TokenHash: helpers.HashToken("v4l1dt0k3n"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	users.CreateEmailChange(&user.EmailChange{
+		UserID:    registered.ID,
+		NewEmail:  "dino.old@mycap.com",
+		TokenHash: helpers.HashToken("3xp1r3dt0k3n"),
+		ExpiresAt: time.Now().Add(-time.Hour),
+	})
+
+	tests := []struct {
+		name       string
+		token      string
+		statusCode int
+	}{
+		{"Token not found", "n0tf0und", http.StatusNotFound},
+		{"Token expired", "3xp1r3dt0k3n", http.StatusBadRequest},
+		{"Valid confirm", "v4l1dt0k3n", http.StatusOK},
+		{"Token used", "v4l1dt0k3n", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resHTTP, resBody := memoryRequest(app, http.MethodPost, "/account/email/confirm", "", user.ConfirmEmail{Token: tt.token})
+			assert.Equalf(t, tt.statusCode, resHTTP.Status, resBody)
+		})
+	}
+
+	confirmed, err := users.FindByID(registered.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "dino@mycap.com", confirmed.Email)
+	assert.Equal(t, []uint{registered.ID}, sessions.revoked)
+}
+
+func TestMemorySetPassword(t *testing.T) {
+	app, users, _, _ := newMemoryApp()
+
+	users.Create(&user.User{
+		Name:     "Dino OIDC",
+		Email:    "dinooidc@mycap.com",
+		Username: "dinooidc",
+		Type:     user.Type{ID: 1},
+	}, nil)
+
+	tests := []struct {
+		name       string
+		data       user.SetPassword
+		statusCode int
+	}{
+		{"Password weak", user.SetPassword{NewPassword: "weak"}, http.StatusBadRequest},
+		{"Valid set", user.SetPassword{NewPassword: "s3cr3tp45sw0rd"}, http.StatusOK},
+		{"Password already set", user.SetPassword{NewPassword: "n3ws3cr3tp45sw0rd"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resHTTP, resBody := memoryRequest(app, http.MethodPost, "/account/password", "dinooidc@mycap.com", tt.data)
+			assert.Equalf(t, tt.statusCode, resHTTP.Status, resBody)
+		})
+	}
+
+	resHTTP, resBody := memoryRequest(app, http.MethodPost, "/login", "", user.LoginUser{Email: "dinooidc@mycap.com", Password: "s3cr3tp45sw0rd"})
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+}
+
+func TestMemoryLogin(t *testing.T) {
+	app, _, _, _ := newMemoryApp()
+
+	memoryRequest(app, http.MethodPost, "/register", "", user.RegisterUser{
+		Name:     "Dino Puguh",
+		Email:    "dinopuguh@mycap.com",
+		Username: "dinopuguh",
+		Password: "s3cr3tp45sw0rd",
+	})
+
+	tests := []struct {
+		name       string
+		data       user.LoginUser
+		statusCode int
+	}{
+		{"Valid login", user.LoginUser{Email: "dinopuguh@mycap.com", Password: "s3cr3tp45sw0rd"}, http.StatusOK},
+		{"Password incorrect", user.LoginUser{Email: "dinopuguh@mycap.com", Password: "wrong"}, http.StatusUnauthorized},
+		{"Email not found", user.LoginUser{Email: "nobody@mycap.com", Password: "s3cr3tp45sw0rd"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resHTTP, resBody := memoryRequest(app, http.MethodPost, "/login", "", tt.data)
+			assert.Equalf(t, tt.statusCode, resHTTP.Status, resBody)
+		})
+	}
+}
+
+func TestMemoryGetAll(t *testing.T) {
+	app, _, _, _ := newMemoryApp()
+
+	for _, username := range []string{"charlie", "alice", "bob"} {
+		memoryRequest(app, http.MethodPost, "/register", "", user.RegisterUser{
+			Name:     username,
+			Email:    username + "@mycap.com",
+			Username: username,
+			Password: "s3cr3tp45sw0rd",
+		})
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		statusCode int
+		usernames  []string
+		total      int64
+	}{
+		{"All users", "", http.StatusOK, []string{"charlie", "alice", "bob"}, 3},
+		{"Sorted by username", "?sort=username", http.StatusOK, []string{"alice", "bob", "charlie"}, 3},
+		{"Sorted descending with limit", "?sort=-username&limit=2", http.StatusOK, []string{"charlie", "bob"}, 3},
+		{"Search prefix", "?q=AL", http.StatusOK, []string{"alice"}, 1},
+		{"Type filter", "?type_id=2", http.StatusOK, []string{}, 0},
+		{"Sort not supported", "?sort=password", http.StatusBadRequest, nil, 0},
+		{"Sort by remaining time not public", "?sort=-remaining_time", http.StatusBadRequest, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resHTTP, resBody := memoryRequest(app, http.MethodGet, "/users"+tt.query, "", nil)
+			assert.Equalf(t, tt.statusCode, resHTTP.Status, resBody)
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+
+			var page struct {
+				pagination.Response
+				Items []user.PublicUser `json:"items"`
+			}
+			pageJSON, _ := json.Marshal(resHTTP.Data)
+			json.Unmarshal(pageJSON, &page)
+
+			var raw struct {
+				Items []map[string]interface{} `json:"items"`
+			}
+			json.Unmarshal(pageJSON, &raw)
+			for _, item := range raw.Items {
+				assert.Contains(t, item, "id")
+			}
+
+			usernames := []string{}
+			for _, u := range page.Items {
+				usernames = append(usernames, u.Username)
+			}
+			assert.Equal(t, tt.usernames, usernames)
+			assert.Equal(t, tt.total, page.Total)
+		})
+	}
+}
+
+func TestMemoryAdminGetAll(t *testing.T) {
+	app, users, _, _ := newMemoryApp()
+
+	for i, username := range []string{"charlie", "alice", "bob"} {
+		memoryRequest(app, http.MethodPost, "/register", "", user.RegisterUser{
+			Name:     username,
+			Email:    username + "@mycap.com",
+			Username: username,
+			Password: "s3cr3tp45sw0rd",
+		})
+
+		registered, _ := users.FindByUsername(username)
+		registered.RemainingTime = int64(i) * 1000
+		registered.ReachedTimeLimit = i == 0
+		users.Save(registered)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		statusCode int
+		usernames  []string
+	}{
+		{"Sorted by remaining time", "?sort=-remaining_time", http.StatusOK, []string{"bob", "alice", "charlie"}},
+		{"Reached time limit", "?reached_time_limit=true", http.StatusOK, []string{"charlie"}},
+		{"Time limit not reached", "?reached_time_limit=false&sort=username", http.StatusOK, []string{"alice", "bob"}},
+		{"Reached time limit invalid", "?reached_time_limit=maybe", http.StatusBadRequest, nil},
+		{"Sort not supported", "?sort=password", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resHTTP, resBody := memoryRequest(app, http.MethodGet, "/admin/users"+tt.query, "", nil)
+			assert.Equalf(t, tt.statusCode, resHTTP.Status, resBody)
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+
+			var page struct {
+				Items []user.PrivateUser `json:"items"`
+			}
+			pageJSON, _ := json.Marshal(resHTTP.Data)
+			json.Unmarshal(pageJSON, &page)
+
+			usernames := []string{}
+			for _, u := range page.Items {
+				usernames = append(usernames, u.Username)
+			}
+			assert.Equal(t, tt.usernames, usernames)
+		})
+	}
+}
+
+func TestMemoryUpdateAndDelete(t *testing.T) {
+	app, users, sessions, apiKeys := newMemoryApp()
+
+	for _, u := range []user.RegisterUser{
+		{Name: "Dino Puguh", Email: "dinopuguh@mycap.com", Username: "dinopuguh", Password: "s3cr3tp45sw0rd"},
+		{Name: "Dino Lain", Email: "dinolain@mycap.com", Username: "dinolain", Password: "s3cr3tp45sw0rd"},
+	} {
+		memoryRequest(app, http.MethodPost, "/register", "", u)
+	}
+	registered, _ := users.FindByEmail("dinopuguh@mycap.com")
+	other, _ := users.FindByEmail("dinolain@mycap.com")
+	endpoint := fmt.Sprintf("/users/%d", registered.ID)
+
+	resHTTP, resBody := memoryRequest(app, http.MethodPut, endpoint, registered.Email, user.UpdateUser{Name: "Dino Yang Baru"})
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+	updated, _ := users.FindByID(registered.ID)
+	assert.Equal(t, "Dino Yang Baru", updated.Name)
+
+	resHTTP, resBody = memoryRequest(app, http.MethodPut, endpoint, registered.Email, user.UpdateUser{})
+	assert.Equalf(t, http.StatusBadRequest, resHTTP.Status, resBody)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "name", "message": "is required"},
+	}, resHTTP.Data)
+
+	resHTTP, resBody = memoryRequest(app, http.MethodPut, endpoint, other.Email, user.UpdateUser{Name: "Dino Dibajak"})
+	assert.Equalf(t, http.StatusForbidden, resHTTP.Status, resBody)
+
+	resHTTP, resBody = memoryRequest(app, http.MethodDelete, endpoint, other.Email, nil)
+	assert.Equalf(t, http.StatusForbidden, resHTTP.Status, resBody)
+
+	unchanged, _ := users.FindByID(registered.ID)
+	assert.Equal(t, "Dino Yang Baru", unchanged.Name)
+
+	resHTTP, resBody = memoryRequest(app, http.MethodDelete, endpoint, registered.Email, nil)
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+	assert.Equal(t, []uint{registered.ID}, sessions.revoked)
+	assert.Equal(t, []uint{registered.ID}, apiKeys.revoked)
+
+	resHTTP, resBody = memoryRequest(app, http.MethodDelete, endpoint, registered.Email, nil)
+	assert.Equalf(t, http.StatusNotFound, resHTTP.Status, resBody)
+
+	resHTTP, resBody = memoryRequest(app, http.MethodPut, endpoint, registered.Email, user.UpdateUser{Name: "Dino"})
+	assert.Equalf(t, http.StatusNotFound, resHTTP.Status, resBody)
+}
+
+func TestMemoryAdminUpdate(t *testing.T) {
+	app, users, _, _ := newMemoryApp()
+
+	memoryRequest(app, http.MethodPost, "/register", "", user.RegisterUser{
+		Name:     "Dino Puguh",
+		Email:    "dinopuguh@mycap.com",
+		Username: "dinopuguh",
+		Password: "s3cr3tp45sw0rd",
+	})
+	registered, _ := users.FindByEmail("dinopuguh@mycap.com")
+	endpoint := fmt.Sprintf("/admin/users/%d", registered.ID)
+
+	resHTTP, resBody := memoryRequest(app, http.MethodPut, endpoint, "", user.AdminUpdateUser{RemainingTime: 1800, TypeID: 2})
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, resBody)
+
+	updated, _ := users.FindByID(registered.ID)
+	assert.Equal(t, int64(1800), updated.RemainingTime)
+	assert.Equal(t, "Premium", updated.Type.Name)
+
+	resHTTP, resBody = memoryRequest(app, http.MethodPut, endpoint, "", user.AdminUpdateUser{TypeID: 99})
+	assert.Equalf(t, http.StatusNotFound, resHTTP.Status, resBody)
+
+	resHTTP, resBody = memoryRequest(app, http.MethodPut, endpoint, "", user.AdminUpdateUser{RemainingTime: -1})
+	assert.Equalf(t, http.StatusBadRequest, resHTTP.Status, resBody)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "remaining_time", "message": "must be at least 0"},
+	}, resHTTP.Data)
+
+	resHTTP, resBody = memoryRequest(app, http.MethodPut, "/admin/users/99", "", user.AdminUpdateUser{})
+	assert.Equalf(t, http.StatusNotFound, resHTTP.Status, resBody)
+}
@@ -0,0 +1,358 @@
+package user
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/pagination"
+	"gorm.io/gorm"
+)
+
+// MemoryTypeRepository stores user types in memory, it's meant for tests running without database
+type MemoryTypeRepository struct {
+	mu    sync.RWMutex
+	types map[uint]Type
+}
+
+// NewMemoryTypeRepository creates an in-memory user type repository holding the given types
+func NewMemoryTypeRepository(types ...Type) *MemoryTypeRepository {
+	r := &MemoryTypeRepository{types: make(map[uint]Type)}
+	for _, userType := range types {
+		r.types[userType.ID] = userType
+	}
+
+	return r
+}
+
+// WithContext returns the repository itself, contexts only matter to queries
+func (r *MemoryTypeRepository) WithContext(ctx context.Context) TypeRepository {
+	return r
+}
+
+// FindByID returns a copy of the type with the ID
+func (r *MemoryTypeRepository) FindByID(id uint) (*Type, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	userType, ok := r.types[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &userType, nil
+}
+
+// MemoryRepository stores users in memory, it's meant for tests running without database.
+// Bonus time credited and consumed is recorded in a ledger like in database.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	types  TypeRepository
+	users  map[uint]User
+	bonus  []BonusEntry
+	emails map[string]EmailChange // pending email changes by token hash
+	nextID uint
+}
+
+// NewMemoryRepository creates an empty in-memory user repository, types are preloaded from the type repository
+func NewMemoryRepository(types TypeRepository) *MemoryRepository {
+	return &MemoryRepository{
+		types:  types,
+		users:  make(map[uint]User),
+		emails: make(map[string]EmailChange),
+		nextID: 1,
+	}
+}
+
+func (r *MemoryRepository) preload(u User) *User {
+	if userType, err := r.types.FindByID(u.TypeID); err == nil {
+		u.Type = *userType
+	}
+
+	return &u
+}
+
+func (r *MemoryRepository) first(match func(u User) bool) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if match(u) {
+			return r.preload(u), nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+// WithContext returns the repository itself, contexts only matter to queries
+func (r *MemoryRepository) WithContext(ctx context.Context) Repository {
+	return r
+}
+
+// FindByID returns a copy of the user with the ID
+func (r *MemoryRepository) FindByID(id uint) (*User, error) {
+	return r.first(func(u User) bool { return u.ID == id })
+}
+
+// FindByEmail returns a copy of the user with the email
+func (r *MemoryRepository) FindByEmail(email string) (*User, error) {
+	return r.first(func(u User) bool { return u.Email == email })
+}
+
+// FindByUsername returns a copy of the user with the username
+func (r *MemoryRepository) FindByUsername(username string) (*User, error) {
+	return r.first(func(u User) bool { return u.Username == username })
+}
+
+// FindByReferralCode returns a copy of the user with the referral code
+func (r *MemoryRepository) FindByReferralCode(code string) (*User, error) {
+	code = strings.ToUpper(code)
+	return r.first(func(u User) bool { return u.ReferralCode == code })
+}
+
+var memoryUserColumns = map[string]func(a, b User) int{
+	"id":             func(a, b User) int { return compareInt(int64(a.ID), int64(b.ID)) },
+	"name":           func(a, b User) int { return strings.Compare(a.Name, b.Name) },
+	"username":       func(a, b User) int { return strings.Compare(a.Username, b.Username) },
+	"remaining_time": func(a, b User) int { return compareInt(a.RemainingTime, b.RemainingTime) },
+	"created_at":     func(a, b User) int { return compareInt(a.CreatedAt.UnixNano(), b.CreatedAt.UnixNano()) },
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// List filters, sorts and pages users, order is an order clause made by pagination.Sort
+func (r *MemoryRepository) List(filter Filter, page pagination.Page, order string) ([]User, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	q := strings.ToLower(filter.Query)
+	var users []User
+	for _, u := range r.users {
+		if q != "" && !strings.HasPrefix(strings.ToLower(u.Username), q) && !strings.HasPrefix(strings.ToLower(u.Name), q) {
+			continue
+		}
+		if filter.TypeID != 0 && u.TypeID != filter.TypeID {
+			continue
+		}
+		if filter.ReachedTimeLimit != nil && u.ReachedTimeLimit != *filter.ReachedTimeLimit {
+			continue
+		}
+		if filter.CreatedFrom != nil && u.CreatedAt.Before(*filter.CreatedFrom) {
+			continue
+		}
+		if filter.CreatedTo != nil && u.CreatedAt.After(*filter.CreatedTo) {
+			continue
+		}
+		users = append(users, *r.preload(u))
+	}
+
+	sort.SliceStable(users, func(i, j int) bool {
+		for _, term := range strings.Split(order, ",") {
+			fields := strings.Fields(term)
+			compare, ok := memoryUserColumns[fields[0]]
+			if !ok {
+				continue
+			}
+			if c := compare(users[i], users[j]); c != 0 {
+				return (c < 0) != (len(fields) > 1 && fields[1] == "desc")
+			}
+		}
+		return false
+	})
+
+	total := int64(len(users))
+	if page.Offset >= len(users) {
+		return []User{}, total, nil
+	}
+	users = users[page.Offset:]
+	if len(users) > page.Limit {
+		users = users[:page.Limit]
+	}
+
+	return users, total, nil
+}
+
+// Create assigns an ID to the user and credits the referral bonus
+func (r *MemoryRepository) Create(u *User, referrer *User) error {
+	if err := u.BeforeCreate(nil); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	u.ID = r.nextID
+	u.CreatedAt = now
+	u.UpdatedAt = now
+	if u.Type.ID != 0 {
+		u.TypeID = u.Type.ID
+	}
+	if u.RemainingTime == 0 {
+		u.RemainingTime = MonthlyAllowance
+	}
+	r.nextID++
+
+	if referrer != nil && ReferralBonus > 0 {
+		stored := r.users[referrer.ID]
+		stored.BonusTime += ReferralBonus
+		stored.ReachedTimeLimit = false
+		r.users[referrer.ID] = stored
+		referrer.BonusTime = stored.BonusTime
+		referrer.ReachedTimeLimit = false
+		r.record(referrer.ID, ReferralBonus, BonusReasonReferral, u.Username)
+
+		u.BonusTime += ReferralBonus
+		u.ReachedTimeLimit = false
+		r.record(u.ID, ReferralBonus, BonusReasonReferral, referrer.Username)
+	}
+	r.users[u.ID] = *u
+
+	return nil
+}
+
+// record appends an entry to the bonus time ledger
+func (r *MemoryRepository) record(userID uint, amount int64, reason, reference string) {
+	now := time.Now()
+	r.bonus = append(r.bonus, BonusEntry{
+		Model:     gorm.Model{ID: uint(len(r.bonus) + 1), CreatedAt: now, UpdatedAt: now},
+		UserID:    userID,
+		Amount:    amount,
+		Reason:    reason,
+		Reference: reference,
+	})
+}
+
+// Consume deducts time used from the monthly allowance first and then from bonus time, like
+// Consume does in a database transaction
+func (r *MemoryRepository) Consume(u *User, used int64, reference string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[u.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	if fromBonus := u.Deduct(used); fromBonus > 0 {
+		r.record(u.ID, -fromBonus, BonusReasonUsage, reference)
+	}
+	u.UpdatedAt = time.Now()
+	r.users[u.ID] = *u
+
+	return nil
+}
+
+// Save replaces the stored user
+func (r *MemoryRepository) Save(u *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[u.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	u.UpdatedAt = time.Now()
+	r.users[u.ID] = *u
+
+	return nil
+}
+
+// UpdatePassword replaces the password of the stored user
+func (r *MemoryRepository) UpdatePassword(u *User) error {
+	return r.update(u.ID, func(stored *User) { stored.Password = u.Password })
+}
+
+// UpdateEmail replaces the email and its confirmation of the stored user
+func (r *MemoryRepository) UpdateEmail(u *User) error {
+	return r.update(u.ID, func(stored *User) {
+		stored.Email = u.Email
+		stored.EmailConfirmedAt = u.EmailConfirmedAt
+	})
+}
+
+func (r *MemoryRepository) update(id uint, change func(stored *User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	change(&stored)
+	stored.UpdatedAt = time.Now()
+	r.users[id] = stored
+
+	return nil
+}
+
+// Delete removes the user
+func (r *MemoryRepository) Delete(u *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users, u.ID)
+
+	return nil
+}
+
+// CreateEmailChange stores a pending email change
+func (r *MemoryRepository) CreateEmailChange(change *EmailChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	change.CreatedAt = now
+	change.UpdatedAt = now
+	r.emails[change.TokenHash] = *change
+
+	return nil
+}
+
+// TakeEmailChange removes and returns the pending email change with the token hash
+func (r *MemoryRepository) TakeEmailChange(tokenHash string) (*EmailChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	change, ok := r.emails[tokenHash]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	delete(r.emails, tokenHash)
+
+	return &change, nil
+}
+
+// ListBonus pages the bonus time ledger of an user, newest first
+func (r *MemoryRepository) ListBonus(userID uint, page pagination.Page) ([]BonusEntry, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := []BonusEntry{}
+	for i := len(r.bonus) - 1; i >= 0; i-- {
+		if r.bonus[i].UserID == userID {
+			entries = append(entries, r.bonus[i])
+		}
+	}
+
+	total := int64(len(entries))
+	if page.Offset >= len(entries) {
+		return []BonusEntry{}, total, nil
+	}
+	entries = entries[page.Offset:]
+	if len(entries) > page.Limit {
+		entries = entries[:page.Limit]
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,209 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/dinopuguh/mycap-backend/pagination"
+	"gorm.io/gorm"
+)
+
+// Filter narrows down users listed by a repository
+type Filter struct {
+	Query            string // username or name prefix, case insensitive
+	TypeID           uint
+	ReachedTimeLimit *bool // filtered only in listings of admins
+	CreatedFrom      *time.Time
+	CreatedTo        *time.Time
+}
+
+// Repository stores users, finders return gorm.ErrRecordNotFound when no user matches
+type Repository interface {
+	FindByID(id uint) (*User, error)
+	FindByEmail(email string) (*User, error)
+	FindByUsername(username string) (*User, error)
+	FindByReferralCode(code string) (*User, error)
+	List(filter Filter, page pagination.Page, order string) ([]User, int64, error)
+	// Create inserts an user, both the user and its referrer are credited the referral bonus
+	Create(u *User, referrer *User) error
+	Save(u *User) error
+	// UpdatePassword and UpdateEmail save only the password or email and its confirmation of an user
+	UpdatePassword(u *User) error
+	UpdateEmail(u *User) error
+	Delete(u *User) error
+	CreateEmailChange(change *EmailChange) error
+	// TakeEmailChange removes and returns the pending email change with the token hash
+	TakeEmailChange(tokenHash string) (*EmailChange, error)
+	// ListBonus returns the bonus time ledger of an user, newest first
+	ListBonus(userID uint, page pagination.Page) ([]BonusEntry, int64, error)
+	// WithContext returns the repository running queries with a context such as logger.Context
+	WithContext(ctx context.Context) Repository
+}
+
+// TypeRepository stores user types, finders return gorm.ErrRecordNotFound when no type matches
+type TypeRepository interface {
+	FindByID(id uint) (*Type, error)
+	WithContext(ctx context.Context) TypeRepository
+}
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates an user repository backed by database
+func NewRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) WithContext(ctx context.Context) Repository {
+	return &gormRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *gormRepository) first(query string, args ...interface{}) (*User, error) {
+	var u = new(User)
+	if err := r.db.Preload("Type").Where(query, args...).First(u).Error; err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (r *gormRepository) FindByID(id uint) (*User, error) {
+	return r.first("id = ?", id)
+}
+
+func (r *gormRepository) FindByEmail(email string) (*User, error) {
+	return r.first("email = ?", email)
+}
+
+func (r *gormRepository) FindByUsername(username string) (*User, error) {
+	return r.first("username = ?", username)
+}
+
+func (r *gormRepository) FindByReferralCode(code string) (*User, error) {
+	return r.first("referral_code = ?", strings.ToUpper(code))
+}
+
+func (r *gormRepository) List(filter Filter, page pagination.Page, order string) ([]User, int64, error) {
+	var filters []func(*gorm.DB) *gorm.DB
+	where := func(query string, args ...interface{}) {
+		filters = append(filters, func(db *gorm.DB) *gorm.DB {
+			return db.Where(query, args...)
+		})
+	}
+
+	if q := strings.ToLower(filter.Query); q != "" {
+		prefix := strings.NewReplacer("%", "\\%", "_", "\\_").Replace(q) + "%"
+		where("LOWER(username) LIKE ? OR LOWER(name) LIKE ?", prefix, prefix)
+	}
+	if filter.TypeID != 0 {
+		where("type_id = ?", filter.TypeID)
+	}
+	if filter.ReachedTimeLimit != nil {
+		where("reached_time_limit = ?", *filter.ReachedTimeLimit)
+	}
+	if filter.CreatedFrom != nil {
+		where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		where("created_at <= ?", *filter.CreatedTo)
+	}
+
+	var total int64
+	if err := r.db.Model(&User{}).Scopes(filters...).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []User
+	if err := r.db.Scopes(filters...).Scopes(page.Scope).Preload("Type").Order(order).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+func (r *gormRepository) Create(u *User, referrer *User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(u).Error; err != nil {
+			return err
+		}
+
+		if referrer == nil || ReferralBonus == 0 {
+			return nil
+		}
+		if err := Credit(tx, referrer, ReferralBonus, BonusReasonReferral, u.Username); err != nil {
+			return err
+		}
+
+		return Credit(tx, u, ReferralBonus, BonusReasonReferral, referrer.Username)
+	})
+}
+
+func (r *gormRepository) Save(u *User) error {
+	return r.db.Save(u).Error
+}
+
+func (r *gormRepository) UpdatePassword(u *User) error {
+	return r.db.Model(u).UpdateColumn("password", u.Password).Error
+}
+
+func (r *gormRepository) UpdateEmail(u *User) error {
+	return r.db.Model(u).UpdateColumns(map[string]interface{}{"email": u.Email, "email_confirmed_at": u.EmailConfirmedAt}).Error
+}
+
+func (r *gormRepository) Delete(u *User) error {
+	return r.db.Delete(u).Error
+}
+
+func (r *gormRepository) CreateEmailChange(change *EmailChange) error {
+	return r.db.Create(change).Error
+}
+
+func (r *gormRepository) TakeEmailChange(tokenHash string) (*EmailChange, error) {
+	var change = new(EmailChange)
+	if err := r.db.Where("token_hash = ?", tokenHash).First(change).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Unscoped().Delete(change).Error; err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+func (r *gormRepository) ListBonus(userID uint, page pagination.Page) ([]BonusEntry, int64, error) {
+	var total int64
+	if err := r.db.Model(&BonusEntry{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []BonusEntry
+	if err := r.db.Where("user_id = ?", userID).Scopes(page.Scope).Order("id desc").Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+type gormTypeRepository struct {
+	db *gorm.DB
+}
+
+// NewTypeRepository creates an user type repository backed by database
+func NewTypeRepository(db *gorm.DB) TypeRepository {
+	return &gormTypeRepository{db: db}
+}
+
+func (r *gormTypeRepository) WithContext(ctx context.Context) TypeRepository {
+	return &gormTypeRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *gormTypeRepository) FindByID(id uint) (*Type, error) {
+	var userType = new(Type)
+	if err := r.db.First(userType, id).Error; err != nil {
+		return nil, err
+	}
+
+	return userType, nil
+}
@@ -1,11 +1,19 @@
 package user
 
 import (
-	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/helpers"
+	"github.com/dinopuguh/mycap-backend/logger"
+	"github.com/dinopuguh/mycap-backend/pagination"
 	"github.com/dinopuguh/mycap-backend/response"
+	"github.com/dinopuguh/mycap-backend/services/session"
+	"github.com/dinopuguh/mycap-backend/validation"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -13,118 +21,325 @@ import (
 // User is a model for user
 type User struct {
 	gorm.Model
-	Name             string `json:"name"`
-	Username         string `json:"username"`
-	Email            string `json:"email"`
-	Password         string `json:"password"`
-	RemainingTime    int64  `json:"remaining_time" gorm:"default:36000000;"`
-	ReachedTimeLimit bool   `json:"reached_time_limit" gorm:"default:false;"`
-	Type             Type   `json:"type"`
-	TypeID           uint   `json:"type_id"`
+	Name              string     `json:"name"`
+	Username          string     `json:"username"`
+	Email             string     `json:"email"`
+	Password          string     `json:"-"`
+	EmailConfirmedAt  *time.Time `json:"-"` // set when a token sent to the email or an identity provider confirmed it
+	RemainingTime     int64      `json:"remaining_time" gorm:"default:36000000;"`
+	ReachedTimeLimit  bool       `json:"reached_time_limit" gorm:"default:false;index;"`
+	NotifiedThreshold int64      `json:"-" gorm:"default:0;"` // highest low balance threshold notified this cycle
+	CycleAnchor       time.Time  `json:"cycle_anchor"`        // start of the first billing cycle
+	CycleEndsAt       time.Time  `json:"cycle_ends_at" gorm:"index;"`
+	BonusTime         int64      `json:"bonus_time" gorm:"default:0;"` // milliseconds kept across billing cycles
+	ReferralCode      string     `json:"referral_code" gorm:"uniqueIndex;"`
+	ReferredByID      *uint      `json:"-"`
+	Type              Type       `json:"type"`
+	TypeID            uint       `json:"type_id" gorm:"index;"`
 }
 
-// Type is a model for user's type
+// MonthlyAllowance is the remaining time in milliseconds given to users every month
+const MonthlyAllowance int64 = 36000000
+
+// BeforeCreate anchors the billing cycle at registration and generates a referral code of a new user
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.CycleAnchor.IsZero() {
+		u.StartCycle(time.Now())
+	}
+
+	if u.ReferralCode == "" {
+		code, err := helpers.RandomToken(4)
+		if err != nil {
+			return err
+		}
+		u.ReferralCode = strings.ToUpper(code)
+	}
+
+	return nil
+}
+
+// Type is a model for user's type, it's serialized within users so its keys are in snake case too
 type Type struct {
-	gorm.Model
-	Name string `json:"name"`
+	ID                        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt                 time.Time      `json:"created_at"`
+	UpdatedAt                 time.Time      `json:"updated_at"`
+	DeletedAt                 gorm.DeletedAt `json:"-" gorm:"index"`
+	Name                      string         `json:"name"`
+	MaxGroupParticipants      int64          `json:"max_group_participants"`      // 0 means unlimited
+	MaxConferenceParticipants int64          `json:"max_conference_participants"` // 0 means unlimited
+}
+
+// PublicUser represents user data visible to other users
+type PublicUser struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+	Type     Type   `json:"type"`
+}
+
+// PrivateUser represents user data visible to the user itself
+type PrivateUser struct {
+	ID               uint      `json:"id"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	Name             string    `json:"name"`
+	Username         string    `json:"username"`
+	Email            string    `json:"email"`
+	RemainingTime    int64     `json:"remaining_time"`
+	ReachedTimeLimit bool      `json:"reached_time_limit"`
+	CycleAnchor      time.Time `json:"cycle_anchor"`
+	CycleEndsAt      time.Time `json:"cycle_ends_at"`
+	BonusTime        int64     `json:"bonus_time"`
+	ReferralCode     string    `json:"referral_code"`
+	ProviderOnly     bool      `json:"provider_only"` // users registered by an identity provider sign in only with it until they set a password
+	Type             Type      `json:"type"`
+	TypeID           uint      `json:"type_id"`
+}
+
+// Public converts user to representation visible to other users
+func (u User) Public() PublicUser {
+	return PublicUser{
+		ID:       u.ID,
+		Name:     u.Name,
+		Username: u.Username,
+		Type:     u.Type,
+	}
+}
+
+// Private converts user to representation visible to the user itself
+func (u User) Private() PrivateUser {
+	return PrivateUser{
+		ID:               u.ID,
+		CreatedAt:        u.CreatedAt,
+		UpdatedAt:        u.UpdatedAt,
+		Name:             u.Name,
+		Username:         u.Username,
+		Email:            u.Email,
+		RemainingTime:    u.RemainingTime,
+		ReachedTimeLimit: u.ReachedTimeLimit,
+		CycleAnchor:      u.CycleAnchor,
+		CycleEndsAt:      u.CycleEndsAt,
+		BonusTime:        u.BonusTime,
+		ReferralCode:     u.ReferralCode,
+		ProviderOnly:     u.Password == "",
+		Type:             u.Type,
+		TypeID:           u.TypeID,
+	}
+}
+
+// PublicUsers converts users to representation visible to other users
+func PublicUsers(users []User) []PublicUser {
+	publicUsers := make([]PublicUser, 0, len(users))
+	for _, u := range users {
+		publicUsers = append(publicUsers, u.Public())
+	}
+
+	return publicUsers
+}
+
+// PrivateUsers converts users to representation visible to the users themselves and admins
+func PrivateUsers(users []User) []PrivateUser {
+	privateUsers := make([]PrivateUser, 0, len(users))
+	for _, u := range users {
+		privateUsers = append(privateUsers, u.Private())
+	}
+
+	return privateUsers
+}
+
+// Sessions signs users in on the requesting device and out of their other devices
+type Sessions interface {
+	New(c *fiber.Ctx, userID uint) (*session.Session, error)
+	// RevokeAll revokes sessions of an user except the session keepID
+	RevokeAll(c *fiber.Ctx, userID, keepID uint) error
+}
+
+// APIKeys revokes API keys of users removing their account
+type APIKeys interface {
+	RevokeAll(c *fiber.Ctx, userID uint) error
+}
+
+// Handler serves user endpoints with its dependencies
+type Handler struct {
+	Users    Repository
+	Types    TypeRepository
+	Sessions Sessions
+	APIKeys  APIKeys
+}
+
+// NewHandler creates user endpoints backed by the repositories
+func NewHandler(users Repository, types TypeRepository, sessions Sessions, apiKeys APIKeys) *Handler {
+	return &Handler{
+		Users:    users,
+		Types:    types,
+		Sessions: sessions,
+		APIKeys:  apiKeys,
+	}
+}
+
+// users returns the user repository running queries with the logger of the request
+func (h *Handler) users(c *fiber.Ctx) Repository {
+	return h.Users.WithContext(logger.Context(c))
+}
+
+// types returns the type repository running queries with the logger of the request
+func (h *Handler) types(c *fiber.Ctx) TypeRepository {
+	return h.Types.WithContext(logger.Context(c))
+}
+
+// Current returns the user signed in by the JWT of the request, or the owner of its API key
+func Current(c *fiber.Ctx, users Repository) (*User, error) {
+	token := c.Locals("user").(*jwt.Token)
+	claims := token.Claims.(jwt.MapClaims)
+
+	return users.FindByEmail(claims["email"].(string))
+}
+
+// owned returns the user by ID of the request path, users can only change their own account
+func (h *Handler) owned(c *fiber.Ctx) (*User, error) {
+	id := c.Params("id")
+
+	userID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, apperror.NotFound("user_not_found", "User with ID %v not found.", id)
+	}
+
+	current, err := Current(c, h.users(c))
+	if err != nil {
+		return nil, err
+	}
+
+	if current.ID != uint(userID) {
+		return nil, apperror.Forbidden("user_forbidden", "User with ID %v is not your account.", id)
+	}
+
+	return current, nil
 }
 
-// GetAll is a function to get all users data from database
+var userSortColumns = map[string]string{
+	"name":       "name",
+	"username":   "username",
+	"created_at": "created_at",
+}
+
+// GetAll is a function to get users data from database with pagination, filters, sorting and search
 // @Summary Get all users
-// @Description Get all users
+// @Description Get users, search by username or name prefix and filter by plan type and creation date
 // @Tags users
 // @Accept json
 // @Produce json
-// @Success 200 {object} response.HTTP{data=[]User}
+// @Param q query string false "Username or name prefix"
+// @Param type_id query int false "User type ID"
+// @Param created_from query string false "Created from date (2006-01-02 or RFC3339)"
+// @Param created_to query string false "Created to date (2006-01-02 or RFC3339)"
+// @Param sort query string false "Sort by name, username or created_at, prefix with - for descending"
+// @Param limit query int false "Page size"
+// @Param cursor query string false "Next page cursor"
+// @Success 200 {object} response.HTTP{data=pagination.Response{items=[]PublicUser}}
 // @Router /v1/users [get]
-func GetAll(c *fiber.Ctx) error {
-	db := database.DBConn
+func (h *Handler) GetAll(c *fiber.Ctx) error {
+	page, err := pagination.Parse(c)
+	if err != nil {
+		return err
+	}
+
+	order, err := pagination.Sort(c.Query("sort"), userSortColumns, "id asc")
+	if err != nil {
+		return err
+	}
 
-	var users []User
-	if res := db.Preload("Type").Find(&users); res.Error != nil {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusServiceUnavailable,
-			Message: res.Error.Error(),
-		})
+	filter, err := parseFilter(c)
+	if err != nil {
+		return err
+	}
+
+	users, total, err := h.users(c).List(filter, page, order)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(response.HTTP{
 		Success: true,
-		Data:    users,
+		Data:    page.Response(PublicUsers(users), len(users), total),
 		Status:  http.StatusOK,
 		Message: "Success get all users.",
 	})
 }
 
-// Update function edit an user by ID
+// parseFilter parses the search and filters of user listings from query parameters
+func parseFilter(c *fiber.Ctx) (Filter, error) {
+	filter := Filter{Query: c.Query("q")}
+
+	if typeID := c.Query("type_id"); typeID != "" {
+		id, err := strconv.ParseUint(typeID, 10, 64)
+		if err != nil {
+			return filter, apperror.Invalid("type_id_invalid", "Type ID invalid.")
+		}
+		filter.TypeID = uint(id)
+	}
+
+	if createdFrom := c.Query("created_from"); createdFrom != "" {
+		from, err := helpers.ParseDate(createdFrom)
+		if err != nil {
+			return filter, apperror.Invalid("date_invalid", "Created from date invalid.")
+		}
+		filter.CreatedFrom = &from
+	}
+
+	if createdTo := c.Query("created_to"); createdTo != "" {
+		to, err := helpers.ParseDate(createdTo)
+		if err != nil {
+			return filter, apperror.Invalid("date_invalid", "Created to date invalid.")
+		}
+		filter.CreatedTo = &to
+	}
+
+	return filter, nil
+}
+
+// Update function edit the current user by ID
 // @Summary Update user by ID
-// @Description Update user by ID
+// @Description Update the name of the current user, plans and remaining time are updated by admins
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param id path int true "User ID"
 // @Param user body UpdateUser true "Update user"
-// @Success 200 {object} response.HTTP{data=User}
+// @Success 200 {object} response.HTTP{data=PrivateUser}
 // @Security ApiKeyAuth
 // @Router /v1/users/{id} [put]
-func Update(c *fiber.Ctx) error {
-	id := c.Params("id")
-	db := database.DBConn
-
+func (h *Handler) Update(c *fiber.Ctx) error {
 	updatedUser := new(UpdateUser)
 	if err := c.BodyParser(&updatedUser); err != nil {
-		return c.JSON(response.HTTP{
-			Status:  http.StatusBadRequest,
-			Message: err.Error(),
-		})
-	}
-
-	user := new(User)
-	if err := db.Preload("Type").First(&user, id).Error; err != nil {
-		switch err.Error() {
-		case "record not found":
-			return c.JSON(response.HTTP{
-				Status:  http.StatusNotFound,
-				Message: fmt.Sprintf("User with ID %v not found.", id),
-			})
-		default:
-			return c.JSON(response.HTTP{
-				Status:  http.StatusServiceUnavailable,
-				Message: err.Error(),
-			})
-		}
+		return apperror.Invalid("body_invalid", err.Error())
 	}
 
-	userType := new(Type)
-	if updatedUser.TypeID != 0 {
-		if res := db.First(&userType, updatedUser.TypeID); res.RowsAffected == 0 {
-			return c.JSON(response.HTTP{
-				Status:  http.StatusNotFound,
-				Message: fmt.Sprintf("User type with ID %v not found.", updatedUser.TypeID),
-			})
-		}
+	if err := validation.Struct(updatedUser); err != nil {
+		return err
+	}
 
-		user.TypeID = updatedUser.TypeID
-		user.Type = *userType
+	user, err := h.owned(c)
+	if err != nil {
+		return err
 	}
 
 	user.Name = updatedUser.Name
-	user.ReachedTimeLimit = updatedUser.ReachedTimeLimit
-	user.RemainingTime = updatedUser.RemainingTime
 
-	db.Save(&user)
+	if err := h.users(c).Save(user); err != nil {
+		return err
+	}
 
 	return c.JSON(response.HTTP{
 		Success: true,
-		Data:    user,
+		Data:    user.Private(),
 		Status:  http.StatusOK,
 		Message: "Success update user.",
 	})
 }
 
-// Delete function removes an user by ID
+// Delete function removes the current user by ID
 // @Summary Remove user by ID
-// @Description Remove user by ID
+// @Description Remove the current user by ID, signing out its sessions and revoking its API keys
 // @Tags users
 // @Accept json
 // @Produce json
@@ -132,28 +347,23 @@ func Update(c *fiber.Ctx) error {
 // @Success 200 {object} response.HTTP
 // @Security ApiKeyAuth
 // @Router /v1/users/{id} [delete]
-func Delete(c *fiber.Ctx) error {
-	id := c.Params("id")
-	db := database.DBConn
-
-	var user User
-	if err := db.First(&user, id).Error; err != nil {
-		switch err.Error() {
-		case "record not found":
-			return c.JSON(response.HTTP{
-				Status:  http.StatusNotFound,
-				Message: fmt.Sprintf("User with ID %v not found.", id),
-			})
-		default:
-			return c.JSON(response.HTTP{
-				Status:  http.StatusServiceUnavailable,
-				Message: err.Error(),
-			})
+func (h *Handler) Delete(c *fiber.Ctx) error {
+	user, err := h.owned(c)
+	if err != nil {
+		return err
+	}
 
-		}
+	if err := h.Sessions.RevokeAll(c, user.ID, 0); err != nil {
+		return err
 	}
 
-	db.Delete(&user)
+	if err := h.APIKeys.RevokeAll(c, user.ID); err != nil {
+		return err
+	}
+
+	if err := h.users(c).Delete(user); err != nil {
+		return err
+	}
 
 	return c.JSON(response.HTTP{
 		Success: true,
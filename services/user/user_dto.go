@@ -2,23 +2,50 @@ package user
 
 // LoginUser is a data transfer object for user login
 type LoginUser struct {
-	Email    string `json:"email" example:"dinopuguh@mycap.com"`
-	Password string `json:"password" example:"s3cr3tp45sw0rd"`
+	Email    string `json:"email" validate:"required,email" example:"dinopuguh@mycap.com"`
+	Password string `json:"password" validate:"required" example:"s3cr3tp45sw0rd"`
 }
 
 // RegisterUser is a data transfer object for create user
 type RegisterUser struct {
-	Name     string `json:"name" example:"Dino Puguh"`
-	Username string `json:"username" example:"dinopuguh"`
-	Email    string `json:"email" example:"dinopuguh@mycap.com"`
-	Password string `json:"password" example:"s3cr3tp45sw0rd"`
-	TypeID   uint   `json:"type_id" example:"1"` // (1: Free, 2: Premium, 3: Pro)
+	Name         string `json:"name" validate:"required,max=100" example:"Dino Puguh"`
+	Username     string `json:"username" validate:"required,username" example:"dinopuguh"`
+	Email        string `json:"email" validate:"required,email" example:"dinopuguh@mycap.com"`
+	Password     string `json:"password" validate:"required,password" example:"s3cr3tp45sw0rd"`
+	TypeID       uint   `json:"type_id" example:"1"` // (1: Free, 2: Premium, 3: Pro)
+	ReferralCode string `json:"referral_code" validate:"omitempty,alphanum,max=16" example:"A1B2C3D4"`
 }
 
 // UpdateUser is a data transfer object for update user
 type UpdateUser struct {
-	Name             string `json:"name" example:"Dino Puguh"`
-	RemainingTime    int64  `json:"remaining_time" example:"1800"`
-	ReachedTimeLimit bool   `json:"reached_time_limit" example:"false"`
-	TypeID           uint   `json:"type_id" example:"2"` // (1: Free, 2: Premium, 3: Pro)
+	Name string `json:"name" validate:"required,max=100" example:"Dino Puguh"`
+}
+
+// AdminUpdateUser is a data transfer object for admins updating the plan and remaining time of an user
+type AdminUpdateUser struct {
+	RemainingTime    int64 `json:"remaining_time" validate:"min=0" example:"1800"`
+	ReachedTimeLimit bool  `json:"reached_time_limit" example:"false"`
+	TypeID           uint  `json:"type_id" example:"2"` // (1: Free, 2: Premium, 3: Pro)
+}
+
+// ChangePassword is a data transfer object for change user's password
+type ChangePassword struct {
+	CurrentPassword string `json:"current_password" validate:"required" example:"s3cr3tp45sw0rd"`
+	NewPassword     string `json:"new_password" validate:"required,password" example:"n3ws3cr3tp45sw0rd"`
+}
+
+// SetPassword is a data transfer object for set password of user registered by an identity provider
+type SetPassword struct {
+	NewPassword string `json:"new_password" validate:"required,password" example:"n3ws3cr3tp45sw0rd"`
+}
+
+// ChangeEmail is a data transfer object for change user's email
+type ChangeEmail struct {
+	NewEmail string `json:"new_email" validate:"required,email" example:"dino@mycap.com"`
+	Password string `json:"password" validate:"required" example:"s3cr3tp45sw0rd"`
+}
+
+// ConfirmEmail is a data transfer object for confirm user's new email
+type ConfirmEmail struct {
+	Token string `json:"token" validate:"required" example:"5f2b1c..."`
 }
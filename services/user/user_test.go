@@ -6,9 +6,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/dinopuguh/mycap-backend/database"
+	"github.com/dinopuguh/mycap-backend/database/databasetest"
+	"github.com/dinopuguh/mycap-backend/mailer"
 	"github.com/dinopuguh/mycap-backend/response"
 	"github.com/dinopuguh/mycap-backend/routes"
 	"github.com/dinopuguh/mycap-backend/services/user"
@@ -16,16 +20,14 @@ import (
 )
 
 var (
-	createdUser *user.User
-	updatedUser *user.User
+	createdUser *user.PrivateUser
+	updatedUser *user.PrivateUser
 )
 
 func TestNew(t *testing.T) {
-	if err := database.Connect(); err != nil {
-		panic("Can't connect database.")
-	}
+	cfg := databasetest.Connect(t)
 
-	app := routes.New()
+	app := routes.New(cfg)
 
 	type args struct {
 		data          user.RegisterUser
@@ -54,7 +56,7 @@ func TestNew(t *testing.T) {
 				Name:     "Dino",
 				Email:    "dino@email.com",
 				Username: "dinopuguh16",
-				Password: "12345678",
+				Password: "d1n0p455w0rd",
 				TypeID:   1,
 			},
 			statusCode:  http.StatusOK,
@@ -88,7 +90,7 @@ func TestNew(t *testing.T) {
 				Name:     "Dino",
 				Email:    "dino@email.com",
 				Username: "dinopuguh16",
-				Password: "12345678",
+				Password: "d1n0p455w0rd",
 				TypeID:   1,
 			},
 			statusCode:  http.StatusBadRequest,
@@ -99,7 +101,7 @@ func TestNew(t *testing.T) {
 				Name:     "Dino",
 				Email:    "dino@gmail.com",
 				Username: "dinopuguh16",
-				Password: "12345678",
+				Password: "d1n0p455w0rd",
 				TypeID:   1,
 			},
 			statusCode:  http.StatusBadRequest,
@@ -110,7 +112,7 @@ func TestNew(t *testing.T) {
 				Name:     "Dino",
 				Email:    "dino@gmail.com",
 				Username: "dinopuguh16",
-				Password: "12345678",
+				Password: "d1n0p455w0rd",
 				TypeID:   1,
 			},
 			statusCode: http.StatusBadRequest,
@@ -163,11 +165,9 @@ func TestNew(t *testing.T) {
 }
 
 func TestUpdate(t *testing.T) {
-	if err := database.Connect(); err != nil {
-		panic(err.Error())
-	}
+	cfg := databasetest.Connect(t)
 
-	app := routes.New()
+	app := routes.New(cfg)
 
 	type args struct {
 		data          user.UpdateUser
@@ -183,24 +183,7 @@ func TestUpdate(t *testing.T) {
 	}{
 		{"Valid update", args{
 			data: user.UpdateUser{
-				Name:             "Dino Yang Baru",
-				RemainingTime:    1800,
-				ReachedTimeLimit: false,
-			},
-			login: user.LoginUser{
-				Email:    "dinopuguh@email.com",
-				Password: "s3cr3tp45sw0rd",
-			},
-			userID:      updatedUser.ID,
-			statusCode:  http.StatusOK,
-			contentType: "application/json",
-		}},
-		{"Valid update 2", args{
-			data: user.UpdateUser{
-				Name:             "Dino Yang Baru",
-				RemainingTime:    0,
-				ReachedTimeLimit: true,
-				TypeID:           1,
+				Name: "Dino Yang Baru",
 			},
 			login: user.LoginUser{
 				Email:    "dinopuguh@email.com",
@@ -210,20 +193,7 @@ func TestUpdate(t *testing.T) {
 			statusCode:  http.StatusOK,
 			contentType: "application/json",
 		}},
-		{"User type not found", args{
-			data: user.UpdateUser{
-				Name:   "Dino Yang Baru",
-				TypeID: 99,
-			},
-			login: user.LoginUser{
-				Email:    "dinopuguh@email.com",
-				Password: "s3cr3tp45sw0rd",
-			},
-			userID:      updatedUser.ID,
-			statusCode:  http.StatusNotFound,
-			contentType: "application/json",
-		}},
-		{"User not found", args{
+		{"Other user forbidden", args{
 			data: user.UpdateUser{
 				Name: "Dino Yang Baru",
 			},
@@ -232,7 +202,7 @@ func TestUpdate(t *testing.T) {
 				Password: "s3cr3tp45sw0rd",
 			},
 			userID:      updatedUser.ID + 1,
-			statusCode:  http.StatusNotFound,
+			statusCode:  http.StatusForbidden,
 			contentType: "application/json",
 		}},
 		{"Body parser invalid", args{
@@ -248,10 +218,7 @@ func TestUpdate(t *testing.T) {
 		}},
 		{"DB connection closed", args{
 			data: user.UpdateUser{
-				Name:             "Dino Yang Baru",
-				RemainingTime:    0,
-				ReachedTimeLimit: true,
-				TypeID:           1,
+				Name: "Dino Yang Baru",
 			},
 			login: user.LoginUser{
 				Email:    "dinopuguh@email.com",
@@ -300,13 +267,12 @@ func TestUpdate(t *testing.T) {
 }
 
 func TestGetAll(t *testing.T) {
-	if err := database.Connect(); err != nil {
-		panic("Can't connect database.")
-	}
+	cfg := databasetest.Connect(t)
 
-	app := routes.New()
+	app := routes.New(cfg)
 
 	type args struct {
+		query         string
 		expectDBError bool
 		statusCode    int
 	}
@@ -318,6 +284,26 @@ func TestGetAll(t *testing.T) {
 			expectDBError: false,
 			statusCode:    http.StatusOK,
 		}},
+		{"Valid search and filter", args{
+			query:      "?q=dino&type_id=1&created_from=2020-01-01&sort=-created_at&limit=1",
+			statusCode: http.StatusOK,
+		}},
+		{"Sort by remaining time not public", args{
+			query:      "?sort=remaining_time",
+			statusCode: http.StatusBadRequest,
+		}},
+		{"Sort not supported", args{
+			query:      "?sort=password",
+			statusCode: http.StatusBadRequest,
+		}},
+		{"Limit invalid", args{
+			query:      "?limit=1000",
+			statusCode: http.StatusBadRequest,
+		}},
+		{"Cursor invalid", args{
+			query:      "?cursor=invalid",
+			statusCode: http.StatusBadRequest,
+		}},
 		{"DB connection closed", args{
 			expectDBError: true,
 			statusCode:    http.StatusServiceUnavailable,
@@ -331,7 +317,7 @@ func TestGetAll(t *testing.T) {
 				db.Close()
 			}
 
-			req, _ := http.NewRequest(http.MethodGet, "/api/v1/users", nil)
+			req, _ := http.NewRequest(http.MethodGet, "/api/v1/users"+tt.args.query, nil)
 
 			resHTTP := new(response.HTTP)
 			res, _ := app.Test(req, -1)
@@ -345,11 +331,9 @@ func TestGetAll(t *testing.T) {
 }
 
 func TestLogin(t *testing.T) {
-	if err := database.Connect(); err != nil {
-		panic("Can't connect database.")
-	}
+	cfg := databasetest.Connect(t)
 
-	app := routes.New()
+	app := routes.New(cfg)
 
 	type args struct {
 		data        user.LoginUser
@@ -363,7 +347,7 @@ func TestLogin(t *testing.T) {
 		{"Valid login", args{
 			data: user.LoginUser{
 				Email:    "dino@email.com",
-				Password: "12345678",
+				Password: "d1n0p455w0rd",
 			},
 			statusCode:  http.StatusOK,
 			contentType: "application/json",
@@ -371,7 +355,7 @@ func TestLogin(t *testing.T) {
 		{"User not found", args{
 			data: user.LoginUser{
 				Email:    "dinopuguh@ymail.com",
-				Password: "12345678",
+				Password: "d1n0p455w0rd",
 			},
 			statusCode:  http.StatusNotFound,
 			contentType: "application/json",
@@ -387,7 +371,7 @@ func TestLogin(t *testing.T) {
 		{"Body parser invalid", args{
 			data: user.LoginUser{
 				Email:    "dino@email.com",
-				Password: "12345678",
+				Password: "d1n0p455w0rd",
 			},
 			statusCode: http.StatusBadRequest,
 		}},
@@ -409,11 +393,9 @@ func TestLogin(t *testing.T) {
 }
 
 func TestDelete(t *testing.T) {
-	if err := database.Connect(); err != nil {
-		panic("Can't connect database.")
-	}
+	cfg := databasetest.Connect(t)
 
-	app := routes.New()
+	app := routes.New(cfg)
 
 	type args struct {
 		login         user.LoginUser
@@ -483,3 +465,207 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdatePassword(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	app := routes.New(cfg)
+
+	registerBody, _ := json.Marshal(user.RegisterUser{
+		Name:     "Dino Credential",
+		Email:    "dinocredential@mycap.com",
+		Username: "dinocredential",
+		Password: "s3cr3tp45sw0rd",
+	})
+	reqRegister, _ := http.NewRequest(http.MethodPost, "/api/v1/register", bytes.NewBuffer(registerBody))
+	reqRegister.Header.Set("Content-Type", "application/json")
+
+	resHTTP := new(response.HTTP)
+	register := new(user.ResponseAuth)
+	resRegister, _ := app.Test(reqRegister, -1)
+	defer resRegister.Body.Close()
+	resBodyRegister, _ := ioutil.ReadAll(resRegister.Body)
+	json.Unmarshal(resBodyRegister, &resHTTP)
+	registerJSON, _ := json.Marshal(resHTTP.Data)
+	json.Unmarshal(registerJSON, &register)
+
+	type args struct {
+		data        user.ChangePassword
+		statusCode  int
+		contentType string
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{"Password incorrect", args{
+			data: user.ChangePassword{
+				CurrentPassword: "wr0ngp45sw0rd",
+				NewPassword:     "n3ws3cr3tp45sw0rd",
+			},
+			statusCode:  http.StatusUnauthorized,
+			contentType: "application/json",
+		}},
+		{"Body parser invalid", args{
+			data: user.ChangePassword{
+				CurrentPassword: "s3cr3tp45sw0rd",
+				NewPassword:     "n3ws3cr3tp45sw0rd",
+			},
+			statusCode: http.StatusBadRequest,
+		}},
+		{"Valid change password", args{
+			data: user.ChangePassword{
+				CurrentPassword: "s3cr3tp45sw0rd",
+				NewPassword:     "n3ws3cr3tp45sw0rd",
+			},
+			statusCode:  http.StatusOK,
+			contentType: "application/json",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqBody, _ := json.Marshal(tt.args.data)
+			req, _ := http.NewRequest(http.MethodPut, "/api/v1/account/password", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", tt.args.contentType)
+			req.Header.Set("Authorization", "Bearer "+register.AccessToken)
+
+			res, _ := app.Test(req, -1)
+			defer res.Body.Close()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			json.Unmarshal(resBody, &resHTTP)
+
+			assert.Equalf(t, tt.args.statusCode, resHTTP.Status, string(resBody))
+		})
+	}
+
+	loginBody, _ := json.Marshal(user.LoginUser{
+		Email:    "dinocredential@mycap.com",
+		Password: "n3ws3cr3tp45sw0rd",
+	})
+	reqLogin, _ := http.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewBuffer(loginBody))
+	reqLogin.Header.Set("Content-Type", "application/json")
+
+	resLogin, _ := app.Test(reqLogin, -1)
+	defer resLogin.Body.Close()
+	resBodyLogin, _ := ioutil.ReadAll(resLogin.Body)
+	json.Unmarshal(resBodyLogin, &resHTTP)
+
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, string(resBodyLogin))
+}
+
+func TestUpdateEmail(t *testing.T) {
+	cfg := databasetest.Connect(t)
+
+	recorder := new(mailer.Recorder)
+	mailer.Default = recorder
+
+	app := routes.New(cfg)
+
+	loginBody, _ := json.Marshal(user.LoginUser{
+		Email:    "dinocredential@mycap.com",
+		Password: "n3ws3cr3tp45sw0rd",
+	})
+	reqLogin, _ := http.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewBuffer(loginBody))
+	reqLogin.Header.Set("Content-Type", "application/json")
+
+	resHTTP := new(response.HTTP)
+	login := new(user.ResponseAuth)
+	resLogin, _ := app.Test(reqLogin, -1)
+	defer resLogin.Body.Close()
+	resBodyLogin, _ := ioutil.ReadAll(resLogin.Body)
+	json.Unmarshal(resBodyLogin, &resHTTP)
+	loginJSON, _ := json.Marshal(resHTTP.Data)
+	json.Unmarshal(loginJSON, &login)
+
+	type args struct {
+		data       user.ChangeEmail
+		statusCode int
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{"Password incorrect", args{
+			data: user.ChangeEmail{
+				NewEmail: "dinocredential@email.com",
+				Password: "wr0ngp45sw0rd",
+			},
+			statusCode: http.StatusUnauthorized,
+		}},
+		{"Email already exist", args{
+			data: user.ChangeEmail{
+				NewEmail: "dinocredential@mycap.com",
+				Password: "n3ws3cr3tp45sw0rd",
+			},
+			statusCode: http.StatusBadRequest,
+		}},
+		{"Valid change email", args{
+			data: user.ChangeEmail{
+				NewEmail: "dinocredential@email.com",
+				Password: "n3ws3cr3tp45sw0rd",
+			},
+			statusCode: http.StatusOK,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqBody, _ := json.Marshal(tt.args.data)
+			req, _ := http.NewRequest(http.MethodPut, "/api/v1/account/email", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+login.AccessToken)
+
+			res, _ := app.Test(req, -1)
+			defer res.Body.Close()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			json.Unmarshal(resBody, &resHTTP)
+
+			assert.Equalf(t, tt.args.statusCode, resHTTP.Status, string(resBody))
+		})
+	}
+
+	message, ok := recorder.Last("dinocredential@email.com")
+	if !assert.True(t, ok) {
+		return
+	}
+	token := strings.TrimPrefix(regexp.MustCompile(`token: [0-9a-f]+`).FindString(message.Body), "token: ")
+
+	confirmBody, _ := json.Marshal(user.ConfirmEmail{Token: token})
+	reqConfirm, _ := http.NewRequest(http.MethodPost, "/api/v1/account/email/confirm", bytes.NewBuffer(confirmBody))
+	reqConfirm.Header.Set("Content-Type", "application/json")
+
+	resConfirm, _ := app.Test(reqConfirm, -1)
+	defer resConfirm.Body.Close()
+	resBodyConfirm, _ := ioutil.ReadAll(resConfirm.Body)
+	json.Unmarshal(resBodyConfirm, &resHTTP)
+
+	assert.Equalf(t, http.StatusOK, resHTTP.Status, string(resBodyConfirm))
+
+	reqSessions, _ := http.NewRequest(http.MethodGet, "/api/v1/sessions", nil)
+	reqSessions.Header.Set("Authorization", "Bearer "+login.AccessToken)
+
+	resSessions, _ := app.Test(reqSessions, -1)
+	defer resSessions.Body.Close()
+	resBodySessions, _ := ioutil.ReadAll(resSessions.Body)
+	json.Unmarshal(resBodySessions, &resHTTP)
+
+	assert.Equalf(t, http.StatusUnauthorized, resHTTP.Status, string(resBodySessions))
+
+	loginBody, _ = json.Marshal(user.LoginUser{
+		Email:    "dinocredential@email.com",
+		Password: "n3ws3cr3tp45sw0rd",
+	})
+	reqLogin, _ = http.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewBuffer(loginBody))
+	reqLogin.Header.Set("Content-Type", "application/json")
+
+	resLogin, _ = app.Test(reqLogin, -1)
+	defer resLogin.Body.Close()
+	resBodyLogin, _ = ioutil.ReadAll(resLogin.Body)
+	json.Unmarshal(resBodyLogin, &resHTTP)
+	loginJSON, _ = json.Marshal(resHTTP.Data)
+	json.Unmarshal(loginJSON, &login)
+
+	endpoint := fmt.Sprintf("/api/v1/users/%d", login.User.ID)
+	reqDeleteUser, _ := http.NewRequest(http.MethodDelete, endpoint, nil)
+	reqDeleteUser.Header.Set("Authorization", "Bearer "+login.AccessToken)
+	app.Test(reqDeleteUser, -1)
+}
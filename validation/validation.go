@@ -0,0 +1,140 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// MinPasswordLength is the shortest password accepted
+	MinPasswordLength = 8
+	// MaxPasswordLength is the longest password accepted, bcrypt ignores bytes after 72
+	MaxPasswordLength = 72
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.]{3,30}$`)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	v.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return Password(fl.Field().String())
+	})
+	v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return usernamePattern.MatchString(fl.Field().String())
+	})
+
+	return v
+}
+
+// Password reports whether a password is long enough and contains at least a letter and a digit
+func Password(password string) bool {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return false
+	}
+
+	var letter, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+
+	return letter && digit
+}
+
+// FieldError describes why a field of the request body is invalid
+type FieldError struct {
+	Field   string `json:"field" example:"email"`
+	Message string `json:"message" example:"must be a valid email address"`
+}
+
+// Errors is a list of invalid fields, it's used as response data of an invalid request body
+type Errors []FieldError
+
+// Struct validates a request body against its validate tags and returns a validation error with
+// Errors listing every invalid field, or nil when the body is valid
+func Struct(s interface{}) error {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	invalid, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+
+	errors := make(Errors, 0, len(invalid))
+	for _, fieldErr := range invalid {
+		errors = append(errors, FieldError{
+			Field:   field(fieldErr),
+			Message: message(fieldErr),
+		})
+	}
+
+	return apperror.Validation("validation_failed", "Request body invalid.", errors)
+}
+
+// field returns the JSON path of the field without the top level struct name
+func field(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func message(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required", "required_without":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "password":
+		return fmt.Sprintf("must be %d to %d characters with at least a letter and a digit", MinPasswordLength, MaxPasswordLength)
+	case "username":
+		return "must be 3 to 30 letters, digits, underscores or dots"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fieldErr.Param()), ", ")
+	case "min", "gte":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fieldErr.Param())
+		}
+		if fieldErr.Kind() == reflect.Slice {
+			return fmt.Sprintf("must have at least %s items", fieldErr.Param())
+		}
+		return "must be at least " + fieldErr.Param()
+	case "max", "lte":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fieldErr.Param())
+		}
+		if fieldErr.Kind() == reflect.Slice {
+			return fmt.Sprintf("must have at most %s items", fieldErr.Param())
+		}
+		return "must be at most " + fieldErr.Param()
+	case "gt":
+		return "must be greater than " + fieldErr.Param()
+	default:
+		return "is invalid"
+	}
+}
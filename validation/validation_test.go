@@ -0,0 +1,63 @@
+package validation_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/dinopuguh/mycap-backend/apperror"
+	"github.com/dinopuguh/mycap-backend/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+type register struct {
+	Username string `json:"username" validate:"required,username"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,password"`
+	Type     string `json:"type" validate:"omitempty,oneof=Group Conference"`
+	Minutes  int64  `json:"minutes" validate:"min=0"`
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   register
+		fields []string
+	}{
+		{"Valid", register{Username: "dino_puguh.16", Email: "dino@mycap.com", Password: "s3cr3tp45sw0rd", Type: "Group"}, nil},
+		{"Missing fields", register{}, []string{"username", "email", "password"}},
+		{"Email invalid", register{Username: "dino", Email: "dino", Password: "s3cr3tp45sw0rd"}, []string{"email"}},
+		{"Username charset", register{Username: "dino puguh", Email: "dino@mycap.com", Password: "s3cr3tp45sw0rd"}, []string{"username"}},
+		{"Username too short", register{Username: "do", Email: "dino@mycap.com", Password: "s3cr3tp45sw0rd"}, []string{"username"}},
+		{"Password without digit", register{Username: "dino", Email: "dino@mycap.com", Password: "secretpassword"}, []string{"password"}},
+		{"Every invalid field", register{Username: "d!", Email: "dino@", Password: "1234", Type: "Chat Room", Minutes: -1}, []string{"username", "email", "password", "type", "minutes"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validation.Struct(tt.data)
+			if tt.fields == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			domainErr, ok := apperror.As(err)
+			assert.True(t, ok)
+			assert.Equal(t, http.StatusBadRequest, domainErr.Status())
+			assert.Equal(t, "Request body invalid.", err.Error())
+			errors := domainErr.Data.(validation.Errors)
+
+			fields := []string{}
+			for _, fieldErr := range errors {
+				assert.NotEmpty(t, fieldErr.Message)
+				fields = append(fields, fieldErr.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
+
+func TestPassword(t *testing.T) {
+	assert.True(t, validation.Password("s3cr3tp45sw0rd"))
+	assert.False(t, validation.Password("12345678"))
+	assert.False(t, validation.Password("a1"))
+	assert.False(t, validation.Password("a1234567890123456789012345678901234567890123456789012345678901234567890123"))
+}